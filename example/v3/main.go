@@ -6,8 +6,6 @@ import (
 	"log"
 	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/prilive-com/telegramreceiver/v2/telegramreceiver"
@@ -20,12 +18,6 @@ import (
 // Optional: config.yaml file in the same directory
 
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
 	// Option 1: Simple programmatic configuration
 	token := os.Getenv("TELEGRAM_BOT_TOKEN")
 	if token == "" {
@@ -48,26 +40,20 @@ func main() {
 	//     telegramreceiver.WithLogger(customLogger),
 	// )
 
-	if err := client.Start(ctx); err != nil {
-		log.Fatalf("Failed to start: %v", err)
-	}
-	defer client.Stop()
-
 	slog.Info("Telegram receiver running. Press Ctrl+C to stop.",
 		"mode", client.Config().Mode,
 	)
 
 	// Consume updates
-	for {
-		select {
-		case update := <-client.Updates():
+	go func() {
+		for update := range client.Updates() {
 			handleUpdate(update)
-
-		case sig := <-sigChan:
-			slog.Info("Received shutdown signal", "signal", sig)
-			cancel()
-			return
 		}
+	}()
+
+	// Blocks until SIGINT/SIGTERM, then stops the client
+	if err := telegramreceiver.RunWithSignals(context.Background(), client); err != nil {
+		log.Fatalf("Failed to start: %v", err)
 	}
 }
 
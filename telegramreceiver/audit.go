@@ -0,0 +1,24 @@
+package telegramreceiver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+)
+
+// auditUpdate writes an audit entry proving receipt of upd: its ID,
+// receive time and the SHA-256 of raw. The content itself is never
+// logged. A nil logger disables auditing.
+func auditUpdate(logger *slog.Logger, source string, upd TelegramUpdate, raw []byte) {
+	if logger == nil {
+		return
+	}
+	sum := sha256.Sum256(raw)
+	logger.Info("update received",
+		"update_id", upd.UpdateID,
+		"received_at", upd.ReceivedAt,
+		"sha256", hex.EncodeToString(sum[:]),
+		"size", len(raw),
+		"source", source,
+	)
+}
@@ -0,0 +1,98 @@
+package telegramreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// auditBody is a known update whose SHA-256 is auditSum.
+const (
+	auditBody = `{"update_id":42,"message":{"message_id":1,"date":0,"text":"secret text"}}`
+	auditSum  = "88bc902181a8f960482c1240bdf805888c2aef06f88b38ca677e4bbac19f2c8a"
+)
+
+// checkAuditEntry asserts that buf holds one audit entry for auditBody
+// without its content.
+func checkAuditEntry(t *testing.T, buf *lockedBuffer, source string) {
+	t.Helper()
+	lines := buf.lines()
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d: %q", len(lines), lines)
+	}
+	if strings.Contains(lines[0], "secret text") {
+		t.Errorf("audit entry contains the update content: %s", lines[0])
+	}
+
+	var entry struct {
+		Msg        string    `json:"msg"`
+		UpdateID   int       `json:"update_id"`
+		ReceivedAt time.Time `json:"received_at"`
+		SHA256     string    `json:"sha256"`
+		Size       int       `json:"size"`
+		Source     string    `json:"source"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("decoding audit entry: %v", err)
+	}
+	if entry.UpdateID != 42 || entry.SHA256 != auditSum || entry.Size != len(auditBody) || entry.Source != source {
+		t.Errorf("audit entry = %+v, want update 42 with sha256 %s", entry, auditSum)
+	}
+	if entry.ReceivedAt.IsZero() {
+		t.Error("audit entry has no received_at")
+	}
+}
+
+func TestWebhookHandler_AuditLog(t *testing.T) {
+	var buf lockedBuffer
+	updates := make(chan TelegramUpdate, 1)
+	handler := newTestHandler(updates, WithWebhookAuditLog(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(auditBody))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	<-updates
+
+	checkAuditEntry(t, &buf, "webhook")
+}
+
+func TestLongPollingClient_AuditLog(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Write([]byte(`{"ok":true,"result":[` + auditBody + `]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	var buf lockedBuffer
+	updates := make(chan TelegramUpdate, 1)
+	client := newTestPollingClient(server, updates, WithPollAuditLog(slog.New(slog.NewJSONHandler(&buf, nil))))
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	select {
+	case upd := <-updates:
+		if upd.Message == nil || upd.Message.Text != "secret text" {
+			t.Errorf("update not decoded: %+v", upd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update polled")
+	}
+
+	checkAuditEntry(t, &buf, "polling")
+}
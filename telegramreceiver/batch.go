@@ -0,0 +1,59 @@
+package telegramreceiver
+
+import "time"
+
+// batchUpdates coalesces updates from in into slices of up to size, emitted
+// when full or maxWait after the first update of the batch. With maxWait
+// <= 0 a batch is emitted as soon as no further update is immediately
+// available. Once stop is closed, updates still buffered in in are flushed
+// and out is closed.
+func batchUpdates(in <-chan TelegramUpdate, stop <-chan struct{}, out chan<- []TelegramUpdate, size int, maxWait time.Duration) {
+	defer close(out)
+
+	var batch []TelegramUpdate
+	var timer *time.Timer
+	var timeout <-chan time.Time
+
+	flush := func() {
+		if timer != nil {
+			timer.Stop()
+			timer, timeout = nil, nil
+		}
+		if len(batch) > 0 {
+			out <- batch
+			batch = nil
+		}
+	}
+	add := func(upd TelegramUpdate) {
+		batch = append(batch, upd)
+		if len(batch) >= size || (maxWait <= 0 && len(in) == 0) {
+			flush()
+		} else if timer == nil && maxWait > 0 {
+			timer = time.NewTimer(maxWait)
+			timeout = timer.C
+		}
+	}
+
+	for {
+		select {
+		case <-stop:
+			for {
+				select {
+				case upd := <-in:
+					batch = append(batch, upd)
+					if len(batch) >= size {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		case upd := <-in:
+			add(upd)
+		case <-timeout:
+			timer, timeout = nil, nil
+			flush()
+		}
+	}
+}
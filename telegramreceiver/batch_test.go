@@ -0,0 +1,104 @@
+package telegramreceiver
+
+import (
+	"testing"
+	"time"
+)
+
+func receiveBatch(t *testing.T, out <-chan []TelegramUpdate) []TelegramUpdate {
+	t.Helper()
+	select {
+	case batch, ok := <-out:
+		if !ok {
+			t.Fatal("batch channel closed unexpectedly")
+		}
+		return batch
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for batch")
+		return nil
+	}
+}
+
+func batchIDs(batch []TelegramUpdate) []int {
+	ids := make([]int, len(batch))
+	for i, upd := range batch {
+		ids[i] = upd.UpdateID
+	}
+	return ids
+}
+
+func TestBatchUpdates_ByCount(t *testing.T) {
+	in := make(chan TelegramUpdate, 10)
+	stop := make(chan struct{})
+	out := make(chan []TelegramUpdate)
+	go batchUpdates(in, stop, out, 3, time.Hour)
+	defer close(stop)
+
+	for i := 1; i <= 6; i++ {
+		in <- TelegramUpdate{UpdateID: i}
+	}
+	for _, want := range [][]int{{1, 2, 3}, {4, 5, 6}} {
+		got := batchIDs(receiveBatch(t, out))
+		if len(got) != 3 || got[0] != want[0] || got[2] != want[2] {
+			t.Errorf("batch = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBatchUpdates_ByTimeout(t *testing.T) {
+	in := make(chan TelegramUpdate, 10)
+	stop := make(chan struct{})
+	out := make(chan []TelegramUpdate)
+	go batchUpdates(in, stop, out, 100, 20*time.Millisecond)
+	defer close(stop)
+
+	in <- TelegramUpdate{UpdateID: 1}
+	in <- TelegramUpdate{UpdateID: 2}
+	if got := batchIDs(receiveBatch(t, out)); len(got) != 2 {
+		t.Errorf("batch = %v, want [1 2]", got)
+	}
+
+	// The timer restarts with the next batch
+	in <- TelegramUpdate{UpdateID: 3}
+	if got := batchIDs(receiveBatch(t, out)); len(got) != 1 || got[0] != 3 {
+		t.Errorf("batch = %v, want [3]", got)
+	}
+}
+
+func TestBatchUpdates_ZeroWaitSendsAvailable(t *testing.T) {
+	in := make(chan TelegramUpdate, 10)
+	stop := make(chan struct{})
+	out := make(chan []TelegramUpdate)
+	defer close(stop)
+
+	in <- TelegramUpdate{UpdateID: 1}
+	in <- TelegramUpdate{UpdateID: 2}
+	go batchUpdates(in, stop, out, 100, 0)
+	if got := batchIDs(receiveBatch(t, out)); len(got) != 2 {
+		t.Errorf("batch = %v, want [1 2]", got)
+	}
+}
+
+func TestClient_BatchUpdatesFlushesOnStop(t *testing.T) {
+	client, err := New(testBotToken, WithWebhook(8443, "secret"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	batches := client.BatchUpdates(10, time.Hour)
+
+	client.updates <- TelegramUpdate{UpdateID: 1}
+	client.updates <- TelegramUpdate{UpdateID: 2}
+	client.Stop()
+
+	if got := batchIDs(receiveBatch(t, batches)); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("flushed batch = %v, want [1 2]", got)
+	}
+	select {
+	case _, ok := <-batches:
+		if ok {
+			t.Error("expected batch channel to be closed after Stop")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("batch channel not closed after Stop")
+	}
+}
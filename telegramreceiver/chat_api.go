@@ -0,0 +1,46 @@
+package telegramreceiver
+
+import "context"
+
+// getChatRequest is the request body for getChat API call.
+type getChatRequest struct {
+	ChatID int64 `json:"chat_id"`
+}
+
+// getChatMemberRequest is the request body for getChatMember API call.
+type getChatMemberRequest struct {
+	ChatID int64 `json:"chat_id"`
+	UserID int64 `json:"user_id"`
+}
+
+// GetChat retrieves up-to-date information about a chat.
+func GetChat(ctx context.Context, botToken SecretToken, chatID int64) (*Chat, error) {
+	return GetChatWithClient(ctx, defaultHTTPClient(), botToken, chatID)
+}
+
+// GetChatWithClient retrieves chat information using a custom HTTP client.
+// Use this for testing or when you need custom HTTP configuration.
+func GetChatWithClient(ctx context.Context, client httpClient, botToken SecretToken, chatID int64) (*Chat, error) {
+	chat, err := call[Chat](ctx, client, botToken, "getChat", getChatRequest{ChatID: chatID})
+	if err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// GetChatMember retrieves information about a member of a chat.
+// Use ChatMember.IsAdmin() for admin-only command checks.
+func GetChatMember(ctx context.Context, botToken SecretToken, chatID, userID int64) (*ChatMember, error) {
+	return GetChatMemberWithClient(ctx, defaultHTTPClient(), botToken, chatID, userID)
+}
+
+// GetChatMemberWithClient retrieves chat member information using a custom HTTP client.
+// Use this for testing or when you need custom HTTP configuration.
+func GetChatMemberWithClient(ctx context.Context, client httpClient, botToken SecretToken, chatID, userID int64) (*ChatMember, error) {
+	req := getChatMemberRequest{ChatID: chatID, UserID: userID}
+	member, err := call[ChatMember](ctx, client, botToken, "getChatMember", req)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
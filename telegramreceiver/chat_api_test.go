@@ -0,0 +1,148 @@
+package telegramreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAPIClient(server *httptest.Server) *http.Client {
+	return &http.Client{
+		Transport: &testTransport{
+			baseURL:    server.URL,
+			httpClient: server.Client(),
+		},
+	}
+}
+
+func TestGetChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/getChat") {
+			t.Errorf("expected getChat in path, got %s", r.URL.Path)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var req getChatRequest
+		json.Unmarshal(body, &req)
+		if req.ChatID != -100123 {
+			t.Errorf("expected chat_id -100123, got %d", req.ChatID)
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok": true,
+			"result": map[string]any{
+				"id":          -100123,
+				"type":        "supergroup",
+				"title":       "Test Group",
+				"is_forum":    true,
+				"description": "A group for tests",
+			},
+		})
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	chat, err := GetChatWithClient(ctx, newTestAPIClient(server), SecretToken("test-token"), -100123)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chat.Title != "Test Group" || !chat.IsForum || chat.Description != "A group for tests" {
+		t.Errorf("unexpected chat: %+v", chat)
+	}
+}
+
+func TestGetChatMember(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     func(w http.ResponseWriter, r *http.Request)
+		wantErr     bool
+		wantCode    int
+		wantAdmin   bool
+		wantCanPin  bool
+		errContains string
+	}{
+		{
+			name: "administrator",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				var req getChatMemberRequest
+				json.Unmarshal(body, &req)
+				if req.ChatID != -100123 || req.UserID != 42 {
+					t.Errorf("unexpected request: %+v", req)
+				}
+
+				json.NewEncoder(w).Encode(map[string]any{
+					"ok": true,
+					"result": map[string]any{
+						"status":           "administrator",
+						"user":             map[string]any{"id": 42, "is_bot": false, "first_name": "Admin"},
+						"can_pin_messages": true,
+					},
+				})
+			},
+			wantAdmin:  true,
+			wantCanPin: true,
+		},
+		{
+			name: "user not found",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]any{
+					"ok":          false,
+					"error_code":  400,
+					"description": "Bad Request: user not found",
+				})
+			},
+			wantErr:     true,
+			wantCode:    400,
+			errContains: "user not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(tt.handler))
+			defer server.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			member, err := GetChatMemberWithClient(ctx, newTestAPIClient(server), SecretToken("test-token"), -100123, 42)
+
+			if tt.wantErr {
+				var apiErr *TelegramAPIError
+				if !errors.As(err, &apiErr) {
+					t.Fatalf("expected TelegramAPIError, got %v", err)
+				}
+				if apiErr.Code != tt.wantCode {
+					t.Errorf("expected code %d, got %d", tt.wantCode, apiErr.Code)
+				}
+				if !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("expected error containing %q, got %q", tt.errContains, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if member.IsAdmin() != tt.wantAdmin {
+				t.Errorf("IsAdmin() = %v, want %v", member.IsAdmin(), tt.wantAdmin)
+			}
+			if member.CanPinMessages != tt.wantCanPin {
+				t.Errorf("CanPinMessages = %v, want %v", member.CanPinMessages, tt.wantCanPin)
+			}
+			if member.User == nil || member.User.ID != 42 {
+				t.Errorf("unexpected user: %+v", member.User)
+			}
+		})
+	}
+}
@@ -1,12 +1,24 @@
 package telegramreceiver
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"reflect"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -19,12 +31,38 @@ import (
 // Client is the main entry point for receiving Telegram updates.
 // Use New() or NewFromConfig() to create a Client.
 type Client struct {
+	mu      sync.RWMutex // Guards config against concurrent Reload
 	config  ClientConfig
 	updates chan TelegramUpdate
 
+	// Channel the receivers write to: updates, or the spool/fanout input.
+	// The spool and fanout goroutines run from the first Start or
+	// WebhookHandler call until Stop.
+	sink         chan TelegramUpdate
+	spool        *spool
+	fanout       *fanout
+	pipelineOnce sync.Once
+
 	// Internal components (created on Start)
 	pollingClient  *LongPollingClient
 	webhookHandler *WebhookHandler
+	hybrid         *hybridReceiver
+
+	// Pushes Stats to a Pushgateway (see WithMetricsPushGateway)
+	pusher *metricsPusher
+
+	// Set by Pause, applied to receivers created later
+	paused atomic.Bool
+
+	// Drives the hybrid mode check and retry intervals
+	clock clock
+
+	// Marked shutting down by Stop (see WithRejectOnShutdown)
+	state ServerState
+
+	// Closed by Stop to flush BatchUpdates
+	stopped  chan struct{}
+	stopOnce sync.Once
 }
 
 // validate is the shared validator instance
@@ -77,6 +115,13 @@ func New(botToken string, opts ...Option) (*Client, error) {
 		opt.apply(&cfg)
 	}
 
+	if err := resolveBotTokenFile(&cfg); err != nil {
+		return nil, err
+	}
+	if err := resolveEnvReferences(&cfg); err != nil {
+		return nil, err
+	}
+
 	// Validate
 	if err := validateClientConfig(&cfg); err != nil {
 		return nil, err
@@ -124,16 +169,28 @@ func LoadClientConfig(configPath string, opts ...Option) (*ClientConfig, error)
 	}
 
 	// 3. ENVIRONMENT VARIABLES (TELEGRAM_*)
-	if err := k.Load(env.Provider("TELEGRAM_", ".", func(s string) string {
+	var envErr error
+	if err := k.Load(env.ProviderWithValue("TELEGRAM_", ".", func(key, value string) (string, interface{}) {
 		// TELEGRAM_BOT_TOKEN -> bot_token
-		key := strings.ToLower(strings.TrimPrefix(s, "TELEGRAM_"))
-		return strings.ReplaceAll(key, "_", ".")
+		key = strings.ToLower(strings.TrimPrefix(key, "TELEGRAM_"))
+		if key == "allowed_updates" {
+			// Comma-separated list or JSON array
+			list, err := parseList(value)
+			if err != nil {
+				envErr = fmt.Errorf("TELEGRAM_ALLOWED_UPDATES: %w", err)
+			}
+			return key, list
+		}
+		return key, value
 	}), nil); err != nil {
 		return nil, fmt.Errorf("loading env vars: %w", err)
 	}
+	if envErr != nil {
+		return nil, envErr
+	}
 
-	// Unmarshal to struct
-	var cfg ClientConfig
+	// Unmarshal to struct, keeping non-serializable defaults (Logger)
+	cfg := DefaultClientConfig()
 	if err := k.Unmarshal("", &cfg); err != nil {
 		return nil, fmt.Errorf("unmarshalling config: %w", err)
 	}
@@ -143,6 +200,13 @@ func LoadClientConfig(configPath string, opts ...Option) (*ClientConfig, error)
 		opt.apply(&cfg)
 	}
 
+	if err := resolveBotTokenFile(&cfg); err != nil {
+		return nil, err
+	}
+	if err := resolveEnvReferences(&cfg); err != nil {
+		return nil, err
+	}
+
 	// Validate
 	if err := validateClientConfig(&cfg); err != nil {
 		return nil, err
@@ -151,6 +215,73 @@ func LoadClientConfig(configPath string, opts ...Option) (*ClientConfig, error)
 	return &cfg, nil
 }
 
+// resolveBotTokenFile loads the bot token from BotTokenFile when configured.
+// The file takes precedence over an inline BotToken.
+func resolveBotTokenFile(cfg *ClientConfig) error {
+	if cfg.BotTokenFile == "" {
+		return nil
+	}
+	token, err := readBotTokenFile(cfg.BotTokenFile)
+	if err != nil {
+		return fmt.Errorf("bot_token_file: %w", err)
+	}
+	cfg.BotToken = token.Value()
+	return nil
+}
+
+// envVarName matches a valid environment variable name, and envReference
+// a whole value of the form ${ENV_VAR}.
+var (
+	envVarName   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	envReference = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+)
+
+// resolveWebhookSecretEnv sets WebhookSecret from the environment variable
+// named by WebhookSecretEnv (see WithSecretTokenFromEnv).
+func resolveWebhookSecretEnv(cfg *ClientConfig) error {
+	if !cfg.webhookSecretFromEnv && cfg.WebhookSecretEnv == "" {
+		return nil
+	}
+	name := cfg.WebhookSecretEnv
+	if !envVarName.MatchString(name) {
+		return fmt.Errorf("webhook_secret: invalid environment variable name %q", name)
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return fmt.Errorf("webhook_secret: environment variable %s is not set", name)
+	}
+	cfg.WebhookSecret = value
+	return nil
+}
+
+// resolveEnvReferences replaces secrets written as ${ENV_VAR}, e.g. in a
+// config file, with the value of that environment variable. Only whole
+// values are replaced; anything else is kept literally.
+func resolveEnvReferences(cfg *ClientConfig) error {
+	if err := resolveWebhookSecretEnv(cfg); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		key   string
+		value *string
+	}{
+		{"bot_token", &cfg.BotToken},
+		{"webhook_secret", &cfg.WebhookSecret},
+		{"webhook_secret_previous", &cfg.WebhookSecretPrevious},
+	} {
+		m := envReference.FindStringSubmatch(*f.value)
+		if m == nil {
+			continue
+		}
+		value, ok := os.LookupEnv(m[1])
+		if !ok {
+			return fmt.Errorf("%s: environment variable %s is not set (referenced as %s)", f.key, m[1], m[0])
+		}
+		*f.value = value
+	}
+	return nil
+}
+
 // validateClientConfig validates the configuration and returns user-friendly errors.
 func validateClientConfig(cfg *ClientConfig) error {
 	// Custom validation logic
@@ -162,72 +293,668 @@ func validateClientConfig(cfg *ClientConfig) error {
 		return fmt.Errorf("bot_token: %w (format: 123456789:ABCdefGHI...)", err)
 	}
 
-	if cfg.Mode == ModeLongPolling {
+	if cfg.Mode == ModeLongPolling || cfg.Mode == ModeHybrid {
 		if cfg.PollingTimeout < 0 || cfg.PollingTimeout > 60 {
 			return fmt.Errorf("polling_timeout: must be between 0 and 60")
 		}
 		if cfg.PollingLimit < 1 || cfg.PollingLimit > 100 {
 			return fmt.Errorf("polling_limit: must be between 1 and 100")
 		}
+		if cfg.PollingAdaptiveMax > 0 {
+			if cfg.PollingAdaptiveMin < 0 || cfg.PollingAdaptiveMax > 60 || cfg.PollingAdaptiveMin > cfg.PollingAdaptiveMax {
+				return fmt.Errorf("polling_adaptive_min/max: must satisfy 0 <= min <= max <= 60")
+			}
+		}
+		switch cfg.RetryBackoffReset {
+		case "", BackoffResetImmediate, BackoffResetDecay:
+		default:
+			return fmt.Errorf("retry_backoff_reset: must be immediate or decay")
+		}
+		if cfg.PollingUnhealthyThreshold < 0 || (cfg.PollingMaxErrors > 0 && cfg.PollingUnhealthyThreshold >= cfg.PollingMaxErrors) {
+			return fmt.Errorf("polling_unhealthy_threshold: must be between 0 and polling_max_errors - 1")
+		}
+		if cfg.PollingMinInterval < 0 {
+			return fmt.Errorf("polling_min_interval: must not be negative")
+		}
+		longestPoll := cfg.PollingTimeout
+		if cfg.PollingAdaptiveMax > 0 {
+			longestPoll = cfg.PollingAdaptiveMax
+		}
+		if cfg.PollingHTTPTimeout > 0 && cfg.PollingHTTPTimeout < minPollHTTPTimeout(longestPoll) {
+			return fmt.Errorf("polling_http_timeout: must be at least %v for polling_timeout %d", minPollHTTPTimeout(longestPoll), longestPoll)
+		}
+	}
+
+	if cfg.SpoolCapacity < 0 {
+		return fmt.Errorf("spool_capacity: must not be negative")
+	}
+
+	if cfg.ProxyURL != "" {
+		if _, err := parseProxyURL(cfg.ProxyURL); err != nil {
+			return fmt.Errorf("proxy_url: %w", err)
+		}
+	}
+
+	if cfg.MetricsPushURL != "" {
+		u, err := url.Parse(cfg.MetricsPushURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("metrics_push_url: must be an http or https URL")
+		}
+		if cfg.MetricsPushInterval <= 0 {
+			return fmt.Errorf("metrics_push_interval: must be positive when metrics_push_url is set")
+		}
+	}
+
+	if cfg.WebhookMaxConcurrentRequests < 0 {
+		return fmt.Errorf("webhook_max_concurrent_requests: must not be negative")
+	}
+	if cfg.WebhookMaxConnections < 0 || cfg.WebhookMaxConnections > 100 {
+		return fmt.Errorf("webhook_max_connections: must be between 1 and 100 (0 = default of 40)")
+	}
+	if cfg.WebhookIPAddress != "" && net.ParseIP(cfg.WebhookIPAddress) == nil {
+		return fmt.Errorf("webhook_ip_address: must be an IP address")
+	}
+	if cfg.WebhookRequestLogLevel != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(cfg.WebhookRequestLogLevel)); err != nil {
+			return fmt.Errorf("webhook_request_log_level: must be debug, info, warn or error")
+		}
+	}
+
+	if cfg.StartupTimeout < 0 {
+		return fmt.Errorf("startup_timeout: must not be negative")
+	}
+	if cfg.HandlerTimeout < 0 {
+		return fmt.Errorf("handler_timeout: must not be negative")
+	}
+
+	if cfg.DropLogInterval < 0 {
+		return fmt.Errorf("drop_log_interval: must not be negative")
 	}
 
-	if cfg.Mode == ModeWebhook {
+	if cfg.Mode == ModeWebhook || cfg.Mode == ModeHybrid {
 		if cfg.WebhookPort < 1 || cfg.WebhookPort > 65535 {
 			return fmt.Errorf("webhook_port: must be between 1 and 65535")
 		}
 	}
 
+	if cfg.Mode == ModeHybrid {
+		if cfg.WebhookURL == "" {
+			return fmt.Errorf("webhook_url: required in hybrid mode to register the webhook again")
+		}
+		if cfg.HybridCheckInterval < 0 || cfg.HybridRetryInterval < 0 {
+			return fmt.Errorf("hybrid_check_interval/hybrid_retry_interval: must not be negative")
+		}
+	}
+
 	return nil
 }
 
 // newClient creates the internal client from validated config.
 func newClient(cfg ClientConfig) (*Client, error) {
-	return &Client{
+	c := &Client{
 		config:  cfg,
 		updates: make(chan TelegramUpdate, 100),
-	}, nil
+		stopped: make(chan struct{}),
+		clock:   realClock{},
+	}
+	// Pipeline: receivers -> [spool] -> [fanout] -> channels
+	c.sink = c.updates
+	if cfg.TypedChannels {
+		c.fanout = newFanout(c.updates)
+		c.sink = c.fanout.in
+	}
+	if cfg.SpoolCapacity > 0 {
+		c.spool = newSpool(cfg.SpoolCapacity, c.sink)
+		c.sink = c.spool.in
+	}
+	return c, nil
 }
 
 // Config returns a copy of the client configuration.
 func (c *Client) Config() ClientConfig {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.config
 }
 
+// EffectiveConfig returns the fully resolved configuration (defaults, file,
+// environment and options) with the bot token, webhook secrets and proxy
+// password redacted, so it can be logged or printed to confirm which
+// settings won. Use Config for the raw values.
+func (c *Client) EffectiveConfig() ClientConfig {
+	return c.Config().redacted()
+}
+
+// Reload applies a new configuration to a running client without dropping
+// in-flight requests or restarting the listener.
+//
+// Rate limit, burst, max body size, webhook secrets and allowed updates
+// take effect immediately. In webhook mode, and in hybrid mode while the
+// webhook is used, changed allowed updates are sent to Telegram with
+// setWebhook; without WebhookURL, register the new filter yourself. Mode,
+// webhook port, bot token, spool capacity and typed channels cannot be
+// changed and return ErrNotReloadable. Other settings are stored and used
+// on the next Start. To change log levels at runtime, build the Logger on
+// a slog.LevelVar and call Set on it.
+//
+//	cfg := client.Config()
+//	cfg.RateLimitRequests = 50
+//	if err := client.Reload(cfg); err != nil { ... }
+//
+// Reload is not tied to a signal; to reload on SIGHUP, wire it up yourself:
+//
+//	hup := make(chan os.Signal, 1)
+//	signal.Notify(hup, syscall.SIGHUP)
+//	for range hup {
+//	    cfg, err := telegramreceiver.LoadClientConfig(path)
+//	    if err == nil {
+//	        err = client.Reload(*cfg)
+//	    }
+//	    ...
+//	}
+func (c *Client) Reload(cfg ClientConfig) error {
+	if err := resolveBotTokenFile(&cfg); err != nil {
+		return err
+	}
+	if err := validateClientConfig(&cfg); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	err := checkReloadable(c.config, cfg)
+	refilter := cfg.WebhookURL != "" && c.webhookActive() &&
+		!slices.Equal(cfg.AllowedUpdates, c.config.AllowedUpdates)
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	// Telegram filters webhook deliveries, so a new filter must be registered
+	if refilter {
+		if err := setWebhookFor(context.Background(), cfg, cfg.AllowedUpdates); err != nil {
+			return fmt.Errorf("allowed_updates: %w", err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.webhookHandler != nil {
+		c.webhookHandler.reload(cfg.RateLimitRequests, cfg.RateLimitBurst, cfg.MaxBodySize)
+		c.webhookHandler.setSecrets(cfg.WebhookSecret, cfg.WebhookSecretPrevious)
+	}
+	if poller := c.poller(); poller != nil {
+		poller.setAllowedUpdates(cfg.AllowedUpdates)
+	}
+
+	c.config = cfg
+	return nil
+}
+
 // Updates returns the channel for receiving Telegram updates.
+// With WithTypedChannels it only carries update types without a typed channel.
 func (c *Client) Updates() <-chan TelegramUpdate {
 	return c.updates
 }
 
-// Start begins receiving updates based on the configured mode.
-func (c *Client) Start(ctx context.Context) error {
+// BatchUpdates consumes Updates() and delivers them in slices of up to size,
+// for bulk sinks such as database writers. A batch is sent when it is full
+// or maxWait after its first update arrived; with maxWait <= 0 it is sent as
+// soon as no further update is immediately waiting. After Stop the partial
+// batch is flushed and the channel closed, so read it until closed. Call it
+// once and do not read Updates() alongside it.
+func (c *Client) BatchUpdates(size int, maxWait time.Duration) <-chan []TelegramUpdate {
+	out := make(chan []TelegramUpdate)
+	go batchUpdates(c.updates, c.stopped, out, max(size, 1), maxWait)
+	return out
+}
+
+// Messages returns the channel of new messages when WithTypedChannels is
+// enabled, and nil otherwise.
+func (c *Client) Messages() <-chan *Message {
+	if c.fanout == nil {
+		return nil
+	}
+	return c.fanout.messages
+}
+
+// EditedMessages returns the channel of edited messages when
+// WithTypedChannels is enabled, and nil otherwise.
+func (c *Client) EditedMessages() <-chan *Message {
+	if c.fanout == nil {
+		return nil
+	}
+	return c.fanout.editedMessages
+}
+
+// CallbackQueries returns the channel of callback queries when
+// WithTypedChannels is enabled, and nil otherwise.
+func (c *Client) CallbackQueries() <-chan *CallbackQuery {
+	if c.fanout == nil {
+		return nil
+	}
+	return c.fanout.callbackQueries
+}
+
+// SpoolStats returns the spool depth, high-water mark and drop count.
+// The second result is false when the client was created without WithSpool.
+func (c *Client) SpoolStats() (SpoolStats, bool) {
+	if c.spool == nil {
+		return SpoolStats{}, false
+	}
+	return c.spool.stats(), true
+}
+
+// Validate checks the configuration and Telegram connectivity without
+// starting the listener or the poll loop, e.g. as a CI or pre-deploy step.
+//
+// It validates the config, calls getMe to verify the token and, in webhook
+// mode, calls getWebhookInfo. All API failures are returned together.
+// WithStartupTimeout bounds the calls; on expiry the error wraps
+// ErrStartupTimeout.
+func (c *Client) Validate(ctx context.Context) error {
+	cfg := c.Config()
+	if err := validateClientConfig(&cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	client := apiClient(cfg)
+	token := SecretToken(cfg.BotToken)
+
+	callCtx := ctx
+	if cfg.StartupTimeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, cfg.StartupTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+	if _, err := GetMeWithClient(callCtx, client, token); err != nil {
+		errs = append(errs, fmt.Errorf("getMe: %w", err))
+	}
+	if cfg.Mode == ModeWebhook || cfg.Mode == ModeHybrid {
+		if _, err := GetWebhookInfoWithClient(callCtx, client, token); err != nil {
+			errs = append(errs, fmt.Errorf("getWebhookInfo: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		err := errors.Join(errs...)
+		if ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("%w after %v: %w", ErrStartupTimeout, cfg.StartupTimeout, err)
+		}
+		return fmt.Errorf("validation failed: %w", err)
+	}
+	return nil
+}
+
+// checkReloadable returns an ErrNotReloadable error for the first setting
+// in next that differs from cur and cannot change on a running client.
+func checkReloadable(cur, next ClientConfig) error {
+	switch {
+	case next.Mode != cur.Mode:
+		return fmt.Errorf("mode: %w", ErrNotReloadable)
+	case next.WebhookPort != cur.WebhookPort:
+		return fmt.Errorf("webhook_port: %w", ErrNotReloadable)
+	case next.BotToken != cur.BotToken:
+		return fmt.Errorf("bot_token: %w", ErrNotReloadable)
+	case next.SpoolCapacity != cur.SpoolCapacity:
+		return fmt.Errorf("spool_capacity: %w", ErrNotReloadable)
+	case next.TypedChannels != cur.TypedChannels:
+		return fmt.Errorf("typed_channels: %w", ErrNotReloadable)
+	}
+	return nil
+}
+
+// webhookActive reports whether updates arrive through the webhook: in
+// webhook mode, and in hybrid mode unless falling back to polling. The
+// caller holds c.mu.
+func (c *Client) webhookActive() bool {
 	switch c.config.Mode {
+	case ModeWebhook:
+		return true
+	case ModeHybrid:
+		return c.hybrid == nil || !c.hybrid.inFallback()
+	}
+	return false
+}
+
+// SetAllowedUpdates changes which update types are delivered without
+// recreating the client. In polling mode the next getUpdates call uses the
+// new filter. In webhook mode setWebhook is called again with WebhookURL,
+// which must be set, so Telegram applies the filter. Hybrid mode does either,
+// depending on whether it is polling. An empty list restores Telegram's
+// default set.
+func (c *Client) SetAllowedUpdates(ctx context.Context, types []UpdateType) error {
+	names := updateTypeNames(types)
+
+	c.mu.RLock()
+	cfg := c.config
+	viaWebhook := c.webhookActive()
+	c.mu.RUnlock()
+
+	// In hybrid mode the fallback poller picks the filter up, and the
+	// webhook gets it when registered again. The lock is not held across
+	// the API call.
+	if viaWebhook {
+		if cfg.WebhookURL == "" {
+			return fmt.Errorf("webhook_url: required to change allowed updates in webhook mode")
+		}
+		if err := setWebhookFor(ctx, cfg, names); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.config.AllowedUpdates = names
+	if poller := c.poller(); poller != nil {
+		poller.setAllowedUpdates(names)
+	}
+	return nil
+}
+
+// setWebhookFor calls setWebhook with cfg's webhook URL, secret, max
+// connections and IP address and the given allowed updates. setWebhook
+// replaces every parameter, so values not configured here are reset to
+// Telegram's defaults.
+func setWebhookFor(ctx context.Context, cfg ClientConfig, allowed []string) error {
+	// Not omitempty: an empty list must be sent to reset the filter
+	req := struct {
+		URL            string   `json:"url"`
+		SecretToken    string   `json:"secret_token,omitempty"`
+		MaxConnections int      `json:"max_connections"`
+		IPAddress      string   `json:"ip_address,omitempty"`
+		AllowedUpdates []string `json:"allowed_updates"`
+	}{
+		cfg.WebhookURL,
+		cfg.WebhookSecret,
+		cmp.Or(cfg.WebhookMaxConnections, defaultWebhookMaxConnections),
+		cfg.WebhookIPAddress,
+		allowed,
+	}
+	if _, err := call[bool](ctx, apiClient(cfg), SecretToken(cfg.BotToken), "setWebhook", req); err != nil {
+		return fmt.Errorf("setWebhook: %w", err)
+	}
+	return nil
+}
+
+// apiClient returns the HTTP client for the client's one-shot API calls,
+// honoring the custom client, proxy and debug tap settings.
+func apiClient(cfg ClientConfig) httpClient {
+	var client httpClient = defaultHTTPClient()
+	if cfg.HTTPClient != nil {
+		client = cfg.HTTPClient
+	} else if cfg.ProxyURL != "" {
+		proxy, _ := parseProxyURL(cfg.ProxyURL) // validated with the config
+		client = newAPIHTTPClient(proxy)
+	}
+	if cfg.DebugTap != nil {
+		client = &tapClient{next: client, tap: cfg.DebugTap}
+	}
+	return client
+}
+
+// Start begins receiving updates based on the configured mode. A stopped
+// client cannot be restarted: Start returns ErrClientStopped.
+func (c *Client) Start(ctx context.Context) error {
+	select {
+	case <-c.stopped:
+		return ErrClientStopped
+	default:
+	}
+	c.startPipeline()
+	cfg := c.Config() // Reload may replace c.config concurrently
+	c.logStartup(cfg)
+
+	var err error
+	switch cfg.Mode {
 	case ModeLongPolling:
-		return c.startPolling(ctx)
+		err = c.startPolling(ctx, cfg)
 	case ModeWebhook:
-		return c.startWebhook(ctx)
+		err = c.startWebhook(ctx)
+	case ModeHybrid:
+		err = c.startHybrid(ctx, cfg)
 	default:
-		return fmt.Errorf("unknown receiver mode: %s", c.config.Mode)
+		return fmt.Errorf("unknown receiver mode: %s", cfg.Mode)
 	}
+	if err == nil && cfg.MetricsPushURL != "" && c.pusher == nil {
+		c.startMetricsPush(cfg)
+	}
+	return err
 }
 
-// Stop gracefully stops receiving updates.
+// startPipeline starts the spool and fanout goroutines once.
+func (c *Client) startPipeline() {
+	c.pipelineOnce.Do(func() {
+		if c.fanout != nil {
+			c.fanout.start()
+		}
+		if c.spool != nil {
+			c.spool.start()
+		}
+	})
+}
+
+// logStartup logs the effective mode and key non-secret settings as one
+// line, so a deployment's configuration is visible in its logs.
+func (c *Client) logStartup(cfg ClientConfig) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	attrs := []any{
+		"mode", cfg.Mode,
+		"bot_token", SecretToken(cfg.BotToken),
+	}
+	if cfg.Name != "" {
+		attrs = append(attrs, "bot", cfg.Name)
+	}
+	switch cfg.Mode {
+	case ModeLongPolling:
+		attrs = append(attrs,
+			"polling_timeout", cfg.PollingTimeout,
+			"polling_limit", cfg.PollingLimit,
+			"polling_max_errors", cfg.PollingMaxErrors,
+		)
+	case ModeWebhook:
+		attrs = append(attrs,
+			"webhook_port", cfg.WebhookPort,
+			"max_body_size", cfg.MaxBodySize,
+		)
+	case ModeHybrid:
+		attrs = append(attrs,
+			"webhook_port", cfg.WebhookPort,
+			"max_body_size", cfg.MaxBodySize,
+			"polling_timeout", cfg.PollingTimeout,
+			"hybrid_check_interval", cfg.HybridCheckInterval,
+			"hybrid_retry_interval", cfg.HybridRetryInterval,
+		)
+	}
+	attrs = append(attrs,
+		"allowed_updates", cfg.AllowedUpdates,
+		"rate_limit_requests", cfg.RateLimitRequests,
+		"rate_limit_burst", cfg.RateLimitBurst,
+		"spool_capacity", cfg.SpoolCapacity,
+	)
+	if cfg.ProxyURL != "" {
+		attrs = append(attrs, "proxy", redactURL(cfg.ProxyURL))
+	}
+	logger.Info("starting telegram receiver", attrs...)
+}
+
+// startMetricsPush starts pushing Stats to the configured Pushgateway.
+func (c *Client) startMetricsPush(cfg ClientConfig) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	collect := func() []byte {
+		spool, spooled := c.SpoolStats()
+		return formatMetrics(c.Stats(), spool, spooled)
+	}
+	c.pusher = newMetricsPusher(cfg.MetricsPushURL, cfg.MetricsPushJob, cfg.Name,
+		cfg.MetricsPushInterval, &http.Client{Timeout: defaultMetricsPushTimeout}, logger, collect)
+}
+
+// Stop gracefully stops receiving updates. Updates still held in the
+// spool or awaiting routing to typed channels are discarded. In hybrid mode
+// the webhook monitor stops first, so no switch-over races the shutdown,
+// then a running fallback poller (see WithHybridRestoreOnStop). With
+// WithRejectOnShutdown the webhook handler rejects updates from then on.
 func (c *Client) Stop() {
+	c.state.isShuttingDown.Store(true)
+	if c.hybrid != nil {
+		c.hybrid.stop()
+	}
 	if c.pollingClient != nil {
 		c.pollingClient.Stop()
 	}
+	if c.spool != nil {
+		c.spool.stop()
+	}
+	if c.fanout != nil {
+		c.fanout.stop()
+	}
+	if c.pusher != nil {
+		c.pusher.stop()
+	}
+	c.stopOnce.Do(func() { close(c.stopped) })
+}
+
+// RunWithSignals starts the client and blocks until SIGINT or SIGTERM is
+// received or ctx is cancelled, then stops the client. Consume Updates()
+// in another goroutine.
+//
+//	go consume(client.Updates())
+//	if err := telegramreceiver.RunWithSignals(ctx, client); err != nil {
+//	    log.Fatal(err)
+//	}
+func RunWithSignals(ctx context.Context, client *Client) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	return runUntilSignal(ctx, client, sigCh)
+}
+
+// runUntilSignal implements RunWithSignals with an injectable signal channel.
+func runUntilSignal(ctx context.Context, client *Client, sigCh <-chan os.Signal) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := client.Start(ctx); err != nil {
+		return err
+	}
+
+	logger := client.Config().Logger
+	select {
+	case sig := <-sigCh:
+		if logger != nil {
+			logger.Info("Received shutdown signal", "signal", sig)
+		}
+	case <-ctx.Done():
+	}
+
+	cancel()
+	client.Stop()
+	return nil
+}
+
+// Pause temporarily stops receiving without stopping the client: polling
+// stops calling getUpdates but keeps its offset, and the webhook handler
+// answers 503 so Telegram retries later. IsHealthy stays true while paused,
+// since the pause is intentional. Call Resume to continue.
+func (c *Client) Pause() {
+	c.paused.Store(true)
+	if poller := c.poller(); poller != nil {
+		poller.Pause()
+	}
+	if c.webhookHandler != nil {
+		c.webhookHandler.Pause()
+	}
+}
+
+// Resume continues receiving after Pause.
+func (c *Client) Resume() {
+	c.paused.Store(false)
+	if poller := c.poller(); poller != nil {
+		poller.Resume()
+	}
+	if c.webhookHandler != nil {
+		c.webhookHandler.Resume()
+	}
+}
+
+// Paused reports whether receiving is paused via Pause.
+func (c *Client) Paused() bool {
+	return c.paused.Load()
+}
+
+// Stats returns a snapshot of the active receiver's activity. It is safe to
+// call concurrently with update processing. Updates dropped by the spool
+// are reported separately by SpoolStats.
+func (c *Client) Stats() Stats {
+	poller := c.poller()
+	switch {
+	case poller != nil:
+		return poller.stats()
+	case c.webhookHandler != nil:
+		return c.webhookHandler.stats()
+	default:
+		return Stats{}
+	}
+}
+
+// Offset returns the next update ID long polling will fetch, or 0 in
+// webhook mode, in hybrid mode while the webhook is used, and before Start.
+func (c *Client) Offset() int {
+	poller := c.poller()
+	if poller == nil {
+		return 0
+	}
+	return poller.Offset()
 }
 
 // IsHealthy returns health status for Kubernetes probes.
 func (c *Client) IsHealthy() bool {
-	if c.pollingClient != nil {
-		return c.pollingClient.IsHealthy()
+	if c.hybrid != nil && c.hybrid.isStalled() {
+		return false
+	}
+	if poller := c.poller(); poller != nil {
+		return poller.IsHealthy()
 	}
 	return true
 }
 
+// IsLive reports whether the client is still receiving. In polling mode it
+// stays true while errors below the max are retried, even after IsHealthy
+// turned false at the unhealthy threshold. Webhook mode is always live.
+func (c *Client) IsLive() bool {
+	if poller := c.poller(); poller != nil {
+		return poller.IsLive()
+	}
+	return true
+}
+
+// poller returns the running polling client: the one started in polling
+// mode, or the fallback poller in hybrid mode. It is nil otherwise.
+func (c *Client) poller() *LongPollingClient {
+	if c.hybrid != nil {
+		return c.hybrid.activePoller()
+	}
+	return c.pollingClient
+}
+
 // WebhookHandler returns the HTTP handler for webhook mode.
 // Use this to integrate with your own HTTP server.
 func (c *Client) WebhookHandler() http.Handler {
+	c.startPipeline()
+
+	// Guards c.config against Reload, which also reads c.webhookHandler
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if c.webhookHandler == nil {
 		logger := c.config.Logger
 		if logger == nil {
@@ -238,67 +965,193 @@ func (c *Client) WebhookHandler() http.Handler {
 			}
 		}
 
+		opts := []WebhookOption{
+			WithWebhookSecrets(c.config.WebhookSecret, c.config.WebhookSecretPrevious),
+			WithWebhookDropLogInterval(c.config.DropLogInterval),
+			WithMaxConcurrentRequests(c.config.WebhookMaxConcurrentRequests),
+		}
+		if c.config.Name != "" {
+			opts = append(opts, WithWebhookName(c.config.Name))
+		}
+		if c.config.StrictDecoding {
+			opts = append(opts, WithWebhookStrictDecoding())
+		}
+		for field, decode := range c.config.CustomUpdateDecoders {
+			opts = append(opts, WithWebhookCustomUpdateDecoder(field, decode))
+		}
+		if c.config.AuditLogger != nil {
+			opts = append(opts, WithWebhookAuditLog(c.config.AuditLogger))
+		}
+		var level slog.Level
+		if level.UnmarshalText([]byte(c.config.WebhookRequestLogLevel)) == nil {
+			opts = append(opts, WithRequestLogLevel(level))
+		}
+		if c.config.WebhookRequestIDHeader != "" {
+			opts = append(opts, WithRequestIDHeader(c.config.WebhookRequestIDHeader))
+		}
+		if c.config.RejectOnShutdown {
+			opts = append(opts, WithServerState(&c.state))
+		}
+		if c.config.WebhookReply != nil {
+			opts = append(opts, WithWebhookResponse(c.config.WebhookReply))
+		}
+		if c.config.WebhookPanicHandler != nil {
+			opts = append(opts, WithPanicHandler(c.config.WebhookPanicHandler))
+		}
+		for _, check := range c.config.WebhookReadinessChecks {
+			opts = append(opts, WithReadinessCheck(check))
+		}
+		if c.config.OnMigration != nil {
+			opts = append(opts, WithWebhookOnMigration(c.config.OnMigration))
+		}
+		if fn := c.config.OnReceive; fn != nil {
+			opts = append(opts, WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+				fn(ctx, u)
+				return nil
+			})), WithWebhookHandlerTimeout(c.config.HandlerTimeout))
+		}
 		c.webhookHandler = NewWebhookHandler(
 			logger,
 			c.config.WebhookSecret,
 			c.config.AllowedDomain,
-			c.updates,
+			c.sink,
 			c.config.RateLimitRequests,
 			c.config.RateLimitBurst,
 			c.config.MaxBodySize,
 			c.config.BreakerMaxRequests,
 			c.config.BreakerInterval,
 			c.config.BreakerTimeout,
+			opts...,
 		)
+		if c.paused.Load() {
+			c.webhookHandler.Pause()
+		}
 	}
 	return c.webhookHandler
 }
 
 // startPolling starts the long polling client.
-func (c *Client) startPolling(ctx context.Context) error {
-	logger := c.config.Logger
+func (c *Client) startPolling(ctx context.Context, cfg ClientConfig) error {
+	poller, err := c.newPollingClient(cfg)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.pollingClient = poller
+	c.mu.Unlock()
+	return poller.Start(ctx)
+}
+
+// newPollingClient creates a polling client from cfg, paused if Pause was
+// called before.
+func (c *Client) newPollingClient(cfg ClientConfig) (*LongPollingClient, error) {
+	logger := cfg.Logger
 	if logger == nil {
-		loggerWrapper, err := NewLogger(0, c.config.LogFilePath)
+		loggerWrapper, err := NewLogger(0, cfg.LogFilePath)
 		if err != nil {
-			return fmt.Errorf("creating logger: %w", err)
+			return nil, fmt.Errorf("creating logger: %w", err)
 		}
 		logger = loggerWrapper.Logger
 	}
 
 	var opts []LongPollingOption
-	if c.config.PollingMaxErrors != 10 {
-		opts = append(opts, WithMaxErrors(c.config.PollingMaxErrors))
+	if cfg.PollingMaxErrors != 10 {
+		opts = append(opts, WithMaxErrors(cfg.PollingMaxErrors))
+	}
+	if cfg.PollingAdaptiveMax > 0 {
+		opts = append(opts, WithAdaptivePolling(cfg.PollingAdaptiveMin, cfg.PollingAdaptiveMax))
+	}
+	if cfg.PollingStrictOrdering {
+		opts = append(opts, WithStrictOrdering())
 	}
-	if len(c.config.AllowedUpdates) > 0 {
-		opts = append(opts, WithAllowedUpdates(c.config.AllowedUpdates))
+	if cfg.PollingUnhealthyThreshold > 0 {
+		opts = append(opts, WithUnhealthyThreshold(cfg.PollingUnhealthyThreshold))
 	}
-	if c.config.PollingDeleteWebhook {
+	if cfg.PollingAutoRestart > 0 {
+		opts = append(opts, WithAutoRestart(cfg.PollingAutoRestart))
+	}
+	if len(cfg.AllowedUpdates) > 0 {
+		opts = append(opts, WithAllowedUpdates(cfg.AllowedUpdates))
+	}
+	if cfg.PollingDeleteWebhook {
 		opts = append(opts, WithDeleteWebhook(true))
 	}
-	if c.config.RetryInitialDelay > 0 || c.config.RetryMaxDelay > 0 {
+	if cfg.StartupTimeout > 0 {
+		opts = append(opts, WithPollStartupTimeout(cfg.StartupTimeout))
+	}
+	opts = append(opts, WithPollDropLogInterval(cfg.DropLogInterval))
+	if cfg.Name != "" {
+		opts = append(opts, WithPollName(cfg.Name))
+	}
+	if cfg.StrictDecoding {
+		opts = append(opts, WithPollStrictDecoding())
+	}
+	for field, decode := range cfg.CustomUpdateDecoders {
+		opts = append(opts, WithPollCustomUpdateDecoder(field, decode))
+	}
+	if cfg.AuditLogger != nil {
+		opts = append(opts, WithPollAuditLog(cfg.AuditLogger))
+	}
+	if cfg.OnMigration != nil {
+		opts = append(opts, WithPollOnMigration(cfg.OnMigration))
+	}
+	if cfg.OffsetPublisher != nil {
+		opts = append(opts, WithPollOffsetPublisher(cfg.OffsetPublisher))
+	}
+	if cfg.OnReceive != nil {
+		opts = append(opts, WithPollOnReceive(cfg.OnReceive), WithPollHandlerTimeout(cfg.HandlerTimeout))
+	}
+	if cfg.RetryInitialDelay > 0 || cfg.RetryMaxDelay > 0 {
 		opts = append(opts, WithRetryConfig(
-			c.config.RetryInitialDelay,
-			c.config.RetryMaxDelay,
-			c.config.RetryBackoffFactor,
+			cfg.RetryInitialDelay,
+			cfg.RetryMaxDelay,
+			cfg.RetryBackoffFactor,
+		))
+	}
+	if cfg.PollingMinInterval > 0 {
+		opts = append(opts, WithMinPollInterval(cfg.PollingMinInterval))
+	}
+	if cfg.RetryBackoffReset != "" {
+		opts = append(opts, WithPollBackoffResetMode(cfg.RetryBackoffReset))
+	}
+	if cfg.PollingHTTPTimeout > 0 {
+		opts = append(opts, WithPollHTTPTimeout(cfg.PollingHTTPTimeout))
+	}
+	if cfg.PollingMaxIdleConns > 0 || cfg.PollingMaxIdleConnsPerHost > 0 || cfg.PollingIdleConnTimeout > 0 {
+		opts = append(opts, WithTransportConfig(
+			cfg.PollingMaxIdleConns,
+			cfg.PollingMaxIdleConnsPerHost,
+			cfg.PollingIdleConnTimeout,
 		))
 	}
-	if c.config.HTTPClient != nil {
-		opts = append(opts, WithHTTPClient(c.config.HTTPClient.(*http.Client)))
+	if cfg.HTTPClient != nil {
+		opts = append(opts, withAPIClient(cfg.HTTPClient))
+	} else if cfg.ProxyURL != "" {
+		proxy, err := parseProxyURL(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("proxy_url: %w", err)
+		}
+		opts = append(opts, WithPollProxy(proxy))
+	}
+	if cfg.DebugTap != nil {
+		opts = append(opts, WithPollDebugTap(cfg.DebugTap))
 	}
 
-	c.pollingClient = NewLongPollingClient(
-		SecretToken(c.config.BotToken),
-		c.updates,
+	poller := NewLongPollingClient(
+		SecretToken(cfg.BotToken),
+		c.sink,
 		logger,
-		c.config.PollingTimeout,
-		c.config.PollingLimit,
-		c.config.BreakerMaxRequests,
-		c.config.BreakerInterval,
-		c.config.BreakerTimeout,
+		cfg.PollingTimeout,
+		cfg.PollingLimit,
+		cfg.BreakerMaxRequests,
+		cfg.BreakerInterval,
+		cfg.BreakerTimeout,
 		opts...,
 	)
-
-	return c.pollingClient.Start(ctx)
+	if c.paused.Load() {
+		poller.Pause()
+	}
+	return poller, nil
 }
 
 // startWebhook starts the webhook server.
@@ -0,0 +1,118 @@
+package telegramreceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock whose waits return immediately, advancing Now by
+// the requested duration and recording it.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1700000000, 0)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.advance(d)
+	ch := make(chan time.Time, 1)
+	ch <- f.Now()
+	return ch
+}
+
+func (f *fakeClock) Sleep(d time.Duration) {
+	f.advance(d)
+}
+
+func (f *fakeClock) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	f.waits = append(f.waits, d)
+}
+
+// recorded returns the waits requested so far.
+func (f *fakeClock) recorded() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.waits...)
+}
+
+// stepClock is a clock whose waits block until the test releases them
+// with step, so a loop can be advanced one iteration at a time.
+type stepClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []stepWaiter
+}
+
+type stepWaiter struct {
+	d  time.Duration
+	ch chan time.Time
+}
+
+func newStepClock() *stepClock {
+	return &stepClock{now: time.Unix(1700000000, 0)}
+}
+
+func (s *stepClock) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+func (s *stepClock) After(d time.Duration) <-chan time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	s.waiters = append(s.waiters, stepWaiter{d, ch})
+	return ch
+}
+
+func (s *stepClock) Sleep(d time.Duration) {
+	<-s.After(d)
+}
+
+// pending waits until a wait is outstanding and returns its duration.
+func (s *stepClock) pending(t *testing.T) time.Duration {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mu.Lock()
+		if len(s.waiters) > 0 {
+			d := s.waiters[0].d
+			s.mu.Unlock()
+			return d
+		}
+		s.mu.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the clock to be waited on")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// step releases the outstanding wait, then waits for the next one and
+// returns its duration.
+func (s *stepClock) step(t *testing.T) time.Duration {
+	t.Helper()
+	s.pending(t)
+	s.mu.Lock()
+	w := s.waiters[0]
+	s.waiters = s.waiters[1:]
+	s.now = s.now.Add(w.d)
+	w.ch <- s.now
+	s.mu.Unlock()
+	return s.pending(t)
+}
@@ -0,0 +1,112 @@
+package telegramreceiver
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Command is a bot command at the start of a message, such as
+// "/help@MyBot arg1 arg2".
+type Command struct {
+	Name    string   // Without slash and mention, e.g. "help"
+	Mention string   // Bot username after "@", empty when not addressed
+	Args    []string // Whitespace-separated arguments
+	RawArgs string   // Text after the command, trimmed
+}
+
+// Command returns the bot command the message text, or caption for media
+// messages, starts with. Telegram marks commands with a "bot_command"
+// entity; only one at offset 0 counts, so "see /help" is not a command.
+func (m *Message) Command() (Command, bool) {
+	text := m.EffectiveText()
+	for _, e := range m.EffectiveEntities() {
+		if e.Type != "bot_command" || e.Offset != 0 {
+			continue
+		}
+		token := e.Text(text)
+		if len(token) < 2 || token[0] != '/' {
+			return Command{}, false
+		}
+		name, mention, _ := strings.Cut(token[1:], "@")
+		rest := strings.TrimSpace(string(utf16.Decode(utf16.Encode([]rune(text))[e.Length:])))
+		return Command{
+			Name:    name,
+			Mention: mention,
+			Args:    strings.Fields(rest),
+			RawArgs: rest,
+		}, true
+	}
+	return Command{}, false
+}
+
+// CommandHandler handles a routed bot command.
+type CommandHandler func(ctx context.Context, update TelegramUpdate, cmd Command) error
+
+// CommandRouter dispatches bot commands in new messages to the handlers
+// registered with OnCommand. Command names match case-insensitively.
+// Commands addressed to another bot ("/start@OtherBot"), unknown commands
+// and all other updates are passed on by Handler. The zero value is not
+// usable; create one with NewCommandRouter.
+type CommandRouter struct {
+	botUsername string
+
+	mu       sync.RWMutex
+	handlers map[string]CommandHandler
+}
+
+// NewCommandRouter creates a router for the bot with the given username,
+// as returned by GetMe, used to tell commands addressed to this bot from
+// ones for other bots in the same group. With an empty username, commands
+// addressed to any bot are routed.
+func NewCommandRouter(botUsername string) *CommandRouter {
+	return &CommandRouter{
+		botUsername: strings.TrimPrefix(botUsername, "@"),
+		handlers:    make(map[string]CommandHandler),
+	}
+}
+
+// OnCommand registers h for the command name, given without slash, such
+// as "start". A later registration for the same name replaces the earlier.
+func (r *CommandRouter) OnCommand(name string, h CommandHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[strings.ToLower(strings.TrimPrefix(name, "/"))] = h
+}
+
+// Route calls the handler for the update's command and reports whether
+// one was found. Edited messages are not routed, so editing a command does
+// not run it again.
+func (r *CommandRouter) Route(ctx context.Context, update TelegramUpdate) (bool, error) {
+	if update.Message == nil {
+		return false, nil
+	}
+	cmd, ok := update.Message.Command()
+	if !ok {
+		return false, nil
+	}
+	if cmd.Mention != "" && r.botUsername != "" && !strings.EqualFold(cmd.Mention, r.botUsername) {
+		return false, nil
+	}
+
+	r.mu.RLock()
+	h := r.handlers[strings.ToLower(cmd.Name)]
+	r.mu.RUnlock()
+	if h == nil {
+		return false, nil
+	}
+	return true, h(ctx, update, cmd)
+}
+
+// Handler returns an UpdateHandler that routes commands and passes every
+// other update to next. next may be nil.
+func (r *CommandRouter) Handler(next UpdateHandler) UpdateHandler {
+	return UpdateHandlerFunc(func(ctx context.Context, update TelegramUpdate) error {
+		routed, err := r.Route(ctx, update)
+		if routed || next == nil {
+			return err
+		}
+		return next.HandleUpdate(ctx, update)
+	})
+}
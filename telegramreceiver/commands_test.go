@@ -0,0 +1,126 @@
+package telegramreceiver
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func commandUpdate(text string, commandLength int) TelegramUpdate {
+	msg := &Message{MessageID: 1, Chat: &Chat{ID: 10, Type: "group"}, Text: text}
+	if commandLength > 0 {
+		msg.Entities = []MessageEntity{{Type: "bot_command", Offset: 0, Length: commandLength}}
+	}
+	return TelegramUpdate{UpdateID: 1, Message: msg}
+}
+
+func TestMessage_Command(t *testing.T) {
+	tests := []struct {
+		name   string
+		msg    Message
+		want   Command
+		wantOK bool
+	}{
+		{
+			name:   "plain command",
+			msg:    *commandUpdate("/start", 6).Message,
+			want:   Command{Name: "start"},
+			wantOK: true,
+		},
+		{
+			name:   "mention and arguments",
+			msg:    *commandUpdate("/help@MyBot arg1  arg2", 11).Message,
+			want:   Command{Name: "help", Mention: "MyBot", Args: []string{"arg1", "arg2"}, RawArgs: "arg1  arg2"},
+			wantOK: true,
+		},
+		{
+			name: "command not at the start",
+			msg: Message{Text: "see /help", Entities: []MessageEntity{
+				{Type: "bot_command", Offset: 4, Length: 5},
+			}},
+		},
+		{
+			name: "arguments after an emoji",
+			msg: Message{Text: "/say 👍 hi", Entities: []MessageEntity{
+				{Type: "bot_command", Offset: 0, Length: 4},
+			}},
+			want:   Command{Name: "say", Args: []string{"👍", "hi"}, RawArgs: "👍 hi"},
+			wantOK: true,
+		},
+		{
+			name: "no entity",
+			msg:  Message{Text: "/start"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.msg.Command()
+			if ok != tt.wantOK {
+				t.Fatalf("Command() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.Name != tt.want.Name || got.Mention != tt.want.Mention ||
+				got.RawArgs != tt.want.RawArgs || !slices.Equal(got.Args, tt.want.Args) {
+				t.Errorf("Command() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandRouter(t *testing.T) {
+	router := NewCommandRouter("MyBot")
+
+	var routed []Command
+	record := func(ctx context.Context, u TelegramUpdate, cmd Command) error {
+		routed = append(routed, cmd)
+		return nil
+	}
+	router.OnCommand("start", record)
+	router.OnCommand("/help", record)
+
+	var passed []string
+	handler := router.Handler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+		passed = append(passed, u.Message.Text)
+		return nil
+	}))
+
+	for _, upd := range []TelegramUpdate{
+		commandUpdate("/start", 6),
+		commandUpdate("/help@MyBot arg1 arg2", 11),
+		commandUpdate("hello there", 0),
+		commandUpdate("/help@OtherBot", 14),
+		commandUpdate("/unknown", 8),
+	} {
+		if err := handler.HandleUpdate(context.Background(), upd); err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+	}
+
+	if len(routed) != 2 {
+		t.Fatalf("expected 2 routed commands, got %+v", routed)
+	}
+	if routed[0].Name != "start" {
+		t.Errorf("first command = %q, want start", routed[0].Name)
+	}
+	if routed[1].Name != "help" || !slices.Equal(routed[1].Args, []string{"arg1", "arg2"}) {
+		t.Errorf("second command = %+v, want help with args arg1 arg2", routed[1])
+	}
+	wantPassed := []string{"hello there", "/help@OtherBot", "/unknown"}
+	if !slices.Equal(passed, wantPassed) {
+		t.Errorf("passed through %q, want %q", passed, wantPassed)
+	}
+}
+
+func TestCommandRouter_IgnoresEdits(t *testing.T) {
+	router := NewCommandRouter("MyBot")
+	router.OnCommand("start", func(ctx context.Context, u TelegramUpdate, cmd Command) error {
+		t.Error("edited command must not be routed")
+		return nil
+	})
+
+	upd := commandUpdate("/start", 6)
+	upd.EditedMessage, upd.Message = upd.Message, nil
+	if routed, err := router.Route(context.Background(), upd); routed || err != nil {
+		t.Errorf("Route() = %v, %v; want false, nil", routed, err)
+	}
+}
@@ -1,6 +1,9 @@
 package telegramreceiver
 
 import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -15,6 +18,9 @@ const (
 	ModeWebhook ReceiverMode = "webhook"
 	// ModeLongPolling receives updates by polling Telegram API (your server pulls).
 	ModeLongPolling ReceiverMode = "longpolling"
+	// ModeHybrid receives via webhook and falls back to polling while
+	// Telegram reports webhook delivery errors (see Client.PollingFallback).
+	ModeHybrid ReceiverMode = "hybrid"
 )
 
 type Config struct {
@@ -32,6 +38,28 @@ type Config struct {
 	AllowedDomain string
 	WebhookURL    string // Public URL for auto-registration (optional)
 
+	// Further setWebhook parameters for auto-registration
+	WebhookMaxConnections int    // Simultaneous connections Telegram opens, 1-100 (default: 40)
+	WebhookIPAddress      string // Fixed IP Telegram sends updates to instead of resolving the URL (optional)
+
+	// Webhook auto-registration retry (transient setWebhook failures)
+	WebhookRegisterMaxAttempts  int           // Total setWebhook attempts (default: 5, 0 or 1 = no retry)
+	WebhookRegisterInitialDelay time.Duration // Delay before the first retry (default: 1s)
+	WebhookRegisterMaxDelay     time.Duration // Maximum delay cap (default: 30s)
+
+	// Skip setWebhook when getWebhookInfo already reports the same URL and
+	// allowed updates. Secret token changes cannot be detected this way.
+	WebhookSkipRedundantRegistration bool
+
+	// Verify auto-registration by polling getWebhookInfo until Telegram
+	// reports no delivery error
+	WebhookVerifyTimeout  time.Duration // How long to wait for a clean report (default: 0 = no verification)
+	WebhookVerifyInterval time.Duration // Delay between getWebhookInfo calls (default: 2s)
+
+	// Bounds registration and verification together, so Start fails with
+	// ErrStartupTimeout instead of hanging on Telegram (default: 0 = caller's context only)
+	StartupTimeout time.Duration
+
 	// Long polling configuration
 	PollingTimeout            int           // Seconds to wait for updates (0-60)
 	PollingLimit              int           // Max updates per request (1-100)
@@ -55,9 +83,30 @@ type Config struct {
 	BreakerInterval    time.Duration
 	BreakerTimeout     time.Duration
 
+	// Webhook server hardening
+	MaxHeaderBytes        int      // Maximum request header size (default: 1 MB)
+	MaxConcurrentRequests int      // In-flight webhook requests before 503 (0 = unlimited; a WebhookHandler limit takes precedence)
+	TLSMinVersion         uint16   // Minimum TLS version, tls.VersionTLS12 or higher (default: TLS 1.2)
+	CipherSuites          []uint16 // TLS 1.2 cipher suites (empty = Go defaults; TLS 1.3 suites are fixed)
+
+	// Additional certificates selected by the SNI server name, for several
+	// hostnames served by one webhook server. TLSCertPath stays the default.
+	TLSSNICerts []TLSCertKeyPair
+
+	// Client certificate verification (mTLS) between a proxy and this server
+	TLSClientCAs  []byte             // PEM CA bundle trusted for client certificates (empty = no client certificates)
+	TLSClientAuth tls.ClientAuthType // Policy when TLSClientCAs is set (default: tls.RequireAndVerifyClientCert)
+
 	// Kubernetes-aware shutdown settings
-	DrainDelay      time.Duration // Time to wait for LB to stop routing before shutdown
-	ShutdownTimeout time.Duration // Max time for graceful shutdown
+	DrainDelay       time.Duration // Time to wait for LB to stop routing before shutdown
+	ShutdownTimeout  time.Duration // Max time for graceful shutdown
+	RejectOnShutdown bool          // Webhook handler returns 503 for new updates once shutdown begins
+}
+
+// TLSCertKeyPair locates a PEM certificate chain and its private key.
+type TLSCertKeyPair struct {
+	CertPath string
+	KeyPath  string
 }
 
 func LoadConfig() (*Config, error) {
@@ -106,16 +155,10 @@ func LoadConfig() (*Config, error) {
 	// Parse polling delete webhook (default: false)
 	pollingDeleteWebhook := strings.ToLower(getEnv("POLLING_DELETE_WEBHOOK", "false")) == "true"
 
-	// Parse allowed updates (comma-separated list)
-	var allowedUpdates []string
-	allowedUpdatesStr := getEnv("ALLOWED_UPDATES", "")
-	if allowedUpdatesStr != "" {
-		for _, u := range strings.Split(allowedUpdatesStr, ",") {
-			trimmed := strings.TrimSpace(u)
-			if trimmed != "" {
-				allowedUpdates = append(allowedUpdates, trimmed)
-			}
-		}
+	// Parse allowed updates (comma-separated list or JSON array)
+	allowedUpdates, err := parseList(getEnv("ALLOWED_UPDATES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("ALLOWED_UPDATES: %w", err)
 	}
 
 	// Parse retry configuration for exponential backoff
@@ -140,6 +183,45 @@ func LoadConfig() (*Config, error) {
 		return nil, ErrInvalidWebhookURL
 	}
 
+	webhookMaxConnections, err := strconv.Atoi(getEnv("WEBHOOK_MAX_CONNECTIONS", "40"))
+	if err != nil {
+		return nil, fmt.Errorf("WEBHOOK_MAX_CONNECTIONS: %w", err)
+	}
+
+	// Parse webhook auto-registration retry and verification settings
+	webhookRegisterMaxAttempts, err := strconv.Atoi(getEnv("WEBHOOK_REGISTER_MAX_ATTEMPTS", "5"))
+	if err != nil {
+		return nil, err
+	}
+
+	webhookRegisterInitialDelay, err := time.ParseDuration(getEnv("WEBHOOK_REGISTER_INITIAL_DELAY", "1s"))
+	if err != nil {
+		return nil, err
+	}
+
+	webhookRegisterMaxDelay, err := time.ParseDuration(getEnv("WEBHOOK_REGISTER_MAX_DELAY", "30s"))
+	if err != nil {
+		return nil, err
+	}
+
+	webhookVerifyTimeout, err := time.ParseDuration(getEnv("WEBHOOK_VERIFY_TIMEOUT", "0s"))
+	if err != nil {
+		return nil, err
+	}
+
+	webhookVerifyInterval, err := time.ParseDuration(getEnv("WEBHOOK_VERIFY_INTERVAL", "2s"))
+	if err != nil {
+		return nil, err
+	}
+
+	startupTimeout, err := time.ParseDuration(getEnv("WEBHOOK_STARTUP_TIMEOUT", "0s"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip re-registering an identical webhook (default: false)
+	webhookSkipRedundantRegistration := strings.ToLower(getEnv("WEBHOOK_SKIP_REDUNDANT_REGISTRATION", "false")) == "true"
+
 	rateLimitRequests, err := strconv.ParseFloat(getEnv("RATE_LIMIT_REQUESTS", "10"), 64)
 	if err != nil {
 		return nil, err
@@ -190,6 +272,54 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	maxHeaderBytes, err := strconv.Atoi(getEnv("MAX_HEADER_BYTES", "1048576"))
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrentRequests, err := strconv.Atoi(getEnv("MAX_CONCURRENT_REQUESTS", "0"))
+	if err != nil {
+		return nil, err
+	}
+
+	tlsMinVersion, err := parseTLSVersion(getEnv("TLS_MIN_VERSION", "1.2"))
+	if err != nil {
+		return nil, fmt.Errorf("TLS_MIN_VERSION: %w", err)
+	}
+
+	cipherSuiteNames, err := parseList(getEnv("TLS_CIPHER_SUITES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("TLS_CIPHER_SUITES: %w", err)
+	}
+	cipherSuites, err := parseCipherSuites(cipherSuiteNames)
+	if err != nil {
+		return nil, fmt.Errorf("TLS_CIPHER_SUITES: %w", err)
+	}
+
+	sniCertItems, err := parseList(getEnv("TLS_SNI_CERTS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("TLS_SNI_CERTS: %w", err)
+	}
+	sniCerts, err := parseCertKeyPairs(sniCertItems)
+	if err != nil {
+		return nil, fmt.Errorf("TLS_SNI_CERTS: %w", err)
+	}
+
+	var tlsClientCAs []byte
+	if path := getEnv("TLS_CLIENT_CA_PATH", ""); path != "" {
+		tlsClientCAs, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("TLS_CLIENT_CA_PATH: %w", err)
+		}
+	}
+	var tlsClientAuth tls.ClientAuthType
+	if value := getEnv("TLS_CLIENT_AUTH", ""); value != "" {
+		tlsClientAuth, err = parseTLSClientAuth(value)
+		if err != nil {
+			return nil, fmt.Errorf("TLS_CLIENT_AUTH: %w", err)
+		}
+	}
+
 	drainDelay, err := time.ParseDuration(getEnv("DRAIN_DELAY", "5s"))
 	if err != nil {
 		return nil, err
@@ -200,39 +330,174 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// Reject new webhook updates during shutdown (default: false)
+	rejectOnShutdown := strings.ToLower(getEnv("WEBHOOK_REJECT_ON_SHUTDOWN", "false")) == "true"
+
+	// Bot token: TELEGRAM_BOT_TOKEN_FILE takes precedence over TELEGRAM_BOT_TOKEN
+	botToken := SecretToken(getEnv("TELEGRAM_BOT_TOKEN", ""))
+	if tokenFile := getEnv("TELEGRAM_BOT_TOKEN_FILE", ""); tokenFile != "" {
+		botToken, err = readBotTokenFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN_FILE: %w", err)
+		}
+	}
+
 	return &Config{
-		ReceiverMode:       receiverMode,
-		BotToken:           SecretToken(getEnv("TELEGRAM_BOT_TOKEN", "")),
-		WebhookPort:        webhookPort,
-		TLSCertPath:        getEnv("TLS_CERT_PATH", ""),
-		TLSKeyPath:         getEnv("TLS_KEY_PATH", ""),
-		WebhookSecret:      getEnv("WEBHOOK_SECRET", ""),
-		AllowedDomain:      getEnv("ALLOWED_DOMAIN", ""),
-		WebhookURL:         webhookURL,
-		PollingTimeout:            pollingTimeout,
-		PollingLimit:              pollingLimit,
-		PollingMaxErrors:          pollingMaxErrors,
-		PollingDeleteWebhook:      pollingDeleteWebhook,
-		AllowedUpdates:            allowedUpdates,
-		PollingRetryInitialDelay:  pollingRetryInitialDelay,
-		PollingRetryMaxDelay:      pollingRetryMaxDelay,
-		PollingRetryBackoffFactor: pollingRetryBackoffFactor,
-		LogFilePath:        getEnv("LOG_FILE_PATH", "logs/telegramreceiver.log"),
-		RateLimitRequests:  rateLimitRequests,
-		RateLimitBurst:     rateLimitBurst,
-		MaxBodySize:        maxBodySize,
-		ReadTimeout:        readTimeout,
-		ReadHeaderTimeout:  readHeaderTimeout,
-		WriteTimeout:       writeTimeout,
-		IdleTimeout:        idleTimeout,
-		BreakerMaxRequests: uint32(breakerMaxRequests),
-		BreakerInterval:    breakerInterval,
-		BreakerTimeout:     breakerTimeout,
-		DrainDelay:         drainDelay,
-		ShutdownTimeout:    shutdownTimeout,
+		ReceiverMode:                     receiverMode,
+		BotToken:                         botToken,
+		WebhookPort:                      webhookPort,
+		TLSCertPath:                      getEnv("TLS_CERT_PATH", ""),
+		TLSKeyPath:                       getEnv("TLS_KEY_PATH", ""),
+		WebhookSecret:                    getEnv("WEBHOOK_SECRET", ""),
+		AllowedDomain:                    getEnv("ALLOWED_DOMAIN", ""),
+		WebhookURL:                       webhookURL,
+		WebhookMaxConnections:            webhookMaxConnections,
+		WebhookIPAddress:                 getEnv("WEBHOOK_IP_ADDRESS", ""),
+		WebhookRegisterMaxAttempts:       webhookRegisterMaxAttempts,
+		WebhookRegisterInitialDelay:      webhookRegisterInitialDelay,
+		WebhookRegisterMaxDelay:          webhookRegisterMaxDelay,
+		WebhookSkipRedundantRegistration: webhookSkipRedundantRegistration,
+		WebhookVerifyTimeout:             webhookVerifyTimeout,
+		WebhookVerifyInterval:            webhookVerifyInterval,
+		StartupTimeout:                   startupTimeout,
+		PollingTimeout:                   pollingTimeout,
+		PollingLimit:                     pollingLimit,
+		PollingMaxErrors:                 pollingMaxErrors,
+		PollingDeleteWebhook:             pollingDeleteWebhook,
+		AllowedUpdates:                   allowedUpdates,
+		PollingRetryInitialDelay:         pollingRetryInitialDelay,
+		PollingRetryMaxDelay:             pollingRetryMaxDelay,
+		PollingRetryBackoffFactor:        pollingRetryBackoffFactor,
+		LogFilePath:                      getEnv("LOG_FILE_PATH", "logs/telegramreceiver.log"),
+		RateLimitRequests:                rateLimitRequests,
+		RateLimitBurst:                   rateLimitBurst,
+		MaxBodySize:                      maxBodySize,
+		ReadTimeout:                      readTimeout,
+		ReadHeaderTimeout:                readHeaderTimeout,
+		WriteTimeout:                     writeTimeout,
+		IdleTimeout:                      idleTimeout,
+		BreakerMaxRequests:               uint32(breakerMaxRequests),
+		BreakerInterval:                  breakerInterval,
+		BreakerTimeout:                   breakerTimeout,
+		MaxHeaderBytes:                   maxHeaderBytes,
+		MaxConcurrentRequests:            maxConcurrentRequests,
+		TLSMinVersion:                    tlsMinVersion,
+		CipherSuites:                     cipherSuites,
+		TLSSNICerts:                      sniCerts,
+		TLSClientCAs:                     tlsClientCAs,
+		TLSClientAuth:                    tlsClientAuth,
+		DrainDelay:                       drainDelay,
+		ShutdownTimeout:                  shutdownTimeout,
+		RejectOnShutdown:                 rejectOnShutdown,
 	}, nil
 }
 
+// readBotTokenFile reads a bot token from a file (e.g. a Kubernetes secret mount),
+// trimming surrounding whitespace, and validates its format.
+func readBotTokenFile(path string) (SecretToken, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := SecretToken(strings.TrimSpace(string(data)))
+	if err := ValidateBotToken(token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// parseCertKeyPairs parses "cert.pem:key.pem" items.
+func parseCertKeyPairs(items []string) ([]TLSCertKeyPair, error) {
+	var pairs []TLSCertKeyPair
+	for _, item := range items {
+		cert, key, ok := strings.Cut(item, ":")
+		if !ok || cert == "" || key == "" {
+			return nil, fmt.Errorf("invalid certificate pair %q (use cert.pem:key.pem)", item)
+		}
+		pairs = append(pairs, TLSCertKeyPair{CertPath: cert, KeyPath: key})
+	}
+	return pairs, nil
+}
+
+// parseTLSClientAuth converts "require" or "verify_if_given" to the client
+// certificate policy used with TLSClientCAs.
+func parseTLSClientAuth(value string) (tls.ClientAuthType, error) {
+	switch value {
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	case "verify_if_given":
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return 0, fmt.Errorf("unsupported client auth %q (use require or verify_if_given)", value)
+	}
+}
+
+// parseTLSVersion converts "1.2" or "1.3" style versions to tls constants.
+func parseTLSVersion(value string) (uint16, error) {
+	switch strings.TrimSpace(value) {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unknown TLS version %q (use 1.2 or 1.3)", value)
+	}
+}
+
+// parseCipherSuites resolves cipher suite names such as
+// TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256. Only suites Go considers secure
+// are accepted.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseList parses a multi-value setting given either as a comma-separated
+// list or as a JSON array of strings, detected by a leading '['.
+// Empty entries are skipped.
+func parseList(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	var items []string
+	if strings.HasPrefix(value, "[") {
+		if err := json.Unmarshal([]byte(value), &items); err != nil {
+			return nil, fmt.Errorf("invalid JSON array %q: %w", value, err)
+		}
+	} else {
+		items = strings.Split(value, ",")
+	}
+
+	var result []string
+	for _, item := range items {
+		if trimmed := strings.TrimSpace(item); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -1,11 +1,20 @@
 package telegramreceiver
 
 import (
+	"bytes"
+	"crypto/tls"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
 
+// testBotToken is a syntactically valid, fake bot token for tests.
+const testBotToken = "123456789:ABCdefGHIjklMNOpqrSTUvwxYZ0123456789"
+
 func TestLoadConfig_Defaults(t *testing.T) {
 	// Clear any existing env vars
 	envVars := []string{
@@ -132,3 +141,181 @@ func TestGetEnv(t *testing.T) {
 		t.Errorf("getEnv() = %s, want default", got)
 	}
 }
+
+func TestLoadConfig_BotTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte(testBotToken+"\n"), 0600); err != nil {
+		t.Fatalf("writing token file: %v", err)
+	}
+
+	t.Setenv("TELEGRAM_BOT_TOKEN", "111111:inline-token-should-be-ignored")
+	t.Setenv("TELEGRAM_BOT_TOKEN_FILE", path)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	if cfg.BotToken.Value() != testBotToken {
+		t.Errorf("BotToken = %q, want %q", cfg.BotToken.Value(), testBotToken)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("config loaded", "token", cfg.BotToken)
+	if strings.Contains(buf.String(), testBotToken) {
+		t.Error("log output should not contain the token read from file")
+	}
+}
+
+func TestLoadConfig_BotTokenFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid")
+	if err := os.WriteFile(invalid, []byte("not-a-token"), 0600); err != nil {
+		t.Fatalf("writing token file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing file", filepath.Join(dir, "missing")},
+		{"invalid token", invalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TELEGRAM_BOT_TOKEN_FILE", tt.path)
+
+			_, err := LoadConfig()
+			if err == nil {
+				t.Fatal("LoadConfig() expected error")
+			}
+			if !strings.Contains(err.Error(), "TELEGRAM_BOT_TOKEN_FILE") {
+				t.Errorf("error %q should mention TELEGRAM_BOT_TOKEN_FILE", err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_AllowedUpdatesFormats(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{"comma-separated", "message, callback_query,,poll", []string{"message", "callback_query", "poll"}},
+		{"JSON array", `["message","poll"]`, []string{"message", "poll"}},
+		{"JSON array with spaces", ` [ "message" , "edited_message" ] `, []string{"message", "edited_message"}},
+		{"empty JSON array", "[]", nil},
+		{"single value", "message", []string{"message"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ALLOWED_UPDATES", tt.value)
+
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if strings.Join(cfg.AllowedUpdates, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("AllowedUpdates = %q, want %q", cfg.AllowedUpdates, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_AllowedUpdatesMalformedJSON(t *testing.T) {
+	t.Setenv("ALLOWED_UPDATES", `["message","poll"`)
+
+	_, err := LoadConfig()
+	if err == nil {
+		t.Fatal("expected error for malformed JSON array")
+	}
+	if !strings.Contains(err.Error(), "ALLOWED_UPDATES") || !strings.Contains(err.Error(), "invalid JSON array") {
+		t.Errorf("expected descriptive ALLOWED_UPDATES error, got %v", err)
+	}
+}
+
+func TestLoadConfig_ServerHardening(t *testing.T) {
+	t.Setenv("MAX_HEADER_BYTES", "16384")
+	t.Setenv("TLS_MIN_VERSION", "1.3")
+	t.Setenv("TLS_CIPHER_SUITES", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.MaxHeaderBytes != 16384 {
+		t.Errorf("MaxHeaderBytes = %d, want 16384", cfg.MaxHeaderBytes)
+	}
+	if cfg.TLSMinVersion != tls.VersionTLS13 {
+		t.Errorf("TLSMinVersion = %x, want TLS 1.3", cfg.TLSMinVersion)
+	}
+	want := []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	if len(cfg.CipherSuites) != 2 || cfg.CipherSuites[0] != want[0] || cfg.CipherSuites[1] != want[1] {
+		t.Errorf("CipherSuites = %v, want %v", cfg.CipherSuites, want)
+	}
+}
+
+func TestLoadConfig_ClientCAs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, []byte("-----BEGIN CERTIFICATE-----\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TLS_CLIENT_CA_PATH", path)
+	t.Setenv("TLS_CLIENT_AUTH", "verify_if_given")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !strings.HasPrefix(string(cfg.TLSClientCAs), "-----BEGIN CERTIFICATE-----") {
+		t.Errorf("TLSClientCAs = %q", cfg.TLSClientCAs)
+	}
+	if cfg.TLSClientAuth != tls.VerifyClientCertIfGiven {
+		t.Errorf("TLSClientAuth = %v, want VerifyClientCertIfGiven", cfg.TLSClientAuth)
+	}
+}
+
+func TestLoadConfig_SNICerts(t *testing.T) {
+	t.Setenv("TLS_SNI_CERTS", "/certs/a.pem:/certs/a.key, /certs/b.pem:/certs/b.key")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	want := []TLSCertKeyPair{
+		{CertPath: "/certs/a.pem", KeyPath: "/certs/a.key"},
+		{CertPath: "/certs/b.pem", KeyPath: "/certs/b.key"},
+	}
+	if !slices.Equal(cfg.TLSSNICerts, want) {
+		t.Errorf("TLSSNICerts = %v, want %v", cfg.TLSSNICerts, want)
+	}
+}
+
+func TestLoadConfig_ServerHardeningErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		envVar string
+		value  string
+	}{
+		{"unknown TLS version", "TLS_MIN_VERSION", "2.0"},
+		{"unknown cipher suite", "TLS_CIPHER_SUITES", "TLS_FAKE_SUITE"},
+		{"insecure cipher suite", "TLS_CIPHER_SUITES", "TLS_RSA_WITH_RC4_128_SHA"},
+		{"invalid header size", "MAX_HEADER_BYTES", "lots"},
+		{"missing client CA file", "TLS_CLIENT_CA_PATH", "/nonexistent/ca.pem"},
+		{"unknown client auth", "TLS_CLIENT_AUTH", "optional"},
+		{"SNI certificate without key", "TLS_SNI_CERTS", "a.pem:b.key,c.pem"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.envVar, tt.value)
+			if _, err := LoadConfig(); err == nil {
+				t.Errorf("LoadConfig() expected error for %s=%s", tt.envVar, tt.value)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package telegramreceiver
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Conversation routes the next message from a user in a chat to a waiting
+// handler, for multi-step flows such as asking a question and awaiting the
+// answer. Messages are fed in through Deliver or the Handler middleware.
+// The zero value is not usable; create one with NewConversation.
+type Conversation struct {
+	mu      sync.Mutex
+	waiters map[conversationKey]*conversationWaiter
+}
+
+type conversationKey struct {
+	chatID int64
+	userID int64
+}
+
+type conversationWaiter struct {
+	key   conversationKey // Current key, updated by MigrateChat
+	ch    chan *Message
+	timer *time.Timer
+}
+
+// NewConversation creates an empty Conversation.
+func NewConversation() *Conversation {
+	return &Conversation{waiters: make(map[conversationKey]*conversationWaiter)}
+}
+
+// Expect registers interest in the next message from userID in chatID. The
+// returned channel receives that message, or is closed without a value once
+// timeout elapses. A later Expect for the same pair replaces the earlier one,
+// whose channel is closed.
+func (c *Conversation) Expect(chatID, userID int64, timeout time.Duration) <-chan *Message {
+	key := conversationKey{chatID: chatID, userID: userID}
+	w := &conversationWaiter{key: key, ch: make(chan *Message, 1)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.waiters[key]; ok {
+		old.timer.Stop()
+		close(old.ch)
+	}
+	c.waiters[key] = w
+	w.timer = time.AfterFunc(timeout, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		// The waiter may already have been served, replaced or migrated
+		if c.waiters[w.key] == w {
+			delete(c.waiters, w.key)
+			close(w.ch)
+		}
+	})
+	return w.ch
+}
+
+// Deliver hands the update's message to a matching waiter and reports
+// whether it was consumed. Updates without a message or sender are ignored.
+func (c *Conversation) Deliver(update TelegramUpdate) bool {
+	msg := update.Message
+	if msg == nil || msg.From == nil || msg.Chat == nil {
+		return false
+	}
+	key := conversationKey{chatID: msg.Chat.ID, userID: msg.From.ID}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	w, ok := c.waiters[key]
+	if !ok {
+		return false
+	}
+	delete(c.waiters, key)
+	w.timer.Stop()
+	w.ch <- msg // buffered, never blocks
+	close(w.ch)
+	return true
+}
+
+// MigrateChat moves waiters registered for chat from to chat to, for a
+// group upgraded to a supergroup (see Message.Migration).
+func (c *Conversation) MigrateChat(from, to int64) {
+	if from == to {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, w := range c.waiters {
+		if key.chatID != from {
+			continue
+		}
+		delete(c.waiters, key)
+		moved := conversationKey{chatID: to, userID: key.userID}
+		if old, ok := c.waiters[moved]; ok {
+			old.timer.Stop()
+			close(old.ch)
+		}
+		w.key = moved
+		c.waiters[moved] = w
+	}
+}
+
+// Pending returns the number of registered waiters.
+func (c *Conversation) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Handler returns an UpdateHandler that delivers expected messages to their
+// waiters and passes every other update to next. next may be nil.
+func (c *Conversation) Handler(next UpdateHandler) UpdateHandler {
+	return UpdateHandlerFunc(func(ctx context.Context, update TelegramUpdate) error {
+		if c.Deliver(update) || next == nil {
+			return nil
+		}
+		return next.HandleUpdate(ctx, update)
+	})
+}
@@ -0,0 +1,119 @@
+package telegramreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func conversationUpdate(chatID, userID int64, text string) TelegramUpdate {
+	return TelegramUpdate{Message: &Message{
+		Chat: &Chat{ID: chatID, Type: "private"},
+		From: &User{ID: userID},
+		Text: text,
+	}}
+}
+
+func TestConversation_QuestionAnswer(t *testing.T) {
+	conv := NewConversation()
+	var passed []string
+	handler := conv.Handler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+		passed = append(passed, u.Message.Text)
+		return nil
+	}))
+
+	answer := conv.Expect(10, 1, time.Second)
+
+	// Another user in the same chat is not the expected reply
+	if err := handler.HandleUpdate(context.Background(), conversationUpdate(10, 2, "not me")); err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	if err := handler.HandleUpdate(context.Background(), conversationUpdate(10, 1, "42")); err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+
+	select {
+	case msg := <-answer:
+		if msg == nil || msg.Text != "42" {
+			t.Fatalf("expected answer 42, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("answer not delivered")
+	}
+	if len(passed) != 1 || passed[0] != "not me" {
+		t.Errorf("expected only the unrelated message to reach next, got %q", passed)
+	}
+
+	// The waiter is consumed; the next message goes to the handler again
+	handler.HandleUpdate(context.Background(), conversationUpdate(10, 1, "again"))
+	if len(passed) != 2 {
+		t.Errorf("expected follow-up message to reach next, got %q", passed)
+	}
+}
+
+func TestConversation_Timeout(t *testing.T) {
+	conv := NewConversation()
+	answer := conv.Expect(10, 1, 10*time.Millisecond)
+
+	select {
+	case msg, ok := <-answer:
+		if ok {
+			t.Fatalf("expected closed channel on timeout, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter did not expire")
+	}
+	if n := conv.Pending(); n != 0 {
+		t.Errorf("expected no pending waiters, got %d", n)
+	}
+	if conv.Deliver(conversationUpdate(10, 1, "late")) {
+		t.Error("late reply must not be consumed")
+	}
+}
+
+func TestConversation_ExpectReplaces(t *testing.T) {
+	conv := NewConversation()
+	first := conv.Expect(10, 1, time.Second)
+	second := conv.Expect(10, 1, time.Second)
+
+	if _, ok := <-first; ok {
+		t.Error("expected the replaced waiter to be closed")
+	}
+	conv.Deliver(conversationUpdate(10, 1, "hi"))
+	if msg := <-second; msg == nil || msg.Text != "hi" {
+		t.Errorf("expected reply on the newest waiter, got %+v", msg)
+	}
+}
+
+func TestConversation_MigrateChat(t *testing.T) {
+	conv := NewConversation()
+	answer := conv.Expect(-4001, 1, time.Second)
+
+	conv.MigrateChat(-4001, -1009001)
+
+	// The reply now arrives from the supergroup
+	if conv.Deliver(conversationUpdate(-4001, 1, "old chat")) {
+		t.Error("the old chat ID must no longer match")
+	}
+	if !conv.Deliver(conversationUpdate(-1009001, 1, "42")) {
+		t.Fatal("expected the migrated waiter to consume the message")
+	}
+	if msg := <-answer; msg == nil || msg.Text != "42" {
+		t.Errorf("expected answer 42, got %+v", msg)
+	}
+
+	// A migrated waiter still times out
+	timeout := conv.Expect(-4002, 1, 10*time.Millisecond)
+	conv.MigrateChat(-4002, -1009002)
+	select {
+	case msg, ok := <-timeout:
+		if ok {
+			t.Errorf("expected timeout, got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("migrated waiter did not time out")
+	}
+	if conv.Pending() != 0 {
+		t.Errorf("expected no pending waiters, got %d", conv.Pending())
+	}
+}
@@ -0,0 +1,48 @@
+package telegramreceiver
+
+import (
+	"encoding/json"
+	"log/slog"
+)
+
+// UpdateDecoder decodes the raw value of a top-level update field that
+// TelegramUpdate does not model, such as an update type added to the Bot
+// API after this release (see WithCustomUpdateDecoder).
+type UpdateDecoder func(raw json.RawMessage) (any, error)
+
+// decodeCustomFields runs the decoders registered for the top-level fields
+// present in data and stores their results in upd.Extra. A field whose
+// decoder fails is logged and left out, so the update is still delivered.
+func decodeCustomFields(logger *slog.Logger, decoders map[string]UpdateDecoder, data []byte, upd *TelegramUpdate) {
+	if len(decoders) == 0 {
+		return
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return // Already reported by the update decoding
+	}
+	for name, raw := range fields {
+		decode, ok := decoders[name]
+		if !ok {
+			continue
+		}
+		v, err := decode(raw)
+		if err != nil {
+			logger.Warn("custom update decoder failed", "update_id", upd.UpdateID, "field", name, "error", err)
+			continue
+		}
+		if upd.Extra == nil {
+			upd.Extra = make(map[string]any)
+		}
+		upd.Extra[name] = v
+	}
+}
+
+// addUpdateDecoder registers decode for field in *decoders, creating the
+// map on first use.
+func addUpdateDecoder(decoders *map[string]UpdateDecoder, field string, decode UpdateDecoder) {
+	if *decoders == nil {
+		*decoders = make(map[string]UpdateDecoder)
+	}
+	(*decoders)[field] = decode
+}
@@ -0,0 +1,127 @@
+package telegramreceiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storyReaction is the value of a hypothetical update type.
+type storyReaction struct {
+	StoryID int    `json:"story_id"`
+	Emoji   string `json:"emoji"`
+}
+
+func decodeStoryReaction(raw json.RawMessage) (any, error) {
+	var r storyReaction
+	err := json.Unmarshal(raw, &r)
+	return r, err
+}
+
+func TestWebhookHandler_CustomUpdateDecoder(t *testing.T) {
+	var logs bytes.Buffer
+	updates := make(chan TelegramUpdate, 10)
+	handler := NewWebhookHandler(
+		slog.New(slog.NewTextHandler(&logs, nil)),
+		"test-secret", "", updates,
+		100, 200, 1<<20,
+		5, 2*time.Minute, 60*time.Second,
+		WithWebhookCustomUpdateDecoder("story_reaction", decodeStoryReaction),
+		WithWebhookCustomUpdateDecoder("broken", func(json.RawMessage) (any, error) {
+			return nil, errors.New("boom")
+		}),
+		WithWebhookStrictDecoding(),
+	)
+
+	body := `{"update_id": 9, "story_reaction": {"story_id": 3, "emoji": "🔥"}, "broken": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	upd := <-updates
+	if got, ok := upd.Extra["story_reaction"].(storyReaction); !ok || got != (storyReaction{StoryID: 3, Emoji: "🔥"}) {
+		t.Errorf("Extra[story_reaction] = %#v", upd.Extra["story_reaction"])
+	}
+	if _, ok := upd.Extra["broken"]; ok {
+		t.Error("a failed decoder must not populate Extra")
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "custom update decoder failed") {
+		t.Errorf("expected a decoder failure warning, got:\n%s", out)
+	}
+	if strings.Contains(out, "update has unknown fields") {
+		t.Errorf("fields with a decoder must not be reported as unknown, got:\n%s", out)
+	}
+}
+
+func TestLongPollingClient_CustomUpdateDecoder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":[{"update_id":4,"story_reaction":{"story_id":7,"emoji":"👍"}},{"update_id":5}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10),
+		WithPollCustomUpdateDecoder("story_reaction", decodeStoryReaction))
+
+	updates, err := client.fetchUpdates(context.Background())
+	if err != nil {
+		t.Fatalf("fetchUpdates: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("unexpected updates %+v", updates)
+	}
+	if got := updates[0].Extra["story_reaction"]; got != (storyReaction{StoryID: 7, Emoji: "👍"}) {
+		t.Errorf("Extra[story_reaction] = %#v", got)
+	}
+	if updates[1].Extra != nil {
+		t.Errorf("update without the field has Extra %v", updates[1].Extra)
+	}
+}
+
+func TestUnmarshalUpdateWith_CustomUpdateDecoder(t *testing.T) {
+	decoders := map[string]UpdateDecoder{"story_reaction": decodeStoryReaction}
+	data := []byte(`{"update_id": 9, "story_reaction": {"story_id": 3, "emoji": "🔥"}}`)
+
+	upd, err := UnmarshalUpdateWith(data, decoders)
+	if err != nil {
+		t.Fatalf("UnmarshalUpdateWith: %v", err)
+	}
+	if got, ok := upd.Extra["story_reaction"].(storyReaction); !ok || got.StoryID != 3 {
+		t.Errorf("Extra[story_reaction] = %#v", upd.Extra["story_reaction"])
+	}
+
+	if upd, err := UnmarshalUpdate(data); err != nil || upd.Extra != nil {
+		t.Errorf("UnmarshalUpdate() = %+v, %v; want no Extra without decoders", upd, err)
+	}
+}
+
+func TestFileSource_CustomUpdateDecoder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "replay.jsonl")
+	if err := os.WriteFile(path, []byte(`{"update_id": 1, "story_reaction": {"story_id": 7, "emoji": "👍"}}`+"\n"), 0600); err != nil {
+		t.Fatalf("writing replay file: %v", err)
+	}
+
+	updates := make(chan TelegramUpdate, 1)
+	src := NewFileSource(path, updates, newTestLogger(), WithReplayCustomUpdateDecoder("story_reaction", decodeStoryReaction))
+	if err := src.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	upd := <-updates
+	if got, ok := upd.Extra["story_reaction"].(storyReaction); !ok || got.StoryID != 7 {
+		t.Errorf("Extra[story_reaction] = %#v", upd.Extra["story_reaction"])
+	}
+}
@@ -0,0 +1,70 @@
+package telegramreceiver
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+)
+
+// DebugTap observes raw Bot API traffic for troubleshooting. direction is
+// DebugTapRequest or DebugTapResponse and method is the Bot API method name
+// (for example "getUpdates"). For requests without a body, such as
+// getUpdates, body holds the URL query instead. The bot token is never
+// passed to the tap, but bodies are verbatim and may carry other secrets,
+// such as the secret_token sent by setWebhook.
+//
+// The tap runs synchronously on the request path; body must not be retained
+// or modified after it returns.
+type DebugTap func(direction, method string, body []byte)
+
+// Directions passed to a DebugTap.
+const (
+	DebugTapRequest  = "request"
+	DebugTapResponse = "response"
+)
+
+// NewDebugTapClient wraps client so every request and response body is
+// passed to tap. Use it with the *WithClient API functions, for example
+// SetWebhookWithClient, to observe webhook API calls, or pass it to
+// WithHTTPClientOption in any mode.
+func NewDebugTapClient(client HTTPClient, tap DebugTap) HTTPClient {
+	return &tapClient{next: client, tap: tap}
+}
+
+// tapClient is an httpClient that reports traffic to a DebugTap.
+type tapClient struct {
+	next httpClient
+	tap  DebugTap
+}
+
+func (c *tapClient) Do(req *http.Request) (*http.Response, error) {
+	// The method name is the last path segment; the token segment is skipped
+	method := path.Base(req.URL.Path)
+
+	body := []byte(req.URL.RawQuery)
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	c.tap(DebugTapRequest, method, body)
+
+	resp, err := c.next.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	c.tap(DebugTapResponse, method, respBody)
+	return resp, nil
+}
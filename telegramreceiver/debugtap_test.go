@@ -0,0 +1,129 @@
+package telegramreceiver
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tapRecord is a single DebugTap invocation.
+type tapRecord struct {
+	direction, method, body string
+}
+
+// recordingTap returns a DebugTap that appends to the returned slice.
+func recordingTap() (DebugTap, func() []tapRecord) {
+	var mu sync.Mutex
+	var records []tapRecord
+	tap := func(direction, method string, body []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, tapRecord{direction, method, string(body)})
+	}
+	get := func() []tapRecord {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]tapRecord(nil), records...)
+	}
+	return tap, get
+}
+
+func TestDebugTap_GetUpdates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":[{"update_id":5}]}`))
+	}))
+	defer server.Close()
+
+	tap, records := recordingTap()
+	updates := make(chan TelegramUpdate, 10)
+	client := newTestPollingClient(server, updates, WithPollDebugTap(tap))
+
+	got, err := client.fetchUpdates(context.Background())
+	if err != nil {
+		t.Fatalf("fetchUpdates: %v", err)
+	}
+	if len(got) != 1 || got[0].UpdateID != 5 {
+		t.Fatalf("response body not preserved for decoding: %+v", got)
+	}
+
+	recs := records()
+	if len(recs) != 2 {
+		t.Fatalf("expected request and response taps, got %+v", recs)
+	}
+	if recs[0].direction != DebugTapRequest || recs[0].method != "getUpdates" || !strings.Contains(recs[0].body, "offset=") {
+		t.Errorf("unexpected request tap: %+v", recs[0])
+	}
+	if recs[1].direction != DebugTapResponse || recs[1].method != "getUpdates" || recs[1].body != `{"ok":true,"result":[{"update_id":5}]}` {
+		t.Errorf("unexpected response tap: %+v", recs[1])
+	}
+	for _, r := range recs {
+		if strings.Contains(r.method+r.body, "test-token") {
+			t.Errorf("tap saw the bot token: %+v", r)
+		}
+	}
+}
+
+func TestDebugTap_WebhookAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer server.Close()
+
+	tap, records := recordingTap()
+	client := NewDebugTapClient(newTestAPIClient(server), tap)
+
+	if err := SetWebhookWithClient(context.Background(), client, SecretToken("123:SECRET"), "https://example.com/hook", "s3cret"); err != nil {
+		t.Fatalf("SetWebhookWithClient: %v", err)
+	}
+
+	recs := records()
+	if len(recs) != 2 {
+		t.Fatalf("expected request and response taps, got %+v", recs)
+	}
+	if recs[0].method != "setWebhook" || !strings.Contains(recs[0].body, `"url":"https://example.com/hook"`) {
+		t.Errorf("unexpected request tap: %+v", recs[0])
+	}
+	if recs[1].body != `{"ok":true,"result":true}` {
+		t.Errorf("unexpected response tap: %+v", recs[1])
+	}
+}
+
+func TestDebugTap_ClientPolling(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":[{"update_id":5}]}`))
+	}))
+	defer server.Close()
+
+	// A tap client is not an *http.Client; the poller must accept it
+	tap, records := recordingTap()
+	client, err := New(testBotToken,
+		WithMode(ModeLongPolling),
+		WithPolling(0, 100),
+		WithHTTPClientOption(NewDebugTapClient(newTestAPIClient(server), tap)),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	select {
+	case upd := <-client.Updates():
+		if upd.UpdateID != 5 {
+			t.Errorf("update ID = %d, want 5", upd.UpdateID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update polled through the tap client")
+	}
+	if recs := records(); len(recs) == 0 || recs[0].method != "getUpdates" {
+		t.Errorf("expected getUpdates taps, got %+v", recs)
+	}
+}
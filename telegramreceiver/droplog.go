@@ -0,0 +1,66 @@
+package telegramreceiver
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// defaultDropLogInterval is how often dropped updates are summarized.
+const defaultDropLogInterval = 10 * time.Second
+
+// dropLogger rate-limits the log lines written for dropped updates. The
+// first drop after a quiet period is logged immediately with its update ID;
+// further drops within the interval are counted and reported in a single
+// summary line once the interval has passed.
+type dropLogger struct {
+	logger   *slog.Logger
+	msg      string        // Per-update message, e.g. "updates channel full, dropping update"
+	summary  string        // Summary format taking the count and interval
+	interval time.Duration // 0 logs every drop
+
+	mu      sync.Mutex
+	lastLog time.Time
+	pending int
+	timer   *time.Timer
+}
+
+func newDropLogger(logger *slog.Logger, msg string, interval time.Duration) *dropLogger {
+	return &dropLogger{logger: logger, msg: msg, summary: "dropped %d updates in last %s", interval: interval}
+}
+
+// drop records a dropped update.
+func (d *dropLogger) drop(updateID int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if d.interval <= 0 || (d.pending == 0 && now.Sub(d.lastLog) >= d.interval) {
+		d.logger.Warn(d.msg, "update_id", updateID)
+		d.lastLog = now
+		return
+	}
+
+	d.pending++
+	if d.timer == nil {
+		d.timer = time.AfterFunc(d.lastLog.Add(d.interval).Sub(now), d.flush)
+	}
+}
+
+// flush writes the summary line for drops counted since the last log.
+func (d *dropLogger) flush() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.timer = nil
+	if d.pending == 0 {
+		return
+	}
+	d.logger.Warn(fmt.Sprintf(d.summary, d.pending, d.interval),
+		"dropped", d.pending,
+		"reason", d.msg,
+	)
+	d.pending = 0
+	d.lastLog = time.Now()
+}
@@ -0,0 +1,98 @@
+package telegramreceiver
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer safe for concurrent log writes.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Split(strings.TrimSpace(b.buf.String()), "\n")
+}
+
+func TestDropLogger_Aggregates(t *testing.T) {
+	var out lockedBuffer
+	d := newDropLogger(slog.New(slog.NewTextHandler(&out, nil)), "dropping update", 50*time.Millisecond)
+
+	for i := 1; i <= 100; i++ {
+		d.drop(i)
+	}
+
+	lines := out.lines()
+	if len(lines) != 1 || !strings.Contains(lines[0], "update_id=1") {
+		t.Fatalf("expected only the first drop to be logged immediately, got %q", lines)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	lines = out.lines()
+	if len(lines) != 2 {
+		t.Fatalf("expected one summary line after the interval, got %q", lines)
+	}
+	if !strings.Contains(lines[1], "dropped 99 updates") || !strings.Contains(lines[1], "dropped=99") {
+		t.Errorf("unexpected summary line: %s", lines[1])
+	}
+
+	// A drop after a quiet period is logged immediately again
+	time.Sleep(60 * time.Millisecond)
+	d.drop(101)
+	if lines = out.lines(); len(lines) != 3 || !strings.Contains(lines[2], "update_id=101") {
+		t.Errorf("expected immediate log after quiet period, got %q", lines)
+	}
+}
+
+func TestDropLogger_ZeroIntervalLogsEachDrop(t *testing.T) {
+	var out lockedBuffer
+	d := newDropLogger(slog.New(slog.NewTextHandler(&out, nil)), "dropping update", 0)
+
+	for i := 1; i <= 5; i++ {
+		d.drop(i)
+	}
+	if lines := out.lines(); len(lines) != 5 {
+		t.Errorf("expected 5 log lines, got %d", len(lines))
+	}
+}
+
+func TestWebhookHandler_DropLogAggregation(t *testing.T) {
+	var out lockedBuffer
+	handler := NewWebhookHandler(
+		slog.New(slog.NewTextHandler(&out, nil)),
+		"", "",
+		make(chan TelegramUpdate), // unbuffered, never read: every update is rejected
+		1000, 1000, 1<<20,
+		1000, time.Minute, time.Minute,
+		WithWebhookDropLogInterval(time.Hour),
+	)
+
+	// Stay below the breaker's default trip threshold of 6 consecutive failures
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"update_id":1}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503, got %d", rec.Code)
+		}
+	}
+
+	if lines := out.lines(); len(lines) != 1 {
+		t.Errorf("expected a single log line for 5 rejections, got %d: %q", len(lines), lines)
+	}
+}
@@ -41,7 +41,9 @@ var (
 	ErrMethodNotAllowed = &WebhookError{Code: 405, Message: "method not allowed"}
 	ErrChannelBlocked   = &WebhookError{Code: 503, Message: "updates channel blocked"}
 	ErrBodyReadFailed   = &WebhookError{Code: 500, Message: "failed to read request body"}
+	ErrBodyTooLarge     = &WebhookError{Code: 413, Message: "request body too large"}
 	ErrInvalidJSON      = &WebhookError{Code: 400, Message: "invalid JSON payload"}
+	ErrShuttingDown     = &WebhookError{Code: 503, Message: "server shutting down"}
 )
 
 // Sentinel errors for configuration.
@@ -51,6 +53,9 @@ var (
 	ErrInvalidPollingTimeout = errors.New("POLLING_TIMEOUT must be between 0 and 60")
 	ErrInvalidPollingLimit   = errors.New("POLLING_LIMIT must be between 1 and 100")
 	ErrInvalidWebhookURL     = errors.New("WEBHOOK_URL must be a valid HTTPS URL")
+	ErrInvalidBotToken       = errors.New("invalid bot token")
+	ErrNotReloadable         = errors.New("cannot be changed without restarting the client")
+	ErrClientStopped         = errors.New("client has been stopped; create a new one to restart")
 )
 
 // Sentinel errors for long polling runtime.
@@ -58,8 +63,20 @@ var (
 	ErrPollingAlreadyRunning = errors.New("long polling client is already running")
 	ErrMaxRetriesExceeded    = errors.New("max consecutive retries exceeded")
 	ErrUpdatesChannelFull    = errors.New("updates channel is full, dropping update")
+	ErrPollingConflict       = errors.New("getUpdates conflict: another instance is polling this bot or a webhook is set")
+	ErrStartupTimeout        = errors.New("startup timeout exceeded")
+	ErrUnauthorizedToken     = errors.New("bot token rejected by Telegram (revoked or invalid)")
+	ErrTruncatedResponse     = errors.New("response truncated, connection dropped mid-body")
 )
 
+// ErrWebhookUnreachable is returned when webhook verification times out
+// while Telegram still reports a delivery error for the registered URL.
+var ErrWebhookUnreachable = errors.New("telegram cannot deliver to the webhook URL")
+
+// ErrHandlerTimeout is the cause of a handler context cancelled by the
+// handler timeout (see WithHandlerTimeout).
+var ErrHandlerTimeout = errors.New("update handler timed out")
+
 // TelegramAPIError represents an error response from the Telegram Bot API.
 type TelegramAPIError struct {
 	Code        int
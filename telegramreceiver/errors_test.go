@@ -53,6 +53,7 @@ func TestSentinelErrors(t *testing.T) {
 		{"ErrChannelBlocked", ErrChannelBlocked, 503},
 		{"ErrBodyReadFailed", ErrBodyReadFailed, 500},
 		{"ErrInvalidJSON", ErrInvalidJSON, 400},
+		{"ErrShuttingDown", ErrShuttingDown, 503},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,90 @@
+package telegramreceiver
+
+import (
+	"sync"
+)
+
+// fanout routes updates to per-type channels. Updates without a typed
+// channel are forwarded to the generic channel unchanged.
+type fanout struct {
+	in      chan TelegramUpdate // Receivers (or the spool) write here
+	generic chan TelegramUpdate // Unrouted update types
+
+	messages        chan *Message
+	editedMessages  chan *Message
+	callbackQueries chan *CallbackQuery
+
+	startOnce sync.Once
+	stopCh    chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// newFanout creates a fanout forwarding unrouted updates to generic. Call
+// start to run it. Typed channels share generic's capacity.
+func newFanout(generic chan TelegramUpdate) *fanout {
+	size := cap(generic)
+	f := &fanout{
+		in:              make(chan TelegramUpdate, size),
+		generic:         generic,
+		messages:        make(chan *Message, size),
+		editedMessages:  make(chan *Message, size),
+		callbackQueries: make(chan *CallbackQuery, size),
+		stopCh:          make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+	return f
+}
+
+// start runs the routing goroutine. It is a no-op after the first call or
+// after stop.
+func (f *fanout) start() {
+	f.startOnce.Do(func() { go f.run() })
+}
+
+func (f *fanout) run() {
+	defer close(f.done)
+
+	for {
+		var upd TelegramUpdate
+		select {
+		case <-f.stopCh:
+			return
+		case upd = <-f.in:
+		}
+
+		// Blocking sends keep backpressure on the receivers; a stalled
+		// consumer of one channel therefore delays all others.
+		var ok bool
+		switch upd.Type() {
+		case UpdateTypeMessage:
+			ok = sendOrStop(f.messages, upd.Message, f.stopCh)
+		case UpdateTypeEditedMessage:
+			ok = sendOrStop(f.editedMessages, upd.EditedMessage, f.stopCh)
+		case UpdateTypeCallbackQuery:
+			ok = sendOrStop(f.callbackQueries, upd.CallbackQuery, f.stopCh)
+		default:
+			ok = sendOrStop(f.generic, upd, f.stopCh)
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// sendOrStop sends v on ch, giving up when stop is closed.
+func sendOrStop[T any](ch chan<- T, v T, stop <-chan struct{}) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// stop terminates the routing goroutine. Pending updates are discarded.
+func (f *fanout) stop() {
+	f.closeOnce.Do(func() { close(f.stopCh) })
+	f.startOnce.Do(func() { close(f.done) }) // never started
+	<-f.done
+}
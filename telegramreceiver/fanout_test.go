@@ -0,0 +1,124 @@
+package telegramreceiver
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTypedTestClient returns a webhook-mode client with typed channels and
+// a handler to feed it updates.
+func newTypedTestClient(t *testing.T, opts ...Option) (*Client, http.Handler) {
+	t.Helper()
+	opts = append([]Option{
+		WithWebhook(8443, ""),
+		WithRateLimit(1000, 1000),
+		WithTypedChannels(),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	}, opts...)
+	client, err := New(testBotToken, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(client.Stop)
+	return client, client.WebhookHandler()
+}
+
+func postUpdate(t *testing.T, handler http.Handler, payload string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestClient_TypedChannels(t *testing.T) {
+	client, handler := newTypedTestClient(t)
+
+	postUpdate(t, handler, `{"update_id":1,"message":{"message_id":10,"date":0,"chat":{"id":1,"type":"private"},"text":"hi"}}`)
+	postUpdate(t, handler, `{"update_id":2,"callback_query":{"id":"cb1","from":{"id":1,"is_bot":false,"first_name":"A"},"chat_instance":"x","data":"buy"}}`)
+	postUpdate(t, handler, `{"update_id":3,"edited_message":{"message_id":10,"date":0,"chat":{"id":1,"type":"private"},"text":"hi!"}}`)
+
+	select {
+	case msg := <-client.Messages():
+		if msg.MessageID != 10 || msg.Text != "hi" {
+			t.Errorf("unexpected message: %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+
+	select {
+	case cq := <-client.CallbackQueries():
+		if cq.ID != "cb1" || cq.Data != "buy" {
+			t.Errorf("unexpected callback query: %+v", cq)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for callback query")
+	}
+
+	select {
+	case msg := <-client.EditedMessages():
+		if msg.Text != "hi!" {
+			t.Errorf("unexpected edited message: %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for edited message")
+	}
+
+	select {
+	case upd := <-client.Updates():
+		t.Errorf("routed update leaked to Updates(): %d", upd.UpdateID)
+	default:
+	}
+}
+
+func TestClient_TypedChannelsUnroutedType(t *testing.T) {
+	client, handler := newTypedTestClient(t)
+
+	// An update kind without a typed channel stays on Updates()
+	postUpdate(t, handler, `{"update_id":5,"poll":{"id":"p1"}}`)
+
+	select {
+	case upd := <-client.Updates():
+		if upd.UpdateID != 5 || upd.Type() != UpdateTypeUnknown {
+			t.Errorf("unexpected update: %+v", upd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for unrouted update")
+	}
+}
+
+func TestClient_TypedChannelsWithSpool(t *testing.T) {
+	client, handler := newTypedTestClient(t, WithSpool(50))
+
+	for i := 1; i <= 3; i++ {
+		body, _ := json.Marshal(TelegramUpdate{UpdateID: i, Message: &Message{MessageID: i, Chat: &Chat{ID: 1}}})
+		postUpdate(t, handler, string(body))
+	}
+	for i := 1; i <= 3; i++ {
+		select {
+		case msg := <-client.Messages():
+			if msg.MessageID != i {
+				t.Fatalf("expected message %d, got %d", i, msg.MessageID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for message %d", i)
+		}
+	}
+}
+
+func TestClient_TypedChannelsDisabled(t *testing.T) {
+	client, err := New(testBotToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.Messages() != nil || client.EditedMessages() != nil || client.CallbackQueries() != nil {
+		t.Error("expected nil typed channels without WithTypedChannels")
+	}
+}
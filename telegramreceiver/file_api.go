@@ -0,0 +1,44 @@
+package telegramreceiver
+
+import (
+	"context"
+	"strings"
+)
+
+const telegramFileBaseURL = "https://api.telegram.org/file/bot"
+
+// File describes a file ready to be downloaded. FilePath is valid for at
+// least one hour after GetFile returns; call GetFile again once it expires.
+type File struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileSize     int64  `json:"file_size,omitempty"`
+	FilePath     string `json:"file_path,omitempty"`
+}
+
+// getFileRequest is the request body for getFile API call.
+type getFileRequest struct {
+	FileID string `json:"file_id"`
+}
+
+// GetFile retrieves the download path for a file, such as a photo or
+// document received in a message. Pass File.FilePath to FileDownloadURL.
+func GetFile(ctx context.Context, botToken SecretToken, fileID string) (*File, error) {
+	return GetFileWithClient(ctx, defaultHTTPClient(), botToken, fileID)
+}
+
+// GetFileWithClient retrieves file information using a custom HTTP client.
+// Use this for testing or when you need custom HTTP configuration.
+func GetFileWithClient(ctx context.Context, client httpClient, botToken SecretToken, fileID string) (*File, error) {
+	file, err := call[File](ctx, client, botToken, "getFile", getFileRequest{FileID: fileID})
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// FileDownloadURL returns the URL to download a file by its FilePath.
+// The URL embeds the bot token: never log it or expose it to users.
+func FileDownloadURL(botToken SecretToken, filePath string) string {
+	return telegramFileBaseURL + botToken.Value() + "/" + strings.TrimPrefix(filePath, "/")
+}
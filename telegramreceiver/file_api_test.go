@@ -0,0 +1,103 @@
+package telegramreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/getFile") {
+			t.Errorf("expected getFile in path, got %s", r.URL.Path)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var req getFileRequest
+		json.Unmarshal(body, &req)
+		if req.FileID != "AgADBAAD" {
+			t.Errorf("expected file_id AgADBAAD, got %q", req.FileID)
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"ok": true,
+			"result": map[string]any{
+				"file_id":        "AgADBAAD",
+				"file_unique_id": "AQADxyz",
+				"file_size":      52345,
+				"file_path":      "photos/file_7.jpg",
+			},
+		})
+	}))
+	defer server.Close()
+
+	file, err := GetFileWithClient(context.Background(), newTestAPIClient(server), SecretToken("test-token"), "AgADBAAD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := File{FileID: "AgADBAAD", FileUniqueID: "AQADxyz", FileSize: 52345, FilePath: "photos/file_7.jpg"}
+	if *file != want {
+		t.Errorf("got %+v, want %+v", *file, want)
+	}
+}
+
+func TestGetFile_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: invalid file_id"}`))
+	}))
+	defer server.Close()
+
+	_, err := GetFileWithClient(context.Background(), newTestAPIClient(server), SecretToken("test-token"), "bogus")
+	var apiErr *TelegramAPIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 400 {
+		t.Fatalf("expected TelegramAPIError 400, got %v", err)
+	}
+}
+
+func TestFileDownloadURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		want     string
+	}{
+		{"relative path", "photos/file_7.jpg", "https://api.telegram.org/file/bot123:ABC/photos/file_7.jpg"},
+		{"leading slash", "/documents/file_1.pdf", "https://api.telegram.org/file/bot123:ABC/documents/file_1.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FileDownloadURL(SecretToken("123:ABC"), tt.filePath); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFile_TransportErrorRedactsToken(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})}
+
+	_, err := GetFileWithClient(context.Background(), client, SecretToken("123:SECRET"), "AgADBAAD")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("error leaks bot token: %v", err)
+	}
+	if !strings.Contains(err.Error(), "/bot[REDACTED]/getFile") {
+		t.Errorf("expected redacted URL in error, got %v", err)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
@@ -0,0 +1,122 @@
+package telegramreceiver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"golang.org/x/time/rate"
+)
+
+// maxReplayLineSize bounds a single JSONL line, matching the largest update
+// a webhook would accept with a generous body limit.
+const maxReplayLineSize = 10 << 20
+
+// FileSourceOption configures a FileSource.
+type FileSourceOption func(*FileSource)
+
+// WithReplayRate limits replay to perSecond updates per second. The default
+// of 0 replays as fast as the updates channel is drained.
+func WithReplayRate(perSecond float64) FileSourceOption {
+	return func(s *FileSource) {
+		if perSecond > 0 {
+			s.limiter = rate.NewLimiter(rate.Limit(perSecond), 1)
+		}
+	}
+}
+
+// WithReplayCloseOnDone closes the updates channel when Run returns, so a
+// consumer ranging over it finishes after the last replayed update.
+func WithReplayCloseOnDone() FileSourceOption {
+	return func(s *FileSource) {
+		s.closeOnDone = true
+	}
+}
+
+// WithReplayCustomUpdateDecoder decodes the top-level update field into
+// TelegramUpdate.Extra, as WithCustomUpdateDecoder does for receivers.
+func WithReplayCustomUpdateDecoder(field string, decode UpdateDecoder) FileSourceOption {
+	return func(s *FileSource) {
+		addUpdateDecoder(&s.customDecoders, field, decode)
+	}
+}
+
+// FileSource replays updates captured as newline-delimited JSON, one
+// TelegramUpdate per line, into an updates channel, for debugging and
+// regression tests of update handlers. Blank lines are ignored; lines that
+// do not decode are logged and skipped.
+type FileSource struct {
+	path    string
+	updates chan<- TelegramUpdate
+	logger  *slog.Logger
+
+	limiter        *rate.Limiter
+	closeOnDone    bool
+	customDecoders map[string]UpdateDecoder
+}
+
+// NewFileSource creates a replay of the JSONL file at path into updates.
+func NewFileSource(path string, updates chan<- TelegramUpdate, logger *slog.Logger, opts ...FileSourceOption) *FileSource {
+	if updates == nil {
+		panic("telegramreceiver: NewFileSource: updates channel is nil")
+	}
+	s := &FileSource{path: path, updates: updates, logger: logger}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run replays the file and returns once every line was delivered, or with
+// ctx's error when cancelled. Deliveries block until the channel accepts
+// them, so no update is dropped.
+func (s *FileSource) Run(ctx context.Context) error {
+	if s.closeOnDone {
+		defer close(s.updates)
+	}
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("opening replay file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxReplayLineSize)
+
+	var line, replayed, skipped int
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		upd, err := unmarshalUpdate(s.logger, data, s.customDecoders, false)
+		if err != nil {
+			skipped++
+			s.logger.Warn("skipping invalid replay line", "line", line, "error", err)
+			continue
+		}
+
+		if s.limiter != nil {
+			if err := s.limiter.Wait(ctx); err != nil {
+				return err
+			}
+		}
+		select {
+		case s.updates <- upd:
+			replayed++
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading replay file at line %d: %w", line+1, err)
+	}
+
+	s.logger.Info("replay finished", "file", s.path, "replayed", replayed, "skipped", skipped)
+	return nil
+}
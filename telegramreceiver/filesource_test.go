@@ -0,0 +1,74 @@
+package telegramreceiver
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSource_Replay(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	src := NewFileSource(filepath.Join("testdata", "replay.jsonl"), updates, newTestLogger(), WithReplayCloseOnDone())
+
+	if err := src.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var got []TelegramUpdate
+	for upd := range updates {
+		got = append(got, upd)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 replayed updates (blank and invalid lines skipped), got %d", len(got))
+	}
+	wantTypes := []UpdateType{UpdateTypeMessage, UpdateTypeEditedMessage, UpdateTypeCallbackQuery}
+	for i, upd := range got {
+		if upd.UpdateID != 100+i || upd.Type() != wantTypes[i] {
+			t.Errorf("update %d: id %d type %q, want %d %q", i, upd.UpdateID, upd.Type(), 100+i, wantTypes[i])
+		}
+		if upd.ReceivedAt.IsZero() {
+			t.Errorf("update %d: ReceivedAt not set", i)
+		}
+	}
+	if got[0].Message.Text != "/start" {
+		t.Errorf("unexpected message text %q", got[0].Message.Text)
+	}
+}
+
+func TestFileSource_Rate(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	src := NewFileSource(filepath.Join("testdata", "replay.jsonl"), updates, newTestLogger(), WithReplayRate(20))
+
+	start := time.Now()
+	if err := src.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// The first update passes immediately, the other two wait 50ms each
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("replay at 20/s took %v, expected about 100ms", elapsed)
+	}
+	if len(updates) != 3 {
+		t.Errorf("expected 3 updates, got %d", len(updates))
+	}
+}
+
+func TestFileSource_Cancel(t *testing.T) {
+	// Unbuffered and never read: Run blocks on the first delivery
+	updates := make(chan TelegramUpdate)
+	src := NewFileSource(filepath.Join("testdata", "replay.jsonl"), updates, newTestLogger())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := src.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestFileSource_MissingFile(t *testing.T) {
+	src := NewFileSource(filepath.Join(t.TempDir(), "missing.jsonl"), make(chan TelegramUpdate), newTestLogger())
+	if err := src.Run(context.Background()); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -1,8 +1,12 @@
 package telegramreceiver
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
-	"strconv"
+	"fmt"
+	"net"
+	"regexp"
 	"strings"
 )
 
@@ -26,15 +30,55 @@ func validateConfig(cfg *Config) error {
 
 // validateWebhookConfig validates webhook-specific configuration.
 func validateWebhookConfig(cfg *Config) error {
-	if cfg.WebhookPort < 1 || cfg.WebhookPort > 65535 {
-		return errors.New("WebhookPort must be 1-65535")
+	if cfg.WebhookPort < 0 || cfg.WebhookPort > 65535 {
+		return errors.New("WebhookPort must be 0-65535 (0 picks a free port)")
 	}
 	if cfg.TLSCertPath == "" || cfg.TLSKeyPath == "" {
 		return errors.New("TLS_CERT_PATH and TLS_KEY_PATH must be set for webhook mode")
 	}
+	for _, pair := range cfg.TLSSNICerts {
+		if pair.CertPath == "" || pair.KeyPath == "" {
+			return errors.New("TLS_SNI_CERTS entries need both a certificate and a key path")
+		}
+	}
+	if cfg.TLSMinVersion != 0 && cfg.TLSMinVersion < tls.VersionTLS12 {
+		return errors.New("TLS_MIN_VERSION must be 1.2 or higher")
+	}
+	if len(cfg.TLSClientCAs) > 0 {
+		if _, err := clientCAPool(cfg.TLSClientCAs); err != nil {
+			return fmt.Errorf("TLS_CLIENT_CA_PATH: %w", err)
+		}
+	} else if cfg.TLSClientAuth >= tls.VerifyClientCertIfGiven {
+		// Go would otherwise verify client certificates against the system roots
+		return errors.New("TLS_CLIENT_CA_PATH must be set to verify client certificates")
+	}
+	if cfg.WebhookMaxConnections < 0 || cfg.WebhookMaxConnections > 100 {
+		return errors.New("WEBHOOK_MAX_CONNECTIONS must be 1-100 (0 uses the default of 40)")
+	}
+	if cfg.WebhookIPAddress != "" && net.ParseIP(cfg.WebhookIPAddress) == nil {
+		return errors.New("WEBHOOK_IP_ADDRESS must be an IP address")
+	}
+	if cfg.StartupTimeout < 0 {
+		return errors.New("WEBHOOK_STARTUP_TIMEOUT must not be negative")
+	}
+	if cfg.MaxHeaderBytes < 0 {
+		return errors.New("MAX_HEADER_BYTES must not be negative")
+	}
+	if cfg.MaxConcurrentRequests < 0 {
+		return errors.New("MAX_CONCURRENT_REQUESTS must not be negative")
+	}
 	return nil
 }
 
+// clientCAPool parses a PEM bundle of CAs trusted for client certificates.
+func clientCAPool(caPEM []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("no valid PEM certificates found")
+	}
+	return pool, nil
+}
+
 // validatePollingConfig validates long polling-specific configuration.
 func validatePollingConfig(cfg *Config) error {
 	if cfg.BotToken.Value() == "" {
@@ -52,32 +96,47 @@ func validatePollingConfig(cfg *Config) error {
 	return nil
 }
 
+// botTokenPattern is the accepted bot token format: a numeric bot ID of at
+// least 6 digits, a colon, and a secret of at least 30 URL-safe characters.
+var botTokenPattern = regexp.MustCompile(`^\d{6,}:[A-Za-z0-9_-]{30,}$`)
+
 // ValidateBotToken validates that the bot token has the correct format.
 // Telegram bot tokens follow the pattern: 123456789:ABCDefGhIJKlmNoPQRsTUVwxyZ
+//
+// A token is valid when it is non-empty and matches ^\d{6,}:[A-Za-z0-9_-]{30,}$.
+// The returned error wraps ErrInvalidBotToken and describes which part failed.
+// This is the single source of truth used by New, validateClientConfig and
+// the "bottoken" struct validator.
 func ValidateBotToken(token SecretToken) error {
 	t := token.Value()
 
-	if len(t) < 10 {
-		return errors.New("bot token: too short")
+	if t == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidBotToken)
 	}
 
-	if !strings.Contains(t, ":") {
-		return errors.New("bot token: must contain colon separator")
+	botID, secret, found := strings.Cut(t, ":")
+	if !found {
+		return fmt.Errorf("%w: must contain colon separator", ErrInvalidBotToken)
 	}
-
-	parts := strings.Split(t, ":")
-	if len(parts) != 2 {
-		return errors.New("bot token: must contain exactly one colon")
+	if strings.Contains(secret, ":") {
+		return fmt.Errorf("%w: must contain exactly one colon", ErrInvalidBotToken)
 	}
 
 	// First part must be a number (bot ID)
-	if _, err := strconv.ParseInt(parts[0], 10, 64); err != nil {
-		return errors.New("bot token: bot ID must be numeric")
+	if botID == "" || strings.Trim(botID, "0123456789") != "" {
+		return fmt.Errorf("%w: bot ID must be numeric", ErrInvalidBotToken)
+	}
+	if len(botID) < 6 {
+		return fmt.Errorf("%w: bot ID must be at least 6 digits", ErrInvalidBotToken)
+	}
+
+	// Second part must be at least 30 URL-safe chars (the actual token hash)
+	if len(secret) < 30 {
+		return fmt.Errorf("%w: token hash too short", ErrInvalidBotToken)
 	}
 
-	// Second part should be at least 30 chars (the actual token hash)
-	if len(parts[1]) < 30 {
-		return errors.New("bot token: token hash too short")
+	if !botTokenPattern.MatchString(t) {
+		return fmt.Errorf("%w: token hash contains invalid characters", ErrInvalidBotToken)
 	}
 
 	return nil
@@ -1,8 +1,10 @@
 package telegramreceiver
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -65,13 +67,13 @@ func TestValidateConfig(t *testing.T) {
 			name: "webhook port too low",
 			cfg: &Config{
 				ReceiverMode: ModeWebhook,
-				WebhookPort:  0,
+				WebhookPort:  -1,
 				TLSCertPath:  "/path/to/cert.pem",
 				TLSKeyPath:   "/path/to/key.pem",
 				LogFilePath:  "logs/test.log",
 			},
 			wantErr: true,
-			errMsg:  "WebhookPort must be 1-65535",
+			errMsg:  "WebhookPort must be 0-65535 (0 picks a free port)",
 		},
 		{
 			name: "webhook port too high",
@@ -83,7 +85,7 @@ func TestValidateConfig(t *testing.T) {
 				LogFilePath:  "logs/test.log",
 			},
 			wantErr: true,
-			errMsg:  "WebhookPort must be 1-65535",
+			errMsg:  "WebhookPort must be 0-65535 (0 picks a free port)",
 		},
 		{
 			name: "missing TLS cert path",
@@ -178,6 +180,60 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+func TestValidateBotToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		token       string
+		wantErr     bool
+		errContains string
+	}{
+		{"valid token", testBotToken, false, ""},
+		{"valid token with dash and underscore", "1234567:AAHdqTcvCH1vGWJxfSeofSAs0K5PALDsa_-w", false, ""},
+		{"empty", "", true, "empty"},
+		{"missing colon", "123456789ABCdefGHIjklMNOpqrSTUvwxYZ0123456789", true, "colon separator"},
+		{"two colons", "123456789:ABCdefGHIjklMNO:pqrSTUvwxYZ0123456789", true, "exactly one colon"},
+		{"non-numeric bot ID", "12345abc:ABCdefGHIjklMNOpqrSTUvwxYZ0123456789", true, "bot ID must be numeric"},
+		{"empty bot ID", ":ABCdefGHIjklMNOpqrSTUvwxYZ0123456789", true, "bot ID must be numeric"},
+		{"bot ID too short", "12345:ABCdefGHIjklMNOpqrSTUvwxYZ0123456789", true, "at least 6 digits"},
+		{"secret too short", "123456789:ABCdefGHI", true, "token hash too short"},
+		{"secret with invalid characters", "123456789:ABCdefGHIjklMNOpqrSTUvwxYZ01234567!@", true, "invalid characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBotToken(SecretToken(tt.token))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateBotToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+			if !errors.Is(err, ErrInvalidBotToken) {
+				t.Errorf("expected error to wrap ErrInvalidBotToken, got %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("error %q should contain %q", err.Error(), tt.errContains)
+			}
+			if tt.token != "" && strings.Contains(err.Error(), tt.token) {
+				t.Error("error should not contain the token value")
+			}
+		})
+	}
+}
+
+func TestValidateBotTokenField(t *testing.T) {
+	type tokenHolder struct {
+		Token string `validate:"bottoken"`
+	}
+
+	if err := validate.Struct(tokenHolder{Token: testBotToken}); err != nil {
+		t.Errorf("expected valid token to pass struct validation, got %v", err)
+	}
+	if err := validate.Struct(tokenHolder{Token: "123:short"}); err == nil {
+		t.Error("expected invalid token to fail struct validation")
+	}
+}
+
 func TestEnsureLogPath(t *testing.T) {
 	// Create a temporary directory for testing
 	tmpDir := t.TempDir()
@@ -0,0 +1,301 @@
+package telegramreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Hybrid mode defaults (see ModeHybrid)
+const (
+	defaultHybridCheckInterval = 30 * time.Second
+	defaultHybridRetryInterval = 5 * time.Minute
+)
+
+// hybridReceiver runs the webhook as the primary receiver and falls back to
+// polling while getWebhookInfo reports delivery errors.
+//
+// Switching to polling deletes the webhook, keeping pending updates, and
+// starts polling after the highest update ID the webhook received, so
+// updates Telegram retries after a failed delivery are not passed on twice.
+// Switching back stops polling, confirms its offset with getUpdates so the
+// polled updates are not sent to the webhook again, and calls setWebhook.
+// Telegram refuses getUpdates while a webhook is set, so neither receiver
+// runs during the switch.
+type hybridReceiver struct {
+	c      *Client
+	logger *slog.Logger
+	clock  clock
+
+	checkInterval time.Duration
+	retryInterval time.Duration
+
+	mu        sync.Mutex
+	poller    *LongPollingClient // Non-nil while falling back to polling
+	switching bool               // Poller stopped, webhook not registered yet
+	stalled   bool               // Switching back and restarting polling both failed
+
+	// Only used by the monitor goroutine, and by stop after it ended
+	baseline int64 // last_error_date seen while the webhook worked (-1 = take the next)
+	offset   int   // Next update ID to poll, carried across fallbacks
+
+	stopCh   chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// startHybrid sets up the webhook handler and starts monitoring webhook
+// delivery. Like webhook mode, serving the handler is up to the caller.
+func (c *Client) startHybrid(ctx context.Context, cfg ClientConfig) error {
+	_ = c.WebhookHandler()
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	h := &hybridReceiver{
+		c:             c,
+		logger:        logger,
+		clock:         c.clock,
+		checkInterval: cfg.HybridCheckInterval,
+		retryInterval: cfg.HybridRetryInterval,
+		baseline:      -1,
+		stopCh:        make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	if h.checkInterval <= 0 {
+		h.checkInterval = defaultHybridCheckInterval
+	}
+	if h.retryInterval <= 0 {
+		h.retryInterval = defaultHybridRetryInterval
+	}
+	c.mu.Lock()
+	c.hybrid = h
+	c.mu.Unlock()
+
+	go h.run(ctx)
+	return nil
+}
+
+// PollingFallback reports whether hybrid mode is currently polling because
+// Telegram could not reach the webhook, including while it switches back.
+// It is false in other modes.
+func (c *Client) PollingFallback() bool {
+	return c.hybrid != nil && c.hybrid.inFallback()
+}
+
+// activePoller returns the running fallback poller, or nil while the
+// webhook is used or during the switch back.
+func (h *hybridReceiver) activePoller() *LongPollingClient {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.poller
+}
+
+// inFallback reports whether the webhook is not registered because of a
+// fallback: polling, switching back, or stalled.
+func (h *hybridReceiver) inFallback() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.poller != nil || h.switching
+}
+
+// isStalled reports whether neither receiver runs because switching back
+// to the webhook and restarting polling both failed. The next retry
+// interval tries again.
+func (h *hybridReceiver) isStalled() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stalled
+}
+
+// run checks the webhook every check interval and, while polling, tries
+// the webhook again every retry interval, until ctx ends or stop is called.
+func (h *hybridReceiver) run(ctx context.Context) {
+	defer close(h.done)
+
+	wait := h.checkInterval
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.stopCh:
+			return
+		case <-h.clock.After(wait):
+		}
+
+		if h.inFallback() {
+			if err := h.restoreWebhook(ctx); err != nil {
+				h.logger.Error("failed to switch back to webhook, polling again", "error", err)
+				if err := h.startPolling(ctx); err != nil {
+					h.logger.Error("failed to restart fallback polling", "error", err)
+					h.mu.Lock()
+					h.stalled = true
+					h.mu.Unlock()
+				}
+			}
+		} else {
+			h.check(ctx)
+		}
+
+		wait = h.checkInterval
+		if h.inFallback() {
+			wait = h.retryInterval
+		}
+	}
+}
+
+// check falls back to polling when getWebhookInfo reports a delivery error
+// newer than the baseline, and registers the webhook if it is missing.
+func (h *hybridReceiver) check(ctx context.Context) {
+	cfg := h.c.Config()
+	info, err := GetWebhookInfoWithClient(ctx, apiClient(cfg), SecretToken(cfg.BotToken))
+	if err != nil {
+		h.logger.Warn("failed to check webhook status", "error", err)
+		return
+	}
+
+	if info.URL == "" {
+		// Deleted by a previous fallback that did not restore it
+		h.logger.Info("webhook not registered, registering it", "url", cfg.WebhookURL)
+		if err := h.registerWebhook(ctx, cfg); err != nil {
+			h.logger.Error("failed to register webhook", "error", err)
+		}
+		return
+	}
+	if h.baseline < 0 || info.LastErrorDate <= h.baseline {
+		h.baseline = info.LastErrorDate
+		return
+	}
+
+	h.logger.Warn("telegram cannot reach the webhook, falling back to polling",
+		"last_error_date", time.Unix(info.LastErrorDate, 0),
+		"last_error_message", info.LastErrorMessage,
+		"pending_update_count", info.PendingUpdateCount,
+	)
+	if err := DeleteWebhookWithClient(ctx, apiClient(cfg), SecretToken(cfg.BotToken), false); err != nil {
+		h.logger.Error("failed to delete webhook for polling fallback", "error", err)
+		return
+	}
+	if err := h.startPolling(ctx); err != nil {
+		// The next check finds the webhook missing and registers it again
+		h.logger.Error("failed to start fallback polling", "error", err)
+	}
+}
+
+// startPolling starts a poller after the updates already received by the
+// webhook or an earlier poller.
+func (h *hybridReceiver) startPolling(ctx context.Context) error {
+	cfg := h.c.Config()
+	cfg.PollingDeleteWebhook = false // Deleted by check
+
+	poller, err := h.c.newPollingClient(cfg)
+	if err != nil {
+		return err
+	}
+	if last := int(h.c.webhookHandler.lastUpdateID.Load()); last >= h.offset {
+		h.offset = last + 1
+	}
+	poller.offset.Store(int64(h.offset))
+	if err := poller.Start(ctx); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.poller = poller
+	h.switching = false
+	h.stalled = false
+	h.mu.Unlock()
+	h.logger.Info("polling fallback started", "offset", h.offset)
+	return nil
+}
+
+// restoreWebhook stops the fallback poller, confirms its offset and
+// registers the webhook again. The stopped poller is cleared before the
+// API calls, so health and stats do not report it while switching; on
+// error the receiver stays switching until polling is restarted.
+func (h *hybridReceiver) restoreWebhook(ctx context.Context) error {
+	h.mu.Lock()
+	poller := h.poller
+	h.poller = nil
+	h.switching = true
+	h.mu.Unlock()
+	if poller != nil {
+		poller.Stop()
+		h.offset = poller.Offset()
+	}
+
+	cfg := h.c.Config()
+	if h.offset > 0 {
+		// Telegram forgets updates only once getUpdates is called with a
+		// higher offset; without this they would reach the webhook again
+		req := struct {
+			Offset  int `json:"offset"`
+			Limit   int `json:"limit"`
+			Timeout int `json:"timeout"`
+		}{h.offset, 1, 0}
+		if _, err := call[json.RawMessage](ctx, apiClient(cfg), SecretToken(cfg.BotToken), "getUpdates", req); err != nil {
+			return fmt.Errorf("confirming offset %d: %w", h.offset, err)
+		}
+	}
+	if err := h.registerWebhook(ctx, cfg); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.switching = false
+	h.stalled = false
+	h.mu.Unlock()
+	h.logger.Info("switched back to webhook", "offset", h.offset)
+	return nil
+}
+
+// registerWebhook calls setWebhook and takes the next last_error_date seen
+// as the new baseline, so earlier errors do not trigger another fallback.
+func (h *hybridReceiver) registerWebhook(ctx context.Context, cfg ClientConfig) error {
+	if err := setWebhookFor(ctx, cfg, cfg.AllowedUpdates); err != nil {
+		return err
+	}
+	h.baseline = -1
+	return nil
+}
+
+// stop ends monitoring, then stops a running fallback poller and, with
+// HybridRestoreOnStop, registers the webhook again within ShutdownTimeout.
+func (h *hybridReceiver) stop() {
+	h.stopOnce.Do(func() {
+		close(h.stopCh)
+		<-h.done
+
+		if !h.inFallback() {
+			return
+		}
+		cfg := h.c.Config()
+		defer func() {
+			h.mu.Lock()
+			h.poller = nil
+			h.switching = false
+			h.stalled = false
+			h.mu.Unlock()
+		}()
+		if !cfg.HybridRestoreOnStop {
+			if poller := h.activePoller(); poller != nil {
+				poller.Stop()
+			}
+			return
+		}
+
+		ctx := context.Background()
+		if cfg.ShutdownTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.ShutdownTimeout)
+			defer cancel()
+		}
+		if err := h.restoreWebhook(ctx); err != nil {
+			h.logger.Error("failed to restore webhook on stop", "error", err)
+		}
+	})
+}
@@ -0,0 +1,307 @@
+package telegramreceiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeHybridAPI simulates the Bot API calls used by hybrid mode: getUpdates
+// fails with 409 while a webhook is set, and updates below the last
+// getUpdates offset are forgotten.
+type fakeHybridAPI struct {
+	mu            sync.Mutex
+	webhookURL    string
+	lastErrorDate int64
+	pending       []int // Update IDs not yet confirmed
+	offsets       []int // Offsets of getUpdates calls
+	calls         []string
+
+	failSetWebhook bool
+	onSetWebhook   func() // Called with the state locked, before replying
+}
+
+func (f *fakeHybridAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.calls = append(f.calls, method)
+	switch method {
+	case "getWebhookInfo":
+		fmt.Fprintf(w, `{"ok":true,"result":{"url":%q,"last_error_date":%d}}`, f.webhookURL, f.lastErrorDate)
+	case "setWebhook":
+		if f.onSetWebhook != nil {
+			f.onSetWebhook()
+		}
+		if f.failSetWebhook {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"ok":false,"error_code":500,"description":"Internal Server Error"}`))
+			return
+		}
+		var req struct {
+			URL string `json:"url"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		f.webhookURL = req.URL
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	case "deleteWebhook":
+		f.webhookURL = ""
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	case "getUpdates":
+		if f.webhookURL != "" {
+			w.Write([]byte(`{"ok":false,"error_code":409,"description":"Conflict: can't use getUpdates method while webhook is active"}`))
+			return
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if r.Method == http.MethodPost {
+			var req struct {
+				Offset int `json:"offset"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			offset = req.Offset
+		}
+		f.offsets = append(f.offsets, offset)
+
+		var result []TelegramUpdate
+		var pending []int
+		for _, id := range f.pending {
+			if id >= offset {
+				pending = append(pending, id)
+				result = append(result, TelegramUpdate{UpdateID: id})
+			}
+		}
+		f.pending = pending
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "result": result})
+	default:
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}
+}
+
+// set runs fn with the fake's state locked.
+func (f *fakeHybridAPI) set(fn func(f *fakeHybridAPI)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+}
+
+func (f *fakeHybridAPI) getWebhookURL() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.webhookURL
+}
+
+func (f *fakeHybridAPI) lastOffset() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.offsets) == 0 {
+		return -1
+	}
+	return f.offsets[len(f.offsets)-1]
+}
+
+// newTestHybridClient returns a hybrid client whose check and retry
+// intervals (the defaults unless set in opts) pass only when the returned
+// clock is stepped.
+func newTestHybridClient(t *testing.T, api *fakeHybridAPI, opts ...Option) (*Client, *stepClock) {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	opts = append([]Option{
+		WithHybrid(8443, "test-secret", "https://example.com/hook"),
+		WithPolling(0, 100),
+		WithMode(ModeHybrid),
+		WithHTTPClientOption(newTestAPIClient(server)),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	}, opts...)
+	client, err := New(testBotToken, opts...)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	clk := newStepClock()
+	client.clock = clk
+	return client, clk
+}
+
+func TestClient_HybridFallbackAndRecovery(t *testing.T) {
+	api := &fakeHybridAPI{webhookURL: "https://example.com/hook", lastErrorDate: 1000}
+	client, clk := newTestHybridClient(t, api)
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	// Update 5 arrives via the webhook, but Telegram records a failure and
+	// keeps it pending along with update 6
+	body, _ := json.Marshal(TelegramUpdate{UpdateID: 5})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	client.WebhookHandler().ServeHTTP(httptest.NewRecorder(), req)
+	if upd := <-client.Updates(); upd.UpdateID != 5 {
+		t.Fatalf("webhook update ID = %d, want 5", upd.UpdateID)
+	}
+
+	// The stale error seen at start must not trigger a fallback
+	if d := clk.pending(t); d != defaultHybridCheckInterval {
+		t.Errorf("first wait = %v, want the check interval", d)
+	}
+	clk.step(t)
+	if client.PollingFallback() {
+		t.Fatal("fell back to polling on an error older than Start")
+	}
+
+	api.set(func(f *fakeHybridAPI) {
+		f.lastErrorDate = 2000
+		f.pending = []int{5, 6}
+	})
+	if d := clk.step(t); d != defaultHybridRetryInterval {
+		t.Errorf("wait while polling = %v, want the retry interval", d)
+	}
+	if !client.PollingFallback() {
+		t.Fatal("did not fall back to polling")
+	}
+	if url := api.getWebhookURL(); url != "" {
+		t.Errorf("webhook still set during fallback: %q", url)
+	}
+
+	select {
+	case upd := <-client.Updates():
+		if upd.UpdateID != 6 {
+			t.Errorf("polled update ID = %d, want 6 (5 came via the webhook)", upd.UpdateID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update polled during fallback")
+	}
+
+	// After the retry interval the webhook is registered again with the
+	// polled updates confirmed
+	if d := clk.step(t); d != defaultHybridCheckInterval {
+		t.Errorf("wait after recovery = %v, want the check interval", d)
+	}
+	if client.PollingFallback() {
+		t.Fatal("did not switch back to webhook")
+	}
+	if url := api.getWebhookURL(); url != "https://example.com/hook" {
+		t.Errorf("webhook URL after recovery = %q", url)
+	}
+	if got := api.lastOffset(); got != 7 {
+		t.Errorf("confirmed offset = %d, want 7", got)
+	}
+
+	// The error that caused the fallback does not cause another one
+	clk.step(t)
+	clk.step(t)
+	if client.PollingFallback() {
+		t.Error("fell back again without a new webhook error")
+	}
+	select {
+	case upd := <-client.Updates():
+		t.Errorf("unexpected duplicate update %d", upd.UpdateID)
+	default:
+	}
+}
+
+func TestClient_HybridStopDuringFallback(t *testing.T) {
+	tests := []struct {
+		name        string
+		restore     bool
+		wantWebhook string
+	}{
+		{name: "restore webhook", restore: true, wantWebhook: "https://example.com/hook"},
+		{name: "leave webhook deleted", restore: false, wantWebhook: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeHybridAPI{webhookURL: "https://example.com/hook"}
+			client, clk := newTestHybridClient(t, api, WithHybridRestoreOnStop(tt.restore))
+			if err := client.Start(context.Background()); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			clk.step(t) // first webhook check
+
+			api.set(func(f *fakeHybridAPI) { f.lastErrorDate = 1000 })
+			clk.step(t)
+			if !client.PollingFallback() {
+				t.Fatal("did not fall back to polling")
+			}
+
+			client.Stop()
+			if client.PollingFallback() {
+				t.Error("still polling after Stop")
+			}
+			if url := api.getWebhookURL(); url != tt.wantWebhook {
+				t.Errorf("webhook URL after Stop = %q, want %q", url, tt.wantWebhook)
+			}
+		})
+	}
+}
+
+func TestClient_HybridRestoreFails(t *testing.T) {
+	api := &fakeHybridAPI{webhookURL: "https://example.com/hook"}
+	client, clk := newTestHybridClient(t, api)
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	clk.step(t) // first webhook check
+	api.set(func(f *fakeHybridAPI) { f.lastErrorDate = 1000 })
+	clk.step(t)
+	if !client.PollingFallback() {
+		t.Fatal("did not fall back to polling")
+	}
+
+	// The stopped poller must not be reported while switching back
+	var healthy, fallback bool
+	var stats Stats
+	api.set(func(f *fakeHybridAPI) {
+		f.failSetWebhook = true
+		f.onSetWebhook = func() {
+			healthy, fallback, stats = client.IsHealthy(), client.PollingFallback(), client.Stats()
+		}
+	})
+	if d := clk.step(t); d != defaultHybridRetryInterval {
+		t.Errorf("wait after failed restore = %v, want the retry interval", d)
+	}
+	api.set(func(f *fakeHybridAPI) {
+		if !healthy || !fallback {
+			t.Errorf("during setWebhook: IsHealthy = %v, PollingFallback = %v, want both true", healthy, fallback)
+		}
+		if !stats.Running {
+			t.Error("during setWebhook: Stats reports a stopped receiver")
+		}
+	})
+
+	// Polling resumes after the failed restore
+	if !client.PollingFallback() || !client.IsHealthy() || !client.IsLive() {
+		t.Errorf("after failed restore: PollingFallback = %v, IsHealthy = %v, IsLive = %v, want all true",
+			client.PollingFallback(), client.IsHealthy(), client.IsLive())
+	}
+	if url := api.getWebhookURL(); url != "" {
+		t.Errorf("webhook set after failed restore: %q", url)
+	}
+
+	api.set(func(f *fakeHybridAPI) { f.failSetWebhook = false })
+	clk.step(t)
+	if client.PollingFallback() {
+		t.Error("did not switch back to webhook on the next retry")
+	}
+}
+
+func TestNew_HybridRequiresWebhookURL(t *testing.T) {
+	_, err := New(testBotToken, WithMode(ModeHybrid))
+	if err == nil || !strings.Contains(err.Error(), "webhook_url") {
+		t.Errorf("expected webhook_url error, got %v", err)
+	}
+}
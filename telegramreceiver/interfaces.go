@@ -40,3 +40,11 @@ var _ WebhookProcessor = (*WebhookHandler)(nil)
 type UpdateHandler interface {
 	HandleUpdate(ctx context.Context, update TelegramUpdate) error
 }
+
+// UpdateHandlerFunc adapts an ordinary function to the UpdateHandler interface.
+type UpdateHandlerFunc func(ctx context.Context, update TelegramUpdate) error
+
+// HandleUpdate calls f(ctx, update).
+func (f UpdateHandlerFunc) HandleUpdate(ctx context.Context, update TelegramUpdate) error {
+	return f(ctx, update)
+}
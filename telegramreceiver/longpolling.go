@@ -1,16 +1,21 @@
 package telegramreceiver
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
 	"math"
 	"math/big"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"runtime/debug"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,9 +34,55 @@ type LongPollingClient struct {
 	// Polling configuration
 	timeout              int
 	limit                int
-	maxErrors            int      // Max consecutive errors before stopping (0 = unlimited)
-	allowedUpdates       []string // Optional: filter update types
-	deleteWebhookOnStart bool     // Delete existing webhook before starting
+	maxErrors            int           // Max consecutive errors before stopping (0 = unlimited)
+	unhealthyThreshold   int           // Consecutive errors at which IsHealthy turns false (0 = maxErrors)
+	autoRestart          time.Duration // Probe interval while paused after maxErrors (0 = stop instead)
+	allowedUpdates       []string      // Optional: filter update types (guarded by settingsMu)
+	settingsMu           sync.RWMutex
+	deleteWebhookOnStart bool          // Delete existing webhook before starting
+	startupTimeout       time.Duration // Bounds network calls made by Start (0 = caller's context only)
+
+	// Adaptive long-poll timeout (see WithAdaptivePolling)
+	adaptive       bool
+	adaptiveMin    int
+	adaptiveMax    int
+	currentTimeout atomic.Int32 // Timeout of the next getUpdates call
+
+	// Minimum spacing between getUpdates calls (see WithMinPollInterval)
+	minPollInterval time.Duration
+
+	// Dropped update logging
+	dropLogInterval time.Duration // Summarize drops per interval (0 = log each drop)
+	drops           *dropLogger
+
+	// Time source for waits and timestamps (fake in tests)
+	clock clock
+
+	// Callback replacing channel delivery (see WithPollOnReceive)
+	onReceive      func(context.Context, TelegramUpdate)
+	handlerTimeout time.Duration // Per-update callback deadline (0 = none)
+
+	// Update ID sequence checking (see WithStrictOrdering)
+	strictOrdering bool
+	lastUpdateID   int // Highest update ID seen, only used by pollLoop
+
+	// Warn about unmodeled update fields (see WithPollStrictDecoding)
+	strictDecoding bool
+
+	// Decoders filling TelegramUpdate.Extra (see WithPollCustomUpdateDecoder)
+	customDecoders map[string]UpdateDecoder
+
+	// Receipt entries with update checksums (see WithPollAuditLog)
+	auditLogger *slog.Logger
+
+	// Group migration hook (see WithPollOnMigration)
+	onMigration func(MigrationEvent)
+
+	// Offset hook called after each batch (see WithPollOffsetPublisher)
+	publishOffset func(offset int)
+
+	// Instance name for breaker and log attributes (see WithPollName)
+	name string
 
 	// Retry configuration with exponential backoff
 	retryInitialDelay  time.Duration // Initial delay before first retry
@@ -39,15 +90,31 @@ type LongPollingClient struct {
 	retryBackoffFactor float64       // Multiplier for each retry (e.g., 2.0 for doubling)
 
 	// HTTP client
-	client httpClient
+	client           httpClient
+	customHTTPClient bool                 // Set by WithHTTPClient
+	debugTap         DebugTap             // Optional raw traffic observer
+	httpTimeout      time.Duration        // Overall HTTP timeout for the default client (0 = timeout+10s)
+	transport        *pollTransportConfig // Connection pool overrides for the default client
+	proxy            *url.URL             // Proxy for the default client (nil = direct)
 
 	// Circuit breaker for resilience
-	breaker *gobreaker.CircuitBreaker[[]byte]
+	breaker              *gobreaker.CircuitBreaker[[]byte]
+	breakerReadyToTrip   func(counts gobreaker.Counts) bool
+	breakerOnStateChange func(name string, from, to gobreaker.State)
 
 	// State management
 	running           atomic.Bool
-	offset            int
-	consecutiveErrors atomic.Int32 // Exposed for health checks
+	offset            atomic.Int64
+	counters          receiverCounters
+	connsReused       atomic.Uint64 // getUpdates calls on a pooled connection
+	connsNew          atomic.Uint64 // getUpdates calls that opened a connection
+	consecutiveErrors atomic.Int32  // Exposed for health checks
+	backoffAttempt    int32         // Attempt used for the retry delay, only used by pollLoop
+	backoffReset      BackoffResetMode
+	paused            atomic.Bool // Waiting for connectivity (auto restart)
+	suspendMu         sync.Mutex
+	resumeCh          chan struct{}         // Non-nil while suspended via Pause; closed by Resume
+	stopErr           atomic.Pointer[error] // Why the loop stopped on its own (see Err)
 	stopCh            chan struct{}
 	closeOnce         sync.Once // Prevents double-close panic
 	wg                sync.WaitGroup
@@ -55,6 +122,36 @@ type LongPollingClient struct {
 
 const defaultMaxConsecutiveErrors = 10
 
+// maxGetUpdatesURLLength is the longest getUpdates URL sent as a GET;
+// longer requests use POST (see newGetUpdatesRequest).
+const maxGetUpdatesURLLength = 2048
+
+// PollingState describes what the polling client is currently doing.
+type PollingState string
+
+// Polling states reported by LongPollingClient.State.
+const (
+	PollingStateStopped   PollingState = "stopped"
+	PollingStateRunning   PollingState = "running"
+	PollingStatePaused    PollingState = "paused"    // Max errors reached, probing until Telegram is reachable
+	PollingStateSuspended PollingState = "suspended" // Paused on request via Pause until Resume
+)
+
+// BackoffResetMode controls how a successful getUpdates call resets the
+// retry backoff.
+type BackoffResetMode string
+
+// Backoff reset modes for WithPollBackoffResetMode.
+const (
+	// BackoffResetImmediate restarts the backoff at the initial delay
+	// after a success (default).
+	BackoffResetImmediate BackoffResetMode = "immediate"
+	// BackoffResetDecay halves the backoff attempt count after a success,
+	// so connectivity that flaps between successes and failures keeps
+	// most of the accumulated delay.
+	BackoffResetDecay BackoffResetMode = "decay"
+)
+
 // Default retry configuration for exponential backoff
 const (
 	defaultRetryInitialDelay  = 1 * time.Second
@@ -66,9 +163,72 @@ const (
 type LongPollingOption func(*LongPollingClient)
 
 // WithHTTPClient sets a custom HTTP client for the polling client.
+// The client's Timeout must exceed the long-poll timeout by a few seconds,
+// otherwise every poll fails; a warning is logged when it does not.
 func WithHTTPClient(client *http.Client) LongPollingOption {
 	return func(c *LongPollingClient) {
 		c.client = client
+		c.customHTTPClient = true
+	}
+}
+
+// WithPollDebugTap passes the raw body of every getUpdates request and
+// response (and of deleteWebhook on start) to tap. See DebugTap.
+func WithPollDebugTap(tap DebugTap) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.debugTap = tap
+	}
+}
+
+// WithPollHTTPTimeout sets the overall HTTP timeout of the default polling
+// client, which otherwise is the long-poll timeout plus 10s.
+// Ignored when WithHTTPClient is used.
+func WithPollHTTPTimeout(d time.Duration) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.httpTimeout = d
+	}
+}
+
+// pollTransportConfig holds connection pool settings of the default client.
+type pollTransportConfig struct {
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+}
+
+// defaultPollTransportConfig is suited to a single bot per process.
+var defaultPollTransportConfig = pollTransportConfig{
+	maxIdleConns:        10,
+	maxIdleConnsPerHost: 10,
+	idleConnTimeout:     90 * time.Second,
+}
+
+// WithTransportConfig tunes the connection pool of the default polling
+// client (defaults: 10, 10, 90s). Zero values keep the default.
+// Ignored when WithHTTPClient is used; to share one *http.Transport across
+// clients, pass clients built on it with WithHTTPClient instead.
+func WithTransportConfig(maxIdleConns, maxIdleConnsPerHost int, idleConnTimeout time.Duration) LongPollingOption {
+	return func(c *LongPollingClient) {
+		t := defaultPollTransportConfig
+		if maxIdleConns > 0 {
+			t.maxIdleConns = maxIdleConns
+		}
+		if maxIdleConnsPerHost > 0 {
+			t.maxIdleConnsPerHost = maxIdleConnsPerHost
+		}
+		if idleConnTimeout > 0 {
+			t.idleConnTimeout = idleConnTimeout
+		}
+		c.transport = &t
+	}
+}
+
+// WithPollProxy routes the default polling client through a proxy (http,
+// https, socks5 or socks5h), keeping its long-poll timeouts. Ignored when
+// WithHTTPClient is used.
+func WithPollProxy(proxy *url.URL) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.proxy = proxy
 	}
 }
 
@@ -79,6 +239,24 @@ func WithCircuitBreaker(breaker *gobreaker.CircuitBreaker[[]byte]) LongPollingOp
 	}
 }
 
+// WithReadyToTrip overrides the predicate deciding when the default circuit
+// breaker opens. The default trips after at least 3 requests with a failure
+// ratio of 60% or more. Ignored when WithCircuitBreaker is used.
+func WithReadyToTrip(readyToTrip func(counts gobreaker.Counts) bool) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.breakerReadyToTrip = readyToTrip
+	}
+}
+
+// WithBreakerOnStateChange registers a callback invoked when the default circuit
+// breaker changes state, in addition to the built-in state change log line.
+// Ignored when WithCircuitBreaker is used.
+func WithBreakerOnStateChange(onStateChange func(name string, from, to gobreaker.State)) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.breakerOnStateChange = onStateChange
+	}
+}
+
 // WithMaxErrors sets the maximum consecutive errors before stopping.
 // Set to 0 for unlimited retries.
 func WithMaxErrors(max int) LongPollingOption {
@@ -87,6 +265,173 @@ func WithMaxErrors(max int) LongPollingOption {
 	}
 }
 
+// WithPollOnReceive calls fn for each update instead of sending it to the
+// updates channel, which may then be nil. fn runs synchronously on the
+// polling goroutine in update order: the next getUpdates call waits until
+// fn returned for the whole batch, so a slow callback slows polling rather
+// than dropping updates. A panic in fn is recovered, logged and counted in
+// Stats.HandlerPanics, and the update counts as delivered.
+func WithPollOnReceive(fn func(ctx context.Context, update TelegramUpdate)) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.onReceive = fn
+	}
+}
+
+// WithPollHandlerTimeout runs each WithPollOnReceive call under a context
+// with deadline d. A callback still running at the deadline is logged,
+// counted in Stats.HandlerTimeouts and abandoned, and polling moves on to
+// the next update; the callback keeps running in the background until it
+// returns, since Go cannot stop a goroutine that ignores its context.
+// 0 disables the limit.
+func WithPollHandlerTimeout(d time.Duration) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.handlerTimeout = d
+	}
+}
+
+// WithUnhealthyThreshold makes IsHealthy report false once n consecutive
+// errors occurred while polling keeps retrying up to the max errors. Use
+// IsHealthy for readiness and IsLive for liveness so an orchestrator stops
+// routing to a degraded instance without restarting it. A threshold of 0,
+// or one not below the max errors, keeps the default of the max errors.
+func WithUnhealthyThreshold(n int) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.unhealthyThreshold = n
+	}
+}
+
+// WithMinPollInterval spaces getUpdates calls at least d apart, waiting
+// out the remainder when a call returns sooner. It keeps a short or zero
+// polling timeout, or a steady stream of updates, from polling in a tight
+// loop. Retry backoff waits count towards the interval.
+func WithMinPollInterval(d time.Duration) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.minPollInterval = d
+	}
+}
+
+// WithPollBackoffResetMode sets how a successful getUpdates call resets
+// the retry backoff. It only affects retry delays: the consecutive error
+// count used by IsHealthy and the max errors still resets to 0 on success.
+func WithPollBackoffResetMode(mode BackoffResetMode) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.backoffReset = mode
+	}
+}
+
+// WithAutoRestart keeps the client alive once the max consecutive errors are
+// exceeded: instead of stopping, it pauses polling and calls getMe every
+// interval until Telegram is reachable again, then resumes. While paused,
+// State reports PollingStatePaused and IsHealthy returns false.
+func WithAutoRestart(interval time.Duration) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.autoRestart = interval
+	}
+}
+
+// withAPIClient is WithHTTPClient for any HTTPClient, such as one from
+// NewDebugTapClient. Client passes ClientConfig.HTTPClient through it.
+func withAPIClient(client HTTPClient) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.client = client
+		c.customHTTPClient = true
+	}
+}
+
+// withClock replaces the time source, for deterministic tests.
+func withClock(clk clock) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.clock = clk
+	}
+}
+
+// WithPollOnMigration calls fn when a group is upgraded to a supergroup,
+// so state keyed by the old chat ID can be moved. Telegram announces a
+// migration in both chats, so fn may run twice for the same event and
+// should be idempotent. Migrations are logged either way.
+func WithPollOnMigration(fn func(MigrationEvent)) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.onMigration = fn
+	}
+}
+
+// WithPollOffsetPublisher calls fn with the new offset after each batch of
+// updates was delivered, so a hot standby can take over from the active
+// instance without replaying or skipping updates. fn runs on the polling
+// goroutine and should hand slow work, such as writing to a shared
+// store, off to another goroutine. It is not called for empty batches.
+//
+// A batch being delivered when Stop is called is finished and published
+// before Stop returns, so the last offset fn received is the one to
+// resume from; any write fn handed off must be flushed by the caller.
+func WithPollOffsetPublisher(fn func(offset int)) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.publishOffset = fn
+	}
+}
+
+// WithPollStrictDecoding logs a warning listing the fields of each update
+// that this package does not model, for example after a Bot API change.
+// The update is still delivered. Meant for debugging: every update is
+// decoded a second time.
+func WithPollStrictDecoding() LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.strictDecoding = true
+	}
+}
+
+// WithPollCustomUpdateDecoder decodes the top-level update field with
+// decode and stores the result in TelegramUpdate.Extra under the field
+// name. See WithWebhookCustomUpdateDecoder.
+func WithPollCustomUpdateDecoder(field string, decode UpdateDecoder) LongPollingOption {
+	return func(c *LongPollingClient) {
+		addUpdateDecoder(&c.customDecoders, field, decode)
+	}
+}
+
+// WithPollAuditLog writes an entry to logger for each polled update with
+// its update_id, received_at and the SHA-256 of its JSON as returned by
+// getUpdates, to prove receipt without retaining the content. The update
+// itself is never logged.
+func WithPollAuditLog(logger *slog.Logger) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.auditLogger = logger
+	}
+}
+
+// WithStrictOrdering logs a warning when getUpdates returns an update ID
+// that is not greater than the previous one (out of order or duplicated)
+// or skips IDs. Telegram delivers polled updates in order, so either
+// usually points to a bug or a second consumer. Gaps are also expected
+// after a long idle period or when allowed_updates filters update types.
+func WithStrictOrdering() LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.strictOrdering = true
+	}
+}
+
+// WithAdaptivePolling varies the getUpdates timeout between min and max
+// seconds: it halves after a poll returned updates, so bursts are drained
+// with short polls, and doubles after an empty poll up to max while idle.
+// Bounds are clamped to Telegram's 0-60 range and the timeout passed to
+// NewLongPollingClient is replaced by max.
+func WithAdaptivePolling(minSeconds, maxSeconds int) LongPollingOption {
+	return func(c *LongPollingClient) {
+		lo, hi := clampPollTimeout(minSeconds), clampPollTimeout(maxSeconds)
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		c.adaptive = true
+		c.adaptiveMin = lo
+		c.adaptiveMax = hi
+	}
+}
+
+// clampPollTimeout limits a getUpdates timeout to Telegram's 0-60 seconds.
+func clampPollTimeout(seconds int) int {
+	return min(max(seconds, 0), 60)
+}
+
 // WithAllowedUpdates sets the update types to receive.
 // See https://core.telegram.org/bots/api#update
 func WithAllowedUpdates(types []string) LongPollingOption {
@@ -103,6 +448,34 @@ func WithDeleteWebhook(delete bool) LongPollingOption {
 	}
 }
 
+// WithPollDropLogInterval sets how often updates dropped because the updates
+// channel is full are summarized in a single log line (default: 10s). The
+// first drop after a quiet period is always logged. Use 0 to log every drop.
+func WithPollDropLogInterval(d time.Duration) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.dropLogInterval = d
+	}
+}
+
+// WithPollName identifies the client when several run in one process: log
+// lines get a bot=name attribute and the default circuit breaker is named
+// "name/telegram-polling".
+func WithPollName(name string) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.name = name
+		c.logger = c.logger.With("bot", name)
+	}
+}
+
+// WithPollStartupTimeout bounds the network calls made by Start, such as
+// deleteWebhook, independently of the context passed to Start. When it
+// expires Start fails with an error wrapping ErrStartupTimeout.
+func WithPollStartupTimeout(d time.Duration) LongPollingOption {
+	return func(c *LongPollingClient) {
+		c.startupTimeout = d
+	}
+}
+
 // WithRetryConfig sets exponential backoff parameters for retry logic.
 // initialDelay: delay before first retry (default: 1s)
 // maxDelay: maximum delay cap (default: 60s)
@@ -122,7 +495,9 @@ func WithRetryConfig(initialDelay, maxDelay time.Duration, backoffFactor float64
 }
 
 // NewLongPollingClient creates a new long polling client.
-// The updates channel must be provided (dependency injection pattern).
+// The updates channel must be provided (dependency injection pattern);
+// a nil channel panics unless WithPollOnReceive is given, as every update
+// would otherwise be dropped.
 //
 // Deprecated: Use New() or NewFromConfig() instead for a simpler API.
 // This function will be removed in v4.
@@ -152,56 +527,169 @@ func NewLongPollingClient(
 		retryInitialDelay:  defaultRetryInitialDelay,
 		retryMaxDelay:      defaultRetryMaxDelay,
 		retryBackoffFactor: defaultRetryBackoffFactor,
-		client:             defaultPollingHTTPClient(timeout),
+		dropLogInterval:    defaultDropLogInterval,
+		clock:              realClock{},
 		stopCh:             make(chan struct{}),
 	}
 
-	// Create default circuit breaker
-	client.breaker = gobreaker.NewCircuitBreaker[[]byte](gobreaker.Settings{
-		Name:        "telegram-polling",
-		MaxRequests: breakerMaxRequests,
-		Interval:    breakerInterval,
-		Timeout:     breakerTimeout,
-		ReadyToTrip: func(counts gobreaker.Counts) bool {
+	// Apply options
+	for _, opt := range opts {
+		opt(client)
+	}
+	if updates == nil && client.onReceive == nil {
+		panic("telegramreceiver: NewLongPollingClient: updates channel is nil and no WithPollOnReceive was given")
+	}
+
+	// HTTP timeouts must cover the longest adaptive poll
+	if client.adaptive {
+		timeout = client.adaptiveMax
+		client.timeout = timeout
+	}
+	client.currentTimeout.Store(int32(timeout))
+
+	// Build the default HTTP client unless a custom one was provided
+	if client.client == nil {
+		client.client = defaultPollingHTTPClient(timeout)
+	}
+	if !client.customHTTPClient && (client.httpTimeout > 0 || client.transport != nil || client.proxy != nil) {
+		httpTimeout := client.httpTimeout
+		if httpTimeout == 0 {
+			httpTimeout = defaultPollHTTPTimeout(timeout)
+		}
+		transport := defaultPollTransportConfig
+		if client.transport != nil {
+			transport = *client.transport
+		}
+		client.client = newPollingHTTPClient(timeout, httpTimeout, transport, client.proxy)
+	}
+	client.warnShortHTTPTimeout()
+	client.warnConnChurn()
+	if client.debugTap != nil {
+		client.client = &tapClient{next: client.client, tap: client.debugTap}
+	}
+	client.drops = newDropLogger(client.logger, "updates channel full, dropping update", client.dropLogInterval)
+
+	// Create default circuit breaker unless a custom one was provided
+	if client.breaker == nil {
+		client.breaker = client.newDefaultBreaker(breakerMaxRequests, breakerInterval, breakerTimeout)
+	}
+
+	return client
+}
+
+// newDefaultBreaker creates the polling circuit breaker, honoring the
+// WithReadyToTrip and WithBreakerOnStateChange overrides.
+func (c *LongPollingClient) newDefaultBreaker(maxRequests uint32, interval, timeout time.Duration) *gobreaker.CircuitBreaker[[]byte] {
+	readyToTrip := c.breakerReadyToTrip
+	if readyToTrip == nil {
+		readyToTrip = func(counts gobreaker.Counts) bool {
 			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
 			return counts.Requests >= 3 && failureRatio >= 0.6
-		},
+		}
+	}
+
+	return gobreaker.NewCircuitBreaker[[]byte](gobreaker.Settings{
+		Name:        breakerName(c.name, "telegram-polling"),
+		MaxRequests: maxRequests,
+		Interval:    interval,
+		Timeout:     timeout,
+		ReadyToTrip: readyToTrip,
 		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
-			logger.Info("circuit breaker state changed",
+			c.logger.Info("circuit breaker state changed",
 				"name", name,
 				"from", from.String(),
 				"to", to.String(),
 			)
+			if c.breakerOnStateChange != nil {
+				c.breakerOnStateChange(name, from, to)
+			}
 		},
 	})
+}
 
-	// Apply options
-	for _, opt := range opts {
-		opt(client)
+// breakerName prefixes a circuit breaker name with the instance name, if any.
+func breakerName(instance, breaker string) string {
+	if instance == "" {
+		return breaker
 	}
+	return instance + "/" + breaker
+}
 
-	return client
+// minPollHTTPTimeout returns the smallest safe HTTP timeout for a long poll:
+// the poll timeout plus headroom for network overhead.
+func minPollHTTPTimeout(timeoutSeconds int) time.Duration {
+	return time.Duration(timeoutSeconds+5) * time.Second
+}
+
+// warnShortHTTPTimeout logs a warning when the HTTP client would time out
+// before Telegram answers a long poll.
+func (c *LongPollingClient) warnShortHTTPTimeout() {
+	hc, ok := c.client.(*http.Client)
+	if !ok || hc.Timeout == 0 {
+		return
+	}
+	if minTimeout := minPollHTTPTimeout(c.timeout); hc.Timeout < minTimeout {
+		c.logger.Warn("HTTP client timeout is shorter than the long-poll timeout, polls will fail",
+			"http_timeout", hc.Timeout,
+			"polling_timeout", time.Duration(c.timeout)*time.Second,
+			"recommended_min", minTimeout,
+		)
+	}
+}
+
+// warnConnChurn warns when the default transport cannot keep the
+// connection between polls, so every getUpdates opens a new one.
+func (c *LongPollingClient) warnConnChurn() {
+	hc, ok := c.client.(*http.Client)
+	if !ok {
+		return
+	}
+	t, ok := hc.Transport.(*http.Transport)
+	if !ok {
+		return
+	}
+	switch {
+	case t.DisableKeepAlives:
+		c.logger.Warn("HTTP keep-alives are disabled, every getUpdates opens a new connection")
+	case t.IdleConnTimeout > 0 && c.minPollInterval >= t.IdleConnTimeout:
+		c.logger.Warn("minimum poll interval reaches the idle connection timeout, every getUpdates opens a new connection",
+			"min_poll_interval", c.minPollInterval,
+			"idle_conn_timeout", t.IdleConnTimeout,
+		)
+	}
 }
 
 // defaultPollingHTTPClient creates an HTTP client optimized for long polling.
 func defaultPollingHTTPClient(timeoutSeconds int) *http.Client {
-	// Add extra time for network overhead beyond the Telegram timeout
-	httpTimeout := time.Duration(timeoutSeconds+10) * time.Second
+	return newPollingHTTPClient(timeoutSeconds, defaultPollHTTPTimeout(timeoutSeconds), defaultPollTransportConfig, nil)
+}
+
+// defaultPollHTTPTimeout adds extra time for network overhead beyond the Telegram timeout.
+func defaultPollHTTPTimeout(timeoutSeconds int) time.Duration {
+	return time.Duration(timeoutSeconds+10) * time.Second
+}
 
+// newPollingHTTPClient creates a long polling HTTP client with the given
+// overall timeout and connection pool settings, routed through proxy when non-nil.
+func newPollingHTTPClient(timeoutSeconds int, httpTimeout time.Duration, pool pollTransportConfig, proxy *url.URL) *http.Client {
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		MaxIdleConns:          pool.maxIdleConns,
+		MaxIdleConnsPerHost:   pool.maxIdleConnsPerHost,
+		IdleConnTimeout:       pool.idleConnTimeout,
+		ResponseHeaderTimeout: time.Duration(timeoutSeconds+5) * time.Second,
+		ForceAttemptHTTP2:     true,
+	}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
 	return &http.Client{
-		Timeout: httpTimeout,
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   10 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-			TLSHandshakeTimeout:   10 * time.Second,
-			MaxIdleConns:          10,
-			MaxIdleConnsPerHost:   10,
-			IdleConnTimeout:       90 * time.Second,
-			ResponseHeaderTimeout: time.Duration(timeoutSeconds+5) * time.Second,
-			ForceAttemptHTTP2:     true,
-		},
+		Timeout:   httpTimeout,
+		Transport: transport,
 	}
 }
 
@@ -237,11 +725,12 @@ func (c *LongPollingClient) Start(ctx context.Context) error {
 	if !c.running.CompareAndSwap(false, true) {
 		return ErrPollingAlreadyRunning
 	}
+	c.stopErr.Store(nil)
 
 	// Only delete webhook if explicitly configured
 	if c.deleteWebhookOnStart {
 		c.logger.Info("deleting existing webhook before starting long polling")
-		if err := DeleteWebhookWithClient(ctx, c.client, c.botToken, false); err != nil {
+		if err := c.deleteWebhookOnStartup(ctx); err != nil {
 			c.running.Store(false)
 			return fmt.Errorf("failed to delete webhook: %w", err)
 		}
@@ -259,6 +748,21 @@ func (c *LongPollingClient) Start(ctx context.Context) error {
 	return nil
 }
 
+// deleteWebhookOnStartup calls deleteWebhook within the startup timeout.
+func (c *LongPollingClient) deleteWebhookOnStartup(ctx context.Context) error {
+	if c.startupTimeout <= 0 {
+		return DeleteWebhookWithClient(ctx, c.client, c.botToken, false)
+	}
+
+	startCtx, cancel := context.WithTimeout(ctx, c.startupTimeout)
+	defer cancel()
+	err := DeleteWebhookWithClient(startCtx, c.client, c.botToken, false)
+	if err != nil && ctx.Err() == nil && errors.Is(startCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %v: %w", ErrStartupTimeout, c.startupTimeout, err)
+	}
+	return err
+}
+
 // Stop gracefully stops the polling client.
 // It blocks until the polling goroutine has finished.
 // Safe to call multiple times.
@@ -280,6 +784,7 @@ func (c *LongPollingClient) pollLoop(ctx context.Context) {
 	defer c.wg.Done()
 	defer c.running.Store(false)
 
+	var lastPoll time.Time // Start of the previous getUpdates call (see WithMinPollInterval)
 	for {
 		select {
 		case <-ctx.Done():
@@ -291,21 +796,74 @@ func (c *LongPollingClient) pollLoop(ctx context.Context) {
 		default:
 		}
 
+		if !c.waitWhileSuspended(ctx) {
+			return
+		}
+
+		if c.minPollInterval > 0 {
+			if wait := c.minPollInterval - c.clock.Now().Sub(lastPoll); !lastPoll.IsZero() && wait > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-c.stopCh:
+					return
+				case <-c.clock.After(wait):
+				}
+			}
+			lastPoll = c.clock.Now()
+		}
+
 		updates, err := c.fetchUpdates(ctx)
 		if err != nil {
 			errCount := c.consecutiveErrors.Add(1)
-			backoff := c.calculateBackoff(errCount)
-			c.logger.Error("failed to fetch updates",
-				"error", err,
-				"consecutive_errors", errCount,
-				"retry_delay", backoff,
-			)
+			c.backoffAttempt++
+			if errors.Is(err, ErrUnauthorizedToken) {
+				// Retrying cannot succeed with a revoked token
+				c.logger.Error("bot token rejected by Telegram, stopping polling; replace the token and restart",
+					"error", err,
+				)
+				c.setStopErr(err)
+				return
+			}
+			backoff := c.calculateBackoff(c.backoffAttempt)
+			switch {
+			case errors.Is(err, ErrPollingConflict):
+				// Retrying quickly cannot succeed while the other poller or
+				// webhook exists; wait the maximum delay instead
+				backoff = c.retryMaxDelay
+				c.logger.Error("polling conflict: only one getUpdates consumer per bot is allowed, check for duplicate instances or an active webhook",
+					"error", err,
+					"consecutive_errors", errCount,
+					"retry_delay", backoff,
+				)
+			case errors.Is(err, ErrTruncatedResponse):
+				// Nothing from the batch was delivered and the offset is
+				// unchanged, so the retry fetches the same updates again
+				c.logger.Warn("getUpdates response truncated, retrying batch",
+					"error", err,
+					"consecutive_errors", errCount,
+					"retry_delay", backoff,
+				)
+			default:
+				c.logger.Error("failed to fetch updates",
+					"error", err,
+					"consecutive_errors", errCount,
+					"retry_delay", backoff,
+				)
+			}
 
 			// Check max errors (0 = unlimited)
 			if c.maxErrors > 0 && int(errCount) >= c.maxErrors {
+				if c.autoRestart > 0 {
+					if !c.pauseUntilReachable(ctx) {
+						return
+					}
+					continue
+				}
 				c.logger.Error("max consecutive errors exceeded, stopping polling",
 					"max_errors", c.maxErrors,
 				)
+				c.setStopErr(fmt.Errorf("%w: %w", ErrMaxRetriesExceeded, err))
 				return
 			}
 
@@ -314,17 +872,34 @@ func (c *LongPollingClient) pollLoop(ctx context.Context) {
 				return
 			case <-c.stopCh:
 				return
-			case <-time.After(backoff):
+			case <-c.clock.After(backoff):
 				continue
 			}
 		}
 
 		c.consecutiveErrors.Store(0)
+		if c.backoffReset == BackoffResetDecay {
+			c.backoffAttempt /= 2
+		} else {
+			c.backoffAttempt = 0
+		}
+		c.adaptTimeout(len(updates))
 
 		for _, update := range updates {
+			if c.strictOrdering {
+				c.checkOrdering(update.UpdateID)
+			}
+
 			// Update offset to acknowledge this update
-			if update.UpdateID >= c.offset {
-				c.offset = update.UpdateID + 1
+			if int64(update.UpdateID) >= c.offset.Load() {
+				c.offset.Store(int64(update.UpdateID) + 1)
+			}
+			c.counters.recordReceived(update.ReceivedAt)
+			observeMigration(c.logger, update, c.onMigration)
+
+			if c.onReceive != nil {
+				c.receive(ctx, update)
+				continue
 			}
 
 			select {
@@ -333,87 +908,316 @@ func (c *LongPollingClient) pollLoop(ctx context.Context) {
 					"update_id", update.UpdateID,
 				)
 			default:
-				c.logger.Warn("updates channel full, dropping update",
-					"update_id", update.UpdateID,
-				)
+				c.counters.recordDropped()
+				c.drops.drop(update.UpdateID)
 			}
 		}
+		if c.publishOffset != nil && len(updates) > 0 {
+			c.publishOffset(c.Offset())
+		}
+	}
+}
+
+// decodeUpdates performs the getUpdates request and stamps ReceivedAt. In
+// strict decoding mode, with custom decoders and with an audit log the
+// updates are decoded individually so their raw JSON can be inspected.
+func (c *LongPollingClient) decodeUpdates(req *http.Request) ([]TelegramUpdate, error) {
+	if !c.strictDecoding && len(c.customDecoders) == 0 && c.auditLogger == nil {
+		updates, err := doAPIRequest[[]TelegramUpdate](c.client, req)
+		receivedAt := c.clock.Now()
+		for i := range updates {
+			updates[i].ReceivedAt = receivedAt
+		}
+		return updates, err
+	}
+
+	raws, err := doAPIRequest[[]json.RawMessage](c.client, req)
+	if err != nil {
+		return nil, err
+	}
+	receivedAt := c.clock.Now()
+	updates := make([]TelegramUpdate, len(raws))
+	for i, raw := range raws {
+		upd, err := unmarshalUpdate(c.logger, raw, c.customDecoders, c.strictDecoding)
+		if err != nil {
+			return nil, &TelegramAPIError{Description: "failed to decode update", Err: err}
+		}
+		upd.ReceivedAt = receivedAt
+		auditUpdate(c.auditLogger, "polling", upd, raw)
+		updates[i] = upd
 	}
+	return updates, nil
 }
 
-// getUpdatesResponse is the response from Telegram's getUpdates API.
-type getUpdatesResponse struct {
-	OK          bool             `json:"ok"`
-	Result      []TelegramUpdate `json:"result,omitempty"`
-	ErrorCode   int              `json:"error_code,omitempty"`
-	Description string           `json:"description,omitempty"`
+// receive calls the WithPollOnReceive callback, applying the handler
+// timeout.
+func (c *LongPollingClient) receive(ctx context.Context, update TelegramUpdate) {
+	if c.handlerTimeout <= 0 {
+		c.callReceive(ctx, update)
+		return
+	}
+	err := callWithTimeout(ctx, c.handlerTimeout, func(ctx context.Context) error {
+		c.callReceive(ctx, update)
+		return nil
+	})
+	if errors.Is(err, ErrHandlerTimeout) {
+		c.counters.timeouts.Add(1)
+		c.logger.Warn("receive callback timed out, skipping update",
+			"update_id", update.UpdateID,
+			"timeout", c.handlerTimeout,
+		)
+	}
 }
 
-// fetchUpdates calls the Telegram getUpdates API.
-func (c *LongPollingClient) fetchUpdates(ctx context.Context) ([]TelegramUpdate, error) {
-	url := fmt.Sprintf("%s%s/getUpdates?timeout=%d&limit=%d&offset=%d",
-		telegramAPIBaseURL,
-		c.botToken.Value(),
-		c.timeout,
-		c.limit,
-		c.offset,
+// callReceive calls the WithPollOnReceive callback, recovering a panic so
+// one bad update cannot stop polling.
+func (c *LongPollingClient) callReceive(ctx context.Context, update TelegramUpdate) {
+	defer func() {
+		if p := recover(); p != nil {
+			c.counters.panics.Add(1)
+			c.logger.Error("receive callback panicked",
+				"update_id", update.UpdateID,
+				"panic", p,
+				"stack", string(debug.Stack()),
+			)
+		}
+	}()
+	c.onReceive(ctx, update)
+}
+
+// pauseUntilReachable probes getMe every autoRestart interval until it
+// succeeds. It returns false if the client was stopped while paused.
+func (c *LongPollingClient) pauseUntilReachable(ctx context.Context) bool {
+	c.paused.Store(true)
+	defer c.paused.Store(false)
+
+	c.logger.Error("max consecutive errors exceeded, pausing polling until Telegram is reachable",
+		"max_errors", c.maxErrors,
+		"probe_interval", c.autoRestart,
 	)
 
-	// Add allowed_updates if configured
-	if len(c.allowedUpdates) > 0 {
-		encoded, err := json.Marshal(c.allowedUpdates)
-		if err == nil {
-			url += "&allowed_updates=" + string(encoded)
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-c.stopCh:
+			return false
+		case <-c.clock.After(c.autoRestart):
 		}
+
+		if _, err := GetMeWithClient(ctx, c.client, c.botToken); err != nil {
+			c.logger.Warn("telegram still unreachable, polling remains paused", "error", err)
+			continue
+		}
+
+		c.consecutiveErrors.Store(0)
+		c.backoffAttempt = 0
+		c.logger.Info("telegram reachable again, resuming polling")
+		return true
+	}
+}
+
+// Pause stops calling getUpdates until Resume, keeping the loop and the
+// offset, for example during downstream maintenance. A long poll already
+// in flight completes and its updates are delivered. Telegram keeps new
+// updates for up to 24 hours. IsHealthy is unaffected.
+func (c *LongPollingClient) Pause() {
+	c.suspendMu.Lock()
+	defer c.suspendMu.Unlock()
+	if c.resumeCh == nil {
+		c.resumeCh = make(chan struct{})
+		c.logger.Info("polling paused")
+	}
+}
+
+// Resume continues polling after Pause from the retained offset.
+func (c *LongPollingClient) Resume() {
+	c.suspendMu.Lock()
+	defer c.suspendMu.Unlock()
+	if c.resumeCh != nil {
+		close(c.resumeCh)
+		c.resumeCh = nil
+		c.logger.Info("polling resumed")
+	}
+}
+
+// suspended reports whether polling is paused via Pause.
+func (c *LongPollingClient) suspended() bool {
+	c.suspendMu.Lock()
+	defer c.suspendMu.Unlock()
+	return c.resumeCh != nil
+}
+
+// waitWhileSuspended blocks while the client is paused via Pause. It
+// returns false if the client was stopped in the meantime.
+func (c *LongPollingClient) waitWhileSuspended(ctx context.Context) bool {
+	c.suspendMu.Lock()
+	resume := c.resumeCh
+	c.suspendMu.Unlock()
+	if resume == nil {
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-c.stopCh:
+		return false
+	case <-resume:
+		return true
 	}
+}
+
+// adaptTimeout adjusts the next getUpdates timeout after a successful poll
+// that returned n updates. No-op unless adaptive polling is enabled.
+func (c *LongPollingClient) adaptTimeout(n int) {
+	if !c.adaptive {
+		return
+	}
+	current := int(c.currentTimeout.Load())
+	if n > 0 {
+		current = max(current/2, c.adaptiveMin)
+	} else {
+		current = min(max(current*2, 1), c.adaptiveMax)
+	}
+	c.currentTimeout.Store(int32(current))
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// fetchUpdates calls the Telegram getUpdates API.
+func (c *LongPollingClient) fetchUpdates(ctx context.Context) ([]TelegramUpdate, error) {
+	req, err := c.newGetUpdatesRequest(c.traceConn(ctx))
 	if err != nil {
 		return nil, &TelegramAPIError{Description: "failed to create request", Err: err}
 	}
 
 	// Use circuit breaker for the HTTP call
-	respBody, err := c.breaker.Execute(func() ([]byte, error) {
-		resp, err := c.client.Do(req)
+	var updates []TelegramUpdate
+	_, err = c.breaker.Execute(func() ([]byte, error) {
+		result, err := c.decodeUpdates(req)
 		if err != nil {
 			return nil, err
 		}
-		defer func() {
-			// Always drain remaining body for connection reuse
-			io.Copy(io.Discard, resp.Body)
-			resp.Body.Close()
-		}()
+		updates = result
+		return nil, nil
+	})
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
+	if err != nil {
+		var apiErr *TelegramAPIError
+		if errors.As(err, &apiErr) {
+			switch apiErr.Code {
+			case http.StatusConflict:
+				return nil, fmt.Errorf("%w: %w", ErrPollingConflict, apiErr)
+			case http.StatusUnauthorized:
+				return nil, fmt.Errorf("%w: %w", ErrUnauthorizedToken, apiErr)
+			}
+			return nil, apiErr
 		}
+		return nil, &TelegramAPIError{Description: "request failed", Err: err}
+	}
 
-		// Check HTTP status code
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-		}
+	return updates, nil
+}
 
-		return body, nil
+// traceConn records whether getUpdates got a pooled connection, so
+// operators can verify keep-alive works: a new connection per poll costs a
+// TCP and TLS handshake each time.
+func (c *LongPollingClient) traceConn(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				c.connsReused.Add(1)
+				return
+			}
+			if n := c.connsNew.Add(1); n > 1 {
+				c.logger.Debug("getUpdates opened a new connection",
+					"connections_new", n,
+					"connections_reused", c.connsReused.Load(),
+				)
+			}
+		},
 	})
+}
 
-	if err != nil {
-		return nil, &TelegramAPIError{Description: "request failed", Err: err}
+// checkOrdering warns when id does not directly follow the highest update
+// ID seen so far.
+func (c *LongPollingClient) checkOrdering(id int) {
+	last := c.lastUpdateID
+	if id > last {
+		c.lastUpdateID = id
+	}
+	switch {
+	case last == 0:
+	case id <= last:
+		c.logger.Warn("update received out of order or duplicated",
+			"update_id", id,
+			"previous_update_id", last,
+		)
+	case id > last+1:
+		c.logger.Warn("gap in update IDs",
+			"update_id", id,
+			"previous_update_id", last,
+			"missing", id-last-1,
+		)
 	}
+}
 
-	var response getUpdatesResponse
-	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, &TelegramAPIError{Description: "failed to parse response", Err: err}
+// newGetUpdatesRequest builds the getUpdates request for the next poll: a
+// GET with query parameters, or a POST with a JSON body when a long
+// allowed_updates list would push the URL past maxGetUpdatesURLLength,
+// which proxies and servers may reject with 414.
+func (c *LongPollingClient) newGetUpdatesRequest(ctx context.Context) (*http.Request, error) {
+	q := c.getUpdatesQuery()
+	endpoint := telegramAPIBaseURL + url.PathEscape(c.botToken.Value()) + "/getUpdates"
+	if reqURL := endpoint + "?" + q.Encode(); len(reqURL) <= maxGetUpdatesURLLength {
+		return http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	}
 
-	if !response.OK {
-		return nil, &TelegramAPIError{
-			Code:        response.ErrorCode,
-			Description: response.Description,
+	// Every parameter value is already a JSON number or array
+	params := make(map[string]json.RawMessage, len(q))
+	for key := range q {
+		params[key] = json.RawMessage(q.Get(key))
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// getUpdatesQuery returns the getUpdates parameters for the next poll. It
+// holds no secrets, so it is safe to log.
+func (c *LongPollingClient) getUpdatesQuery() url.Values {
+	q := url.Values{}
+	q.Set("timeout", strconv.Itoa(int(c.currentTimeout.Load())))
+	q.Set("limit", strconv.Itoa(c.limit))
+	q.Set("offset", strconv.FormatInt(c.offset.Load(), 10))
+
+	// Add allowed_updates if configured; an empty list resets the filter
+	if allowed := c.getAllowedUpdates(); allowed != nil {
+		if encoded, err := json.Marshal(allowed); err == nil {
+			q.Set("allowed_updates", string(encoded))
 		}
 	}
+	return q
+}
+
+// Err returns the error that made the poll loop stop on its own, wrapping
+// ErrUnauthorizedToken or ErrMaxRetriesExceeded. It is nil while running
+// and after a stop requested via Stop or context cancellation.
+func (c *LongPollingClient) Err() error {
+	if p := c.stopErr.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
 
-	return response.Result, nil
+func (c *LongPollingClient) setStopErr(err error) {
+	c.stopErr.Store(&err)
 }
 
 // Running returns true if the polling client is currently running.
@@ -421,14 +1225,44 @@ func (c *LongPollingClient) Running() bool {
 	return c.running.Load()
 }
 
+// State reports whether the client is running, paused (see WithAutoRestart),
+// suspended (see Pause) or stopped.
+func (c *LongPollingClient) State() PollingState {
+	switch {
+	case !c.running.Load():
+		return PollingStateStopped
+	case c.paused.Load():
+		return PollingStatePaused
+	case c.suspended():
+		return PollingStateSuspended
+	default:
+		return PollingStateRunning
+	}
+}
+
 // IsHealthy returns health status for K8s probes.
-// Returns false if not running or too many consecutive errors.
+// Returns false if not running, paused or too many consecutive errors
+// (the unhealthy threshold when set, otherwise the max errors).
 func (c *LongPollingClient) IsHealthy() bool {
-	if c.maxErrors == 0 {
+	if c.paused.Load() {
+		return false
+	}
+	limit := c.maxErrors
+	if c.unhealthyThreshold > 0 && (limit == 0 || c.unhealthyThreshold < limit) {
+		limit = c.unhealthyThreshold
+	}
+	if limit == 0 {
 		// Unlimited errors mode - just check if running
 		return c.running.Load()
 	}
-	return c.running.Load() && int(c.consecutiveErrors.Load()) < c.maxErrors
+	return c.running.Load() && int(c.consecutiveErrors.Load()) < limit
+}
+
+// IsLive reports whether the polling loop is still running, including while
+// it retries errors below the max or waits to auto-restart. Use it for
+// liveness probes alongside IsHealthy for readiness.
+func (c *LongPollingClient) IsLive() bool {
+	return c.running.Load()
 }
 
 // ConsecutiveErrors returns the current consecutive error count.
@@ -436,7 +1270,42 @@ func (c *LongPollingClient) ConsecutiveErrors() int32 {
 	return c.consecutiveErrors.Load()
 }
 
-// Offset returns the current update offset.
+// Offset returns the current update offset: the ID of the next update to
+// fetch. It is safe to call concurrently with polling.
 func (c *LongPollingClient) Offset() int {
-	return c.offset
+	return int(c.offset.Load())
+}
+
+// stats returns a snapshot of the polling activity.
+func (c *LongPollingClient) stats() Stats {
+	s := Stats{
+		Running:           c.running.Load(),
+		ConsecutiveErrors: int(c.consecutiveErrors.Load()),
+		BreakerState:      c.breaker.State().String(),
+		Offset:            c.Offset(),
+		ConnectionsReused: c.connsReused.Load(),
+		ConnectionsNew:    c.connsNew.Load(),
+	}
+	c.counters.fill(&s)
+	return s
+}
+
+// setAllowedUpdates replaces the update type filter. The new filter is
+// used from the next getUpdates call onwards. nil keeps Telegram's previous
+// setting, an empty list resets it to the default set.
+func (c *LongPollingClient) setAllowedUpdates(types []string) {
+	c.settingsMu.Lock()
+	defer c.settingsMu.Unlock()
+	if types == nil {
+		c.allowedUpdates = nil
+		return
+	}
+	c.allowedUpdates = append(make([]string, 0, len(types)), types...)
+}
+
+// getAllowedUpdates returns the current update type filter.
+func (c *LongPollingClient) getAllowedUpdates() []string {
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	return c.allowedUpdates
 }
@@ -1,16 +1,25 @@
 package telegramreceiver
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/sony/gobreaker/v2"
 )
 
 func TestLongPollingClient_Start(t *testing.T) {
@@ -280,6 +289,12 @@ done:
 	if len(received) > 1 && received[1].Message.Text != "World" {
 		t.Errorf("expected second message text 'World', got %q", received[1].Message.Text)
 	}
+
+	for _, update := range received {
+		if since := time.Since(update.ReceivedAt); since < 0 || since > 5*time.Second {
+			t.Errorf("update %d: expected ReceivedAt close to now, got %v", update.UpdateID, update.ReceivedAt)
+		}
+	}
 }
 
 func TestLongPollingClient_GracefulShutdown(t *testing.T) {
@@ -533,61 +548,1264 @@ func TestLongPollingClient_WithRetryConfig(t *testing.T) {
 	}
 }
 
-func TestLongPollingClient_CalculateBackoff(t *testing.T) {
+func TestLongPollingClient_WithReadyToTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var transitions []gobreaker.State
+
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10),
+		WithReadyToTrip(func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= 1
+		}),
+		WithBreakerOnStateChange(func(name string, from, to gobreaker.State) {
+			mu.Lock()
+			defer mu.Unlock()
+			transitions = append(transitions, to)
+		}),
+	)
+
+	if _, err := client.fetchUpdates(context.Background()); err == nil {
+		t.Fatal("expected fetch error from failing server")
+	}
+
+	if state := client.breaker.State(); state != gobreaker.StateOpen {
+		t.Errorf("expected breaker to be open after first failure, got %s", state)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(transitions) != 1 || transitions[0] != gobreaker.StateOpen {
+		t.Errorf("expected one transition to open, got %v", transitions)
+	}
+}
+
+func TestLongPollingClient_DefaultReadyToTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10))
+
+	client.fetchUpdates(context.Background())
+	if state := client.breaker.State(); state != gobreaker.StateClosed {
+		t.Errorf("expected default breaker to stay closed after one failure, got %s", state)
+	}
+}
+
+func TestLongPollingClient_ShortHTTPTimeoutWarning(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	NewLongPollingClient(
+		SecretToken("test-token"),
+		make(chan TelegramUpdate, 10),
+		logger,
+		30,
+		10,
+		5,
+		time.Minute,
+		time.Minute,
+		WithHTTPClient(&http.Client{Timeout: 10 * time.Second}),
+	)
+
+	if !strings.Contains(buf.String(), "HTTP client timeout is shorter than the long-poll timeout") {
+		t.Errorf("expected short timeout warning, got %q", buf.String())
+	}
+}
+
+func TestLongPollingClient_WithPollHTTPTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	client := NewLongPollingClient(
+		SecretToken("test-token"),
+		make(chan TelegramUpdate, 10),
+		logger,
+		30,
+		10,
+		5,
+		time.Minute,
+		time.Minute,
+		WithPollHTTPTimeout(45*time.Second),
+	)
+
+	hc, ok := client.client.(*http.Client)
+	if !ok {
+		t.Fatalf("expected *http.Client, got %T", client.client)
+	}
+	if hc.Timeout != 45*time.Second {
+		t.Errorf("expected HTTP timeout 45s, got %v", hc.Timeout)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no warning for a sufficient timeout, got %q", buf.String())
+	}
+}
+
+func TestLongPollingClient_WithTransportConfig(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	updates := make(chan TelegramUpdate, 10)
 
 	client := NewLongPollingClient(
 		SecretToken("test-token"),
-		updates,
+		make(chan TelegramUpdate, 10),
 		logger,
-		1,
+		30,
 		10,
 		5,
 		time.Minute,
 		time.Minute,
-		WithRetryConfig(time.Second, 60*time.Second, 2.0),
+		WithTransportConfig(100, 0, 5*time.Minute),
+	)
+
+	hc, ok := client.client.(*http.Client)
+	if !ok {
+		t.Fatalf("expected *http.Client, got %T", client.client)
+	}
+	tr, ok := hc.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected *http.Transport, got %T", hc.Transport)
+	}
+	if tr.MaxIdleConns != 100 {
+		t.Errorf("expected MaxIdleConns 100, got %d", tr.MaxIdleConns)
+	}
+	if tr.MaxIdleConnsPerHost != 10 {
+		t.Errorf("expected default MaxIdleConnsPerHost 10, got %d", tr.MaxIdleConnsPerHost)
+	}
+	if tr.IdleConnTimeout != 5*time.Minute {
+		t.Errorf("expected IdleConnTimeout 5m, got %v", tr.IdleConnTimeout)
+	}
+	if hc.Timeout != 40*time.Second {
+		t.Errorf("expected default HTTP timeout 40s, got %v", hc.Timeout)
+	}
+}
+
+// newStubProxy starts an HTTP proxy that records CONNECT targets and refuses them.
+func newStubProxy(t *testing.T) (*httptest.Server, <-chan string) {
+	t.Helper()
+	targets := make(chan string, 10)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			targets <- r.Host
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, targets
+}
+
+func TestLongPollingClient_WithPollProxy(t *testing.T) {
+	proxy, targets := newStubProxy(t)
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	client := NewLongPollingClient(
+		SecretToken("test-token"),
+		make(chan TelegramUpdate, 10),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		30,
+		10,
+		5,
+		time.Minute,
+		time.Minute,
+		WithPollProxy(proxyURL),
+	)
+
+	if _, err := client.fetchUpdates(context.Background()); err == nil {
+		t.Fatal("expected error from refusing proxy")
+	}
+	select {
+	case target := <-targets:
+		if target != "api.telegram.org:443" {
+			t.Errorf("expected CONNECT api.telegram.org:443, got %s", target)
+		}
+	default:
+		t.Fatal("request did not go through the proxy")
+	}
+
+	hc := client.client.(*http.Client)
+	if hc.Timeout != 40*time.Second {
+		t.Errorf("expected long-poll HTTP timeout 40s, got %v", hc.Timeout)
+	}
+}
+
+func TestLongPollingClient_Conflict(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"ok":false,"error_code":409,"description":"Conflict: terminated by other getUpdates request; make sure that only one bot instance is running"}`))
+	}))
+	defer server.Close()
+
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10),
+		WithRetryConfig(time.Millisecond, time.Hour, 2.0),
+		WithMaxErrors(0),
 	)
 
+	_, err := client.fetchUpdates(context.Background())
+	if !errors.Is(err, ErrPollingConflict) {
+		t.Fatalf("expected ErrPollingConflict, got %v", err)
+	}
+	var apiErr *TelegramAPIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusConflict {
+		t.Errorf("expected wrapped 409 TelegramAPIError, got %v", err)
+	}
+
+	// The loop must wait the maximum delay instead of the 1ms initial delay
+	requests.Store(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	client.Stop()
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected a single getUpdates call during conflict backoff, got %d", got)
+	}
+}
+
+func TestLongPollingClient_TruncatedResponse(t *testing.T) {
+	full := `{"ok":true,"result":[{"update_id":7,"message":{"message_id":1,"date":1700000000,"chat":{"id":1,"type":"private"},"text":"a"}},{"update_id":8,"message":{"message_id":2,"date":1700000000,"chat":{"id":1,"type":"private"},"text":"b"}}]}`
+	truncated := full[:len(full)/2]
+
 	tests := []struct {
-		attempt     int32
-		minExpected time.Duration
-		maxExpected time.Duration
+		name  string
+		write func(w http.ResponseWriter)
 	}{
-		{1, time.Second, time.Second + 250*time.Millisecond},           // 1s + 0-25% jitter
-		{2, 2 * time.Second, 2*time.Second + 500*time.Millisecond},     // 2s + 0-25% jitter
-		{3, 4 * time.Second, 4*time.Second + time.Second},              // 4s + 0-25% jitter
-		{4, 8 * time.Second, 8*time.Second + 2*time.Second},            // 8s + 0-25% jitter
-		{10, 60 * time.Second, 60*time.Second + 15*time.Second},        // capped at max
+		{"connection dropped mid-body", func(w http.ResponseWriter) {
+			w.Header().Set("Content-Length", strconv.Itoa(len(full)))
+			w.Write([]byte(truncated))
+		}},
+		{"body ends mid-document", func(w http.ResponseWriter) {
+			w.Write([]byte(truncated))
+		}},
 	}
 
 	for _, tt := range tests {
-		backoff := client.calculateBackoff(tt.attempt)
-		if backoff < tt.minExpected || backoff > tt.maxExpected {
-			t.Errorf("attempt %d: expected backoff between %v and %v, got %v",
-				tt.attempt, tt.minExpected, tt.maxExpected, backoff)
-		}
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case requests.Add(1) <= 2:
+					tt.write(w)
+				case r.URL.Query().Get("offset") == "0":
+					w.Write([]byte(full))
+				default:
+					w.Write([]byte(`{"ok":true,"result":[]}`))
+				}
+			}))
+			defer server.Close()
+
+			updates := make(chan TelegramUpdate, 10)
+			client := newTestPollingClient(server, updates, WithRetryConfig(time.Millisecond, 5*time.Millisecond, 2.0))
+
+			got, err := client.fetchUpdates(context.Background())
+			if !errors.Is(err, ErrTruncatedResponse) {
+				t.Fatalf("expected ErrTruncatedResponse, got %v", err)
+			}
+			if got != nil || client.offset.Load() != 0 {
+				t.Fatalf("truncated batch must not yield updates or advance the offset (updates %v, offset %d)", got, client.offset.Load())
+			}
+
+			// The loop treats it as transient and refetches the whole batch
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := client.Start(ctx); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			defer client.Stop()
+
+			for _, want := range []int{7, 8} {
+				select {
+				case upd := <-updates:
+					if upd.UpdateID != want {
+						t.Fatalf("expected update %d, got %d", want, upd.UpdateID)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatalf("update %d not delivered after retry", want)
+				}
+			}
+			select {
+			case upd := <-updates:
+				t.Errorf("unexpected extra update %d", upd.UpdateID)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
 	}
 }
 
-// testTransport intercepts HTTP requests and redirects them to the test server.
-type testTransport struct {
-	baseURL    string
-	httpClient *http.Client
+func TestLongPollingClient_StrictOrdering(t *testing.T) {
+	for _, strict := range []bool{true, false} {
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			var served atomic.Bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if served.CompareAndSwap(false, true) {
+					w.Write([]byte(`{"ok":true,"result":[{"update_id":5},{"update_id":7},{"update_id":6}]}`))
+					return
+				}
+				w.Write([]byte(`{"ok":true,"result":[]}`))
+			}))
+			defer server.Close()
+
+			var logs lockedBuffer
+			updates := make(chan TelegramUpdate, 10)
+			var opts []LongPollingOption
+			if strict {
+				opts = append(opts, WithStrictOrdering())
+			}
+			client := newTestPollingClient(server, updates, opts...)
+			client.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := client.Start(ctx); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			for range 3 {
+				select {
+				case <-updates:
+				case <-time.After(2 * time.Second):
+					t.Fatal("updates not delivered")
+				}
+			}
+			client.Stop()
+
+			out := strings.Join(logs.lines(), "\n")
+			gap := strings.Contains(out, `msg="gap in update IDs" update_id=7 previous_update_id=5 missing=1`)
+			order := strings.Contains(out, `msg="update received out of order or duplicated" update_id=6 previous_update_id=7`)
+			if gap != strict || order != strict {
+				t.Errorf("expected ordering warnings=%v, got gap=%v out-of-order=%v in:\n%s", strict, gap, order, out)
+			}
+		})
+	}
 }
 
-func (t *testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Rewrite the URL to point to our test server
-	newURL := t.baseURL + req.URL.Path
-	if req.URL.RawQuery != "" {
-		newURL += "?" + req.URL.RawQuery
+func TestLongPollingClient_GetUpdatesQueryEncoding(t *testing.T) {
+	allowed := []string{"message", "callback_query", "odd&type=x#y"}
+	queries := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case queries <- r.URL.RawQuery:
+		default:
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 1), WithAllowedUpdates(allowed))
+	client.offset.Store(42)
+	if _, err := client.fetchUpdates(context.Background()); err != nil {
+		t.Fatalf("fetchUpdates: %v", err)
 	}
 
-	newReq, err := http.NewRequestWithContext(req.Context(), req.Method, newURL, req.Body)
+	raw := <-queries
+	if strings.ContainsAny(raw, `[]"#`) {
+		t.Errorf("query is not URL-encoded: %s", raw)
+	}
+	q, err := url.ParseQuery(raw)
 	if err != nil {
-		return nil, err
+		t.Fatalf("invalid query %q: %v", raw, err)
 	}
-	newReq.Header = req.Header
+	var got []string
+	if err := json.Unmarshal([]byte(q.Get("allowed_updates")), &got); err != nil {
+		t.Fatalf("allowed_updates is not a JSON array: %v", err)
+	}
+	if !slices.Equal(got, allowed) {
+		t.Errorf("allowed_updates = %q, want %q", got, allowed)
+	}
+	if q.Get("offset") != "42" || q.Get("limit") != "10" || q.Get("timeout") != "1" {
+		t.Errorf("unexpected parameters: %v", q)
+	}
+	if strings.Contains(client.getUpdatesQuery().Encode(), "test-token") {
+		t.Error("query must not contain the bot token")
+	}
+}
 
-	return t.httpClient.Transport.RoundTrip(newReq)
+func TestLongPollingClient_PauseResume(t *testing.T) {
+	var requests atomic.Int32
+	gate := make(chan struct{}, 1) // each token releases one update
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case <-gate:
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			fmt.Fprintf(w, `{"ok":true,"result":[{"update_id":%d}]}`, max(offset, 1))
+		case <-time.After(5 * time.Millisecond):
+			w.Write([]byte(`{"ok":true,"result":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	updates := make(chan TelegramUpdate, 10)
+	client := newTestPollingClient(server, updates)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	receive := func() int {
+		t.Helper()
+		select {
+		case upd := <-updates:
+			return upd.UpdateID
+		case <-time.After(2 * time.Second):
+			t.Fatal("update not delivered")
+			return 0
+		}
+	}
+
+	gate <- struct{}{}
+	if id := receive(); id != 1 {
+		t.Fatalf("expected update 1, got %d", id)
+	}
+
+	client.Pause()
+	time.Sleep(50 * time.Millisecond) // let the in-flight poll finish
+	before := requests.Load()
+	gate <- struct{}{}
+	time.Sleep(100 * time.Millisecond)
+
+	if got := requests.Load(); got != before {
+		t.Errorf("getUpdates called %d times while paused", got-before)
+	}
+	if client.State() != PollingStateSuspended {
+		t.Errorf("expected state suspended, got %s", client.State())
+	}
+	if !client.IsHealthy() {
+		t.Error("a paused client must stay healthy")
+	}
+	if client.Offset() != 2 {
+		t.Errorf("expected offset 2 kept while paused, got %d", client.Offset())
+	}
+	select {
+	case upd := <-updates:
+		t.Fatalf("update %d delivered while paused", upd.UpdateID)
+	default:
+	}
+
+	client.Resume()
+	if id := receive(); id != 2 {
+		t.Errorf("expected update 2 after resume, got %d", id)
+	}
+	if client.State() != PollingStateRunning {
+		t.Errorf("expected state running, got %s", client.State())
+	}
+}
+
+func TestNewLongPollingClient_NilUpdates(t *testing.T) {
+	defer func() {
+		r := recover()
+		if msg, _ := r.(string); !strings.Contains(msg, "updates channel is nil") {
+			t.Errorf("expected a clear panic for a nil updates channel, got %v", r)
+		}
+	}()
+	NewLongPollingClient(SecretToken(testBotToken), nil, newTestLogger(), 1, 10, 5, time.Minute, time.Minute)
+}
+
+func TestLongPollingClient_OnReceive(t *testing.T) {
+	var served atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.CompareAndSwap(false, true) {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":1},{"update_id":2},{"update_id":3}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	received := make(chan int, 10)
+	client := newTestPollingClient(server, nil,
+		WithPollOnReceive(func(ctx context.Context, u TelegramUpdate) {
+			if ctx == nil {
+				t.Error("expected a context")
+			}
+			if u.UpdateID == 2 {
+				panic("boom")
+			}
+			received <- u.UpdateID
+		}),
+	)
+
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	for _, want := range []int{1, 3} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("callback got update %d, want %d", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("callback not called for update %d", want)
+		}
+	}
+	if got := client.stats().HandlerPanics; got != 1 {
+		t.Errorf("HandlerPanics = %d, want 1", got)
+	}
+	if client.Offset() != 4 {
+		t.Errorf("expected offset 4 after the batch, got %d", client.Offset())
+	}
+}
+
+func TestLongPollingClient_HandlerTimeout(t *testing.T) {
+	var served atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.CompareAndSwap(false, true) {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":1},{"update_id":2}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	received := make(chan int, 10)
+	client := newTestPollingClient(server, nil,
+		WithPollHandlerTimeout(20*time.Millisecond),
+		WithPollOnReceive(func(ctx context.Context, u TelegramUpdate) {
+			if u.UpdateID == 1 {
+				<-release // Ignores ctx
+			}
+			received <- u.UpdateID
+		}),
+	)
+
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	select {
+	case got := <-received:
+		if got != 2 {
+			t.Errorf("callback got update %d, want 2", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("polling did not move on after the handler timeout")
+	}
+	if got := client.stats().HandlerTimeouts; got != 1 {
+		t.Errorf("HandlerTimeouts = %d, want 1", got)
+	}
+}
+
+func TestLongPollingClient_OffsetPublisher(t *testing.T) {
+	batches := []string{
+		`{"ok":true,"result":[{"update_id":10},{"update_id":11}]}`,
+		`{"ok":true,"result":[]}`,
+		`{"ok":true,"result":[{"update_id":12}]}`,
+	}
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i := int(calls.Add(1)) - 1; i < len(batches) {
+			w.Write([]byte(batches[i]))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	published := make(chan int, 10)
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10),
+		WithPollOffsetPublisher(func(offset int) { published <- offset }),
+	)
+
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	for _, want := range []int{12, 13} {
+		select {
+		case got := <-published:
+			if got != want {
+				t.Errorf("published offset %d, want %d", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("offset %d not published", want)
+		}
+	}
+	if got := client.Offset(); got != 13 {
+		t.Errorf("Offset() = %d, want 13", got)
+	}
+}
+
+func TestLongPollingClient_OffsetPublishedBeforeStopReturns(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":1},{"update_id":2},{"update_id":3}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var published []int
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10),
+		WithPollOffsetPublisher(func(offset int) {
+			mu.Lock()
+			defer mu.Unlock()
+			published = append(published, offset)
+		}),
+		WithPollOnReceive(func(ctx context.Context, u TelegramUpdate) {
+			if u.UpdateID == 1 {
+				close(started)
+				<-release
+			}
+		}),
+	)
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// Stop while the first update of the batch is being handled
+	<-started
+	stopped := make(chan struct{})
+	go func() {
+		client.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop returned while a batch was being delivered")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(published) == 0 || published[len(published)-1] != 4 {
+		t.Errorf("published offsets %v, want the last to be 4", published)
+	}
+	if got := client.Offset(); got != 4 {
+		t.Errorf("Offset() = %d, want 4", got)
+	}
+}
+
+func TestLongPollingClient_UnhealthyThreshold(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var delivered atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"ok":false,"error_code":502,"description":"Bad Gateway"}`))
+			return
+		}
+		if delivered.CompareAndSwap(false, true) {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":7}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	updates := make(chan TelegramUpdate, 10)
+	client := newTestPollingClient(server, updates,
+		WithRetryConfig(5*time.Millisecond, 10*time.Millisecond, 2.0),
+		WithReadyToTrip(func(gobreaker.Counts) bool { return false }),
+		WithMaxErrors(1000),
+		WithUnhealthyThreshold(2),
+	)
+
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.ConsecutiveErrors() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 consecutive errors, got %d", client.ConsecutiveErrors())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if client.IsHealthy() {
+		t.Error("expected unhealthy at the threshold")
+	}
+	if !client.IsLive() || client.State() != PollingStateRunning {
+		t.Errorf("expected polling to continue below max errors, live=%v state=%s", client.IsLive(), client.State())
+	}
+
+	down.Store(false)
+
+	select {
+	case upd := <-updates:
+		if upd.UpdateID != 7 {
+			t.Errorf("expected update 7, got %d", upd.UpdateID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("polling did not continue after the threshold")
+	}
+	if !client.IsHealthy() {
+		t.Error("expected healthy again after a successful poll")
+	}
+}
+
+func TestLongPollingClient_AutoRestart(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var delivered atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"ok":false,"error_code":502,"description":"Bad Gateway"}`))
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/getMe") {
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"bot"}}`))
+			return
+		}
+		if delivered.CompareAndSwap(false, true) {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":7}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	updates := make(chan TelegramUpdate, 10)
+	client := newTestPollingClient(server, updates,
+		WithRetryConfig(time.Millisecond, 5*time.Millisecond, 2.0),
+		WithMaxErrors(2),
+		WithAutoRestart(10*time.Millisecond),
+	)
+
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.State() != PollingStatePaused {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected paused state, got %s", client.State())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if client.IsHealthy() {
+		t.Error("expected unhealthy while paused")
+	}
+
+	down.Store(false)
+
+	select {
+	case upd := <-updates:
+		if upd.UpdateID != 7 {
+			t.Errorf("expected update 7, got %d", upd.UpdateID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("polling did not resume after recovery")
+	}
+	if got := client.State(); got != PollingStateRunning {
+		t.Errorf("expected running state after recovery, got %s", got)
+	}
+	if !client.IsHealthy() {
+		t.Error("expected healthy after recovery")
+	}
+	if got := client.ConsecutiveErrors(); got != 0 {
+		t.Errorf("expected consecutive errors reset, got %d", got)
+	}
+}
+
+func TestLongPollingClient_State(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 1))
+	if got := client.State(); got != PollingStateStopped {
+		t.Errorf("expected stopped before Start, got %s", got)
+	}
+}
+
+func TestLongPollingClient_Stats(t *testing.T) {
+	var served atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.CompareAndSwap(false, true) {
+			w.Write([]byte(`{"ok":true,"result":[{"update_id":1},{"update_id":2},{"update_id":3}]}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	// Room for two updates: the third is dropped
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 2))
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.stats().UpdatesReceived < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("updates not received, stats: %+v", client.stats())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stats := client.stats()
+	if stats.UpdatesDropped != 1 {
+		t.Errorf("expected 1 dropped update, got %d", stats.UpdatesDropped)
+	}
+	if stats.Offset != 4 {
+		t.Errorf("expected offset 4, got %d", stats.Offset)
+	}
+	if !stats.Running || stats.BreakerState != "closed" || stats.ConsecutiveErrors != 0 {
+		t.Errorf("unexpected state: %+v", stats)
+	}
+	if stats.LastUpdateAt.IsZero() {
+		t.Error("expected LastUpdateAt to be set")
+	}
+}
+
+func TestLongPollingClient_UnauthorizedTokenStops(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+	}))
+	defer server.Close()
+
+	// Unlimited errors and auto restart must not keep a revoked token polling
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10),
+		WithRetryConfig(time.Millisecond, time.Millisecond, 2.0),
+		WithMaxErrors(0),
+		WithAutoRestart(time.Millisecond),
+	)
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer client.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for client.Running() {
+		if time.Now().After(deadline) {
+			t.Fatal("polling did not stop after 401")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Errorf("expected a single getUpdates call, got %d", got)
+	}
+	err := client.Err()
+	if !errors.Is(err, ErrUnauthorizedToken) {
+		t.Fatalf("expected ErrUnauthorizedToken, got %v", err)
+	}
+	var apiErr *TelegramAPIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusUnauthorized {
+		t.Errorf("expected wrapped 401 TelegramAPIError, got %v", err)
+	}
+}
+
+func TestLongPollingClient_AdaptivePolling(t *testing.T) {
+	const busyPolls = 3
+	timeouts := make(chan string, 20)
+	var polls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case timeouts <- r.URL.Query().Get("timeout"):
+		default:
+		}
+		n := polls.Add(1)
+		if n <= busyPolls {
+			fmt.Fprintf(w, `{"ok":true,"result":[{"update_id":%d}]}`, n)
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10), WithAdaptivePolling(1, 8))
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// Busy: 8 -> 4 -> 2 -> 1, then idle: 2 -> 4 -> 8 and capped at 8
+	want := []string{"8", "4", "2", "1", "2", "4", "8", "8"}
+	var got []string
+	for range want {
+		select {
+		case timeout := <-timeouts:
+			got = append(got, timeout)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("not enough polls, got timeouts %v", got)
+		}
+	}
+	client.Stop()
+
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("timeouts = %v, want %v", got, want)
+	}
+}
+
+func TestWithAdaptivePolling_Clamps(t *testing.T) {
+	client := &LongPollingClient{}
+	WithAdaptivePolling(90, -5)(client)
+	if client.adaptiveMin != 0 || client.adaptiveMax != 60 {
+		t.Errorf("expected bounds 0..60, got %d..%d", client.adaptiveMin, client.adaptiveMax)
+	}
+}
+
+func TestLongPollingClient_BackoffWithFakeClock(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"ok":false,"error_code":502,"description":"Bad Gateway"}`))
+	}))
+	defer server.Close()
+
+	clk := newFakeClock()
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 1),
+		WithRetryConfig(time.Second, 8*time.Second, 2.0),
+		WithMaxErrors(6),
+		WithReadyToTrip(func(gobreaker.Counts) bool { return false }),
+		withClock(clk),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// Minutes of backoff complete instantly with the fake clock
+	deadline := time.Now().Add(2 * time.Second)
+	for client.Running() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if client.Running() {
+		t.Fatal("client did not stop after max errors")
+	}
+	if !errors.Is(client.Err(), ErrMaxRetriesExceeded) {
+		t.Errorf("expected ErrMaxRetriesExceeded, got %v", client.Err())
+	}
+
+	waits := clk.recorded()
+	bases := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second}
+	if len(waits) != len(bases) {
+		t.Fatalf("expected %d backoff waits, got %v", len(bases), waits)
+	}
+	for i, base := range bases {
+		if waits[i] < base || waits[i] > base+base/4 {
+			t.Errorf("wait %d = %v, want %v plus up to 25%% jitter", i+1, waits[i], base)
+		}
+	}
+	if got := requests.Load(); got != 6 {
+		t.Errorf("expected 6 getUpdates calls, got %d", got)
+	}
+}
+
+func TestLongPollingClient_BackoffResetMode(t *testing.T) {
+	// fail, fail, fail, success, fail, then block until the test ends
+	script := []bool{false, false, false, true, false}
+	tests := []struct {
+		name  string
+		mode  BackoffResetMode
+		bases []time.Duration // Base delay of each retry wait
+	}{
+		{"immediate", BackoffResetImmediate, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, time.Second}},
+		{"decay", BackoffResetDecay, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 2 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			release := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := int(requests.Add(1)) - 1
+				switch {
+				case i >= len(script):
+					select {
+					case <-release:
+					case <-r.Context().Done():
+					}
+					w.Write([]byte(`{"ok":true,"result":[]}`))
+				case script[i]:
+					w.Write([]byte(`{"ok":true,"result":[{"update_id":1}]}`))
+				default:
+					w.WriteHeader(http.StatusBadGateway)
+					w.Write([]byte(`{"ok":false,"error_code":502,"description":"Bad Gateway"}`))
+				}
+			}))
+			defer server.Close()
+			defer close(release)
+
+			clk := newFakeClock()
+			client := newTestPollingClient(server, make(chan TelegramUpdate, 10),
+				WithRetryConfig(time.Second, time.Minute, 2.0),
+				WithReadyToTrip(func(gobreaker.Counts) bool { return false }),
+				WithPollBackoffResetMode(tt.mode),
+				withClock(clk),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if err := client.Start(ctx); err != nil {
+				t.Fatalf("Start: %v", err)
+			}
+			defer client.Stop()
+			defer cancel()
+
+			waitFor(t, "scripted requests", func() bool { return int(requests.Load()) > len(script) })
+
+			waits := clk.recorded()
+			if len(waits) != len(tt.bases) {
+				t.Fatalf("expected %d backoff waits, got %v", len(tt.bases), waits)
+			}
+			for i, base := range tt.bases {
+				if waits[i] < base || waits[i] > base+base/4 {
+					t.Errorf("wait %d = %v, want %v plus up to 25%% jitter", i+1, waits[i], base)
+				}
+			}
+			if got := client.ConsecutiveErrors(); got != 1 {
+				t.Errorf("ConsecutiveErrors = %d, want 1: the error streak resets in both modes", got)
+			}
+		})
+	}
+}
+
+func TestLongPollingClient_MinPollInterval(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("timeout") != "0" {
+			t.Errorf("expected timeout=0, got %q", r.URL.Query().Get("timeout"))
+		}
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewLongPollingClient(
+		SecretToken("test-token"),
+		make(chan TelegramUpdate, 1),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		0, // Telegram answers at once: without throttling this is a tight loop
+		10,
+		5,
+		time.Minute,
+		time.Minute,
+		WithHTTPClient(&http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &testTransport{baseURL: server.URL, httpClient: server.Client()},
+		}),
+		WithMinPollInterval(interval),
+	)
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitFor(t, "four polls", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(times) >= 4
+	})
+	client.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i := 1; i < len(times); i++ {
+		// Arrival times jitter slightly around the client-side spacing
+		if gap := times[i].Sub(times[i-1]); gap < interval-5*time.Millisecond {
+			t.Errorf("poll %d came %v after the previous one, want at least %v", i+1, gap, interval)
+		}
+	}
+}
+
+func TestLongPollingClient_CalculateBackoff(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	updates := make(chan TelegramUpdate, 10)
+
+	client := NewLongPollingClient(
+		SecretToken("test-token"),
+		updates,
+		logger,
+		1,
+		10,
+		5,
+		time.Minute,
+		time.Minute,
+		WithRetryConfig(time.Second, 60*time.Second, 2.0),
+	)
+
+	tests := []struct {
+		attempt     int32
+		minExpected time.Duration
+		maxExpected time.Duration
+	}{
+		{1, time.Second, time.Second + 250*time.Millisecond},           // 1s + 0-25% jitter
+		{2, 2 * time.Second, 2*time.Second + 500*time.Millisecond},     // 2s + 0-25% jitter
+		{3, 4 * time.Second, 4*time.Second + time.Second},              // 4s + 0-25% jitter
+		{4, 8 * time.Second, 8*time.Second + 2*time.Second},            // 8s + 0-25% jitter
+		{10, 60 * time.Second, 60*time.Second + 15*time.Second},        // capped at max
+	}
+
+	for _, tt := range tests {
+		backoff := client.calculateBackoff(tt.attempt)
+		if backoff < tt.minExpected || backoff > tt.maxExpected {
+			t.Errorf("attempt %d: expected backoff between %v and %v, got %v",
+				tt.attempt, tt.minExpected, tt.maxExpected, backoff)
+		}
+	}
+}
+
+// newTestPollingClient creates a polling client whose requests are routed to server.
+func newTestPollingClient(server *httptest.Server, updates chan TelegramUpdate, opts ...LongPollingOption) *LongPollingClient {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	opts = append([]LongPollingOption{
+		WithHTTPClient(&http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &testTransport{
+				baseURL:    server.URL,
+				httpClient: server.Client(),
+			},
+		}),
+		WithRetryConfig(10*time.Millisecond, 50*time.Millisecond, 2.0),
+	}, opts...)
+
+	return NewLongPollingClient(
+		SecretToken("test-token"),
+		updates,
+		logger,
+		1,
+		10,
+		5,
+		time.Minute,
+		time.Minute,
+		opts...,
+	)
+}
+
+// testTransport intercepts HTTP requests and redirects them to the test server.
+type testTransport struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func (t *testTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Rewrite the URL to point to our test server
+	newURL := t.baseURL + req.URL.Path
+	if req.URL.RawQuery != "" {
+		newURL += "?" + req.URL.RawQuery
+	}
+
+	newReq, err := http.NewRequestWithContext(req.Context(), req.Method, newURL, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	newReq.Header = req.Header
+
+	return t.httpClient.Transport.RoundTrip(newReq)
+}
+
+func TestLongPollingClient_ConnectionReuse(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		close      bool // Server refuses keep-alive
+		wantReused uint64
+		wantNew    uint64
+	}{
+		{name: "keep-alive", wantReused: 2, wantNew: 1},
+		{name: "connection close", close: true, wantReused: 0, wantNew: 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.close {
+					w.Header().Set("Connection", "close")
+				}
+				w.Write([]byte(`{"ok":true,"result":[]}`))
+			}))
+			defer server.Close()
+
+			client := newTestPollingClient(server, make(chan TelegramUpdate, 1))
+			for range 3 {
+				if _, err := client.fetchUpdates(context.Background()); err != nil {
+					t.Fatalf("fetchUpdates: %v", err)
+				}
+			}
+
+			s := client.stats()
+			if s.ConnectionsReused != tt.wantReused || s.ConnectionsNew != tt.wantNew {
+				t.Errorf("connections reused/new = %d/%d, want %d/%d",
+					s.ConnectionsReused, s.ConnectionsNew, tt.wantReused, tt.wantNew)
+			}
+		})
+	}
+}
+
+func TestLongPollingClient_LongAllowedUpdatesUsePOST(t *testing.T) {
+	allowed := make([]string, 200)
+	for i := range allowed {
+		allowed[i] = fmt.Sprintf("future_update_type_%03d", i)
+	}
+
+	var (
+		method      string
+		contentType string
+		rawQuery    string
+		body        struct {
+			Offset         int      `json:"offset"`
+			Limit          int      `json:"limit"`
+			Timeout        int      `json:"timeout"`
+			AllowedUpdates []string `json:"allowed_updates"`
+		}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, contentType, rawQuery = r.Method, r.Header.Get("Content-Type"), r.URL.RawQuery
+		if r.Method == http.MethodPost {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding body: %v", err)
+			}
+		}
+		w.Write([]byte(`{"ok":true,"result":[{"update_id":8}]}`))
+	}))
+	defer server.Close()
+
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 1), WithAllowedUpdates(allowed))
+	client.offset.Store(8)
+	updates, err := client.fetchUpdates(context.Background())
+	if err != nil {
+		t.Fatalf("fetchUpdates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].UpdateID != 8 {
+		t.Errorf("unexpected updates %+v", updates)
+	}
+
+	if method != http.MethodPost || contentType != "application/json" || rawQuery != "" {
+		t.Fatalf("request = %s %q with query %q, want a JSON POST without query", method, contentType, rawQuery)
+	}
+	if body.Offset != 8 || body.Limit != 10 || body.Timeout != 1 || !slices.Equal(body.AllowedUpdates, allowed) {
+		t.Errorf("unexpected body offset=%d limit=%d timeout=%d with %d allowed updates",
+			body.Offset, body.Limit, body.Timeout, len(body.AllowedUpdates))
+	}
+
+	// A short list keeps using GET
+	client = newTestPollingClient(server, make(chan TelegramUpdate, 1), WithAllowedUpdates([]string{"message"}))
+	if _, err := client.fetchUpdates(context.Background()); err != nil {
+		t.Fatalf("fetchUpdates: %v", err)
+	}
+	if method != http.MethodGet {
+		t.Errorf("short allowed_updates sent with %s, want GET", method)
+	}
 }
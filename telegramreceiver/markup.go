@@ -0,0 +1,294 @@
+package telegramreceiver
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ToHTML renders the message text, or the caption for media messages,
+// with its entities as Telegram-flavored HTML, suitable for sending with
+// ParseMode "HTML". Overlapping entities are split so the tags nest.
+func (m *Message) ToHTML() string {
+	return renderEntities(m.EffectiveText(), m.EffectiveEntities(), htmlMarkup)
+}
+
+// ToMarkdownV2 renders the message text, or the caption for media
+// messages, with its entities as MarkdownV2, suitable for sending with
+// ParseMode "MarkdownV2". Overlapping entities are split so the markers
+// nest.
+func (m *Message) ToMarkdownV2() string {
+	return renderEntities(m.EffectiveText(), m.EffectiveEntities(), markdownV2Markup)
+}
+
+// markupStyle describes how one parse mode writes entities.
+type markupStyle struct {
+	open  func(e *MessageEntity) string
+	close func(e *MessageEntity) string
+	// escape renders plain text inside the given open entities, outermost first
+	escape func(s string, active []*MessageEntity) string
+}
+
+// renderEntities writes text with its entities in style. Offsets count
+// UTF-16 code units. Entities outside the text are ignored. Where entities
+// overlap without nesting, the inner one is closed and reopened around the
+// end of the outer one, so the output is always well-formed.
+func renderEntities(text string, entities []MessageEntity, style markupStyle) string {
+	units := utf16.Encode([]rune(text))
+
+	valid := make([]*MessageEntity, 0, len(entities))
+	points := []int{0, len(units)}
+	for i := range entities {
+		e := &entities[i]
+		if e.Offset < 0 || e.Length <= 0 || e.Offset+e.Length > len(units) {
+			continue
+		}
+		valid = append(valid, e)
+		points = append(points, e.Offset, e.Offset+e.Length)
+	}
+	slices.Sort(points)
+	points = slices.Compact(points)
+
+	end := func(e *MessageEntity) int { return e.Offset + e.Length }
+	// Longer entities open first so they enclose the shorter ones
+	byEnd := func(a, b *MessageEntity) int { return cmp.Compare(end(b), end(a)) }
+
+	var b strings.Builder
+	lastUnderscore := false
+	marker := func(s string) {
+		if s == "" {
+			return
+		}
+		// MarkdownV2 reads "___" greedily as underline; \r separates markers
+		if lastUnderscore && s[0] == '_' {
+			b.WriteByte('\r')
+		}
+		b.WriteString(s)
+		lastUnderscore = s[len(s)-1] == '_'
+	}
+
+	var stack []*MessageEntity
+	for i, p := range points {
+		// Close everything down to the outermost entity ending here and
+		// reopen the ones that continue
+		var reopen []*MessageEntity
+		if j := slices.IndexFunc(stack, func(e *MessageEntity) bool { return end(e) == p }); j >= 0 {
+			for k := len(stack) - 1; k >= j; k-- {
+				marker(style.close(stack[k]))
+			}
+			for _, e := range stack[j:] {
+				if end(e) != p {
+					reopen = append(reopen, e)
+				}
+			}
+			stack = stack[:j]
+		}
+
+		opening := reopen
+		for _, e := range valid {
+			if e.Offset == p {
+				opening = append(opening, e)
+			}
+		}
+		slices.SortStableFunc(opening, byEnd)
+		for _, e := range opening {
+			marker(style.open(e))
+			stack = append(stack, e)
+		}
+
+		if i+1 < len(points) {
+			if s := string(utf16.Decode(units[p:points[i+1]])); s != "" {
+				b.WriteString(style.escape(s, stack))
+				lastUnderscore = false
+			}
+		}
+	}
+	return b.String()
+}
+
+var (
+	htmlTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	htmlAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+)
+
+var htmlMarkup = markupStyle{
+	open: func(e *MessageEntity) string {
+		switch e.Type {
+		case "bold":
+			return "<b>"
+		case "italic":
+			return "<i>"
+		case "underline":
+			return "<u>"
+		case "strikethrough":
+			return "<s>"
+		case "spoiler":
+			return "<tg-spoiler>"
+		case "code":
+			return "<code>"
+		case "pre":
+			if e.Language != "" {
+				return `<pre><code class="language-` + htmlAttrEscaper.Replace(e.Language) + `">`
+			}
+			return "<pre>"
+		case "text_link":
+			return `<a href="` + htmlAttrEscaper.Replace(e.URL) + `">`
+		case "text_mention":
+			if e.User != nil {
+				return `<a href="tg://user?id=` + strconv.FormatInt(e.User.ID, 10) + `">`
+			}
+		case "custom_emoji":
+			if e.CustomEmojiID != "" {
+				return `<tg-emoji emoji-id="` + htmlAttrEscaper.Replace(e.CustomEmojiID) + `">`
+			}
+		case "blockquote":
+			return "<blockquote>"
+		case "expandable_blockquote":
+			return "<blockquote expandable>"
+		}
+		return ""
+	},
+	close: func(e *MessageEntity) string {
+		switch e.Type {
+		case "bold":
+			return "</b>"
+		case "italic":
+			return "</i>"
+		case "underline":
+			return "</u>"
+		case "strikethrough":
+			return "</s>"
+		case "spoiler":
+			return "</tg-spoiler>"
+		case "code":
+			return "</code>"
+		case "pre":
+			if e.Language != "" {
+				return "</code></pre>"
+			}
+			return "</pre>"
+		case "text_link":
+			return "</a>"
+		case "text_mention":
+			if e.User != nil {
+				return "</a>"
+			}
+		case "custom_emoji":
+			if e.CustomEmojiID != "" {
+				return "</tg-emoji>"
+			}
+		case "blockquote", "expandable_blockquote":
+			return "</blockquote>"
+		}
+		return ""
+	},
+	escape: func(s string, _ []*MessageEntity) string {
+		return htmlTextEscaper.Replace(s)
+	},
+}
+
+var (
+	markdownV2TextEscaper = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+		"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	markdownV2CodeEscaper = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	markdownV2URLEscaper  = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
+
+var markdownV2Markup = markupStyle{
+	open: func(e *MessageEntity) string {
+		switch e.Type {
+		case "bold":
+			return "*"
+		case "italic":
+			return "_"
+		case "underline":
+			return "__"
+		case "strikethrough":
+			return "~"
+		case "spoiler":
+			return "||"
+		case "code":
+			return "`"
+		case "pre":
+			return "```" + markdownV2CodeEscaper.Replace(e.Language) + "\n"
+		case "text_link", "text_mention", "custom_emoji":
+			if markdownV2LinkTarget(e) == "" {
+				return ""
+			}
+			if e.Type == "custom_emoji" {
+				return "!["
+			}
+			return "["
+		case "blockquote":
+			return ">"
+		case "expandable_blockquote":
+			return "**>"
+		}
+		return ""
+	},
+	close: func(e *MessageEntity) string {
+		switch e.Type {
+		case "bold":
+			return "*"
+		case "italic":
+			return "_"
+		case "underline":
+			return "__"
+		case "strikethrough":
+			return "~"
+		case "spoiler":
+			return "||"
+		case "code":
+			return "`"
+		case "pre":
+			return "\n```"
+		case "text_link", "text_mention", "custom_emoji":
+			if target := markdownV2LinkTarget(e); target != "" {
+				return "](" + markdownV2URLEscaper.Replace(target) + ")"
+			}
+		case "expandable_blockquote":
+			return "||"
+		}
+		return ""
+	},
+	escape: func(s string, active []*MessageEntity) string {
+		quoted := false
+		for _, e := range active {
+			switch e.Type {
+			case "code", "pre":
+				return markdownV2CodeEscaper.Replace(s)
+			case "blockquote", "expandable_blockquote":
+				quoted = true
+			}
+		}
+		s = markdownV2TextEscaper.Replace(s)
+		if quoted {
+			// Every line of a quotation starts with ">"
+			s = strings.ReplaceAll(s, "\n", "\n>")
+		}
+		return s
+	},
+}
+
+// markdownV2LinkTarget returns the URL a link-like entity points to, or ""
+// when it has none.
+func markdownV2LinkTarget(e *MessageEntity) string {
+	switch e.Type {
+	case "text_link":
+		return e.URL
+	case "text_mention":
+		if e.User != nil {
+			return "tg://user?id=" + strconv.FormatInt(e.User.ID, 10)
+		}
+	case "custom_emoji":
+		if e.CustomEmojiID != "" {
+			return "tg://emoji?id=" + e.CustomEmojiID
+		}
+	}
+	return ""
+}
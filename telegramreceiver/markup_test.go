@@ -0,0 +1,118 @@
+package telegramreceiver
+
+import "testing"
+
+func TestMessage_ToHTMLAndMarkdownV2(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      Message
+		wantHTML string
+		wantMD   string
+	}{
+		{
+			name:     "plain text with special characters",
+			msg:      Message{Text: "1 < 2 & a_b.c! (x)"},
+			wantHTML: "1 &lt; 2 &amp; a_b.c! (x)",
+			wantMD:   `1 < 2 & a\_b\.c\! \(x\)`,
+		},
+		{
+			name: "overlapping bold and italic",
+			msg: Message{
+				Text: "bold both italic",
+				Entities: []MessageEntity{
+					{Type: "bold", Offset: 0, Length: 9},
+					{Type: "italic", Offset: 5, Length: 11},
+				},
+			},
+			wantHTML: "<b>bold <i>both</i></b><i> italic</i>",
+			wantMD:   "*bold _both_*_ italic_",
+		},
+		{
+			name: "nested entities sharing a start",
+			msg: Message{
+				Text: "abc",
+				Entities: []MessageEntity{
+					{Type: "italic", Offset: 0, Length: 1},
+					{Type: "bold", Offset: 0, Length: 3},
+				},
+			},
+			wantHTML: "<b><i>a</i>bc</b>",
+			wantMD:   "*_a_bc*",
+		},
+		{
+			name: "link with escaped URL",
+			msg: Message{
+				Text: "see docs.",
+				Entities: []MessageEntity{
+					{Type: "text_link", Offset: 4, Length: 4, URL: `https://example.com/a_(b)?q="x"&y`},
+				},
+			},
+			wantHTML: `see <a href="https://example.com/a_(b)?q=&quot;x&quot;&amp;y">docs</a>.`,
+			wantMD:   `see [docs](https://example.com/a_(b\)?q="x"&y)\.`,
+		},
+		{
+			name: "UTF-16 offsets after an emoji",
+			msg: Message{
+				Text:     "👍 ok",
+				Entities: []MessageEntity{{Type: "bold", Offset: 3, Length: 2}},
+			},
+			wantHTML: "👍 <b>ok</b>",
+			wantMD:   "👍 *ok*",
+		},
+		{
+			name: "code escapes only backticks and backslashes",
+			msg: Message{
+				Text: "run a_b`c\\ <x>",
+				Entities: []MessageEntity{
+					{Type: "pre", Offset: 4, Length: 10, Language: "go"},
+				},
+			},
+			wantHTML: "run <pre><code class=\"language-go\">a_b`c\\ &lt;x&gt;</code></pre>",
+			wantMD:   "run ```go\na_b\\`c\\\\ <x>\n```",
+		},
+		{
+			name: "italic inside underline",
+			msg: Message{
+				Text: "xy",
+				Entities: []MessageEntity{
+					{Type: "underline", Offset: 0, Length: 2},
+					{Type: "italic", Offset: 0, Length: 2},
+				},
+			},
+			wantHTML: "<u><i>xy</i></u>",
+			wantMD:   "__\r_xy_\r__",
+		},
+		{
+			name: "text mention and multi-line quote",
+			msg: Message{
+				Text: "hi Ann\nline 2",
+				Entities: []MessageEntity{
+					{Type: "blockquote", Offset: 0, Length: 13},
+					{Type: "text_mention", Offset: 3, Length: 3, User: &User{ID: 42}},
+				},
+			},
+			wantHTML: `<blockquote>hi <a href="tg://user?id=42">Ann</a>` + "\nline 2</blockquote>",
+			wantMD:   ">hi [Ann](tg://user?id=42)\n>line 2",
+		},
+		{
+			name: "caption and out-of-range entity",
+			msg: Message{
+				Caption:         "pic",
+				CaptionEntities: []MessageEntity{{Type: "bold", Offset: 0, Length: 3}, {Type: "italic", Offset: 2, Length: 5}},
+			},
+			wantHTML: "<b>pic</b>",
+			wantMD:   "*pic*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.msg.ToHTML(); got != tt.wantHTML {
+				t.Errorf("ToHTML() = %q, want %q", got, tt.wantHTML)
+			}
+			if got := tt.msg.ToMarkdownV2(); got != tt.wantMD {
+				t.Errorf("ToMarkdownV2() = %q, want %q", got, tt.wantMD)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package telegramreceiver
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxMediaGroupSize is the largest album Telegram sends. A group reaching it
+// is delivered without waiting for the window to elapse.
+const maxMediaGroupSize = 10
+
+// MediaGroup is an album: messages sharing a media_group_id, ordered by
+// message ID.
+type MediaGroup struct {
+	ID       string
+	Messages []*Message
+}
+
+// MediaGroupAggregator collects album messages, which Telegram sends as
+// separate updates, and delivers each album as one MediaGroup. Telegram
+// does not mark the last message of an album, so a group is complete once
+// no message for it arrived within the window, or when it holds 10
+// messages. A message arriving after its group was delivered starts a new,
+// partial group. Messages are fed in through Deliver or the Handler
+// middleware. The zero value is not usable; create one with
+// NewMediaGroupAggregator.
+type MediaGroupAggregator struct {
+	window  time.Duration
+	deliver func(MediaGroup)
+
+	mu     sync.Mutex
+	groups map[mediaGroupKey]*pendingMediaGroup
+}
+
+type mediaGroupKey struct {
+	chatID int64
+	id     string
+}
+
+type pendingMediaGroup struct {
+	messages []*Message
+	timer    *time.Timer
+}
+
+// NewMediaGroupAggregator creates an aggregator that calls deliver with
+// each album once window passed without a further message for it. deliver
+// runs on a timer goroutine, or on the caller of Deliver when an album
+// reaches 10 messages, and must be safe for concurrent use.
+func NewMediaGroupAggregator(window time.Duration, deliver func(MediaGroup)) *MediaGroupAggregator {
+	return &MediaGroupAggregator{
+		window:  window,
+		deliver: deliver,
+		groups:  make(map[mediaGroupKey]*pendingMediaGroup),
+	}
+}
+
+// Deliver buffers the update's message if it belongs to an album and
+// reports whether it was consumed. Other updates are ignored.
+func (a *MediaGroupAggregator) Deliver(update TelegramUpdate) bool {
+	msg := update.Message
+	if msg == nil || msg.MediaGroupID == "" || msg.Chat == nil {
+		return false
+	}
+	key := mediaGroupKey{chatID: msg.Chat.ID, id: msg.MediaGroupID}
+
+	a.mu.Lock()
+	g, ok := a.groups[key]
+	if !ok {
+		g = &pendingMediaGroup{}
+		a.groups[key] = g
+	}
+	g.messages = append(g.messages, msg)
+	if g.timer != nil {
+		g.timer.Stop()
+	}
+	if len(g.messages) >= maxMediaGroupSize {
+		delete(a.groups, key)
+		a.mu.Unlock()
+		a.emit(key, g)
+		return true
+	}
+	g.timer = time.AfterFunc(a.window, func() {
+		a.mu.Lock()
+		// The group may already have been delivered by size or Flush
+		if a.groups[key] != g {
+			a.mu.Unlock()
+			return
+		}
+		delete(a.groups, key)
+		a.mu.Unlock()
+		a.emit(key, g)
+	})
+	a.mu.Unlock()
+	return true
+}
+
+// emit sorts a completed group and passes it to the deliver callback.
+func (a *MediaGroupAggregator) emit(key mediaGroupKey, g *pendingMediaGroup) {
+	slices.SortStableFunc(g.messages, func(x, y *Message) int {
+		return x.MessageID - y.MessageID
+	})
+	a.deliver(MediaGroup{ID: key.id, Messages: g.messages})
+}
+
+// Flush delivers every buffered group immediately, for example on shutdown.
+func (a *MediaGroupAggregator) Flush() {
+	a.mu.Lock()
+	groups := a.groups
+	a.groups = make(map[mediaGroupKey]*pendingMediaGroup)
+	a.mu.Unlock()
+
+	for key, g := range groups {
+		g.timer.Stop()
+		a.emit(key, g)
+	}
+}
+
+// Pending returns the number of albums still being collected.
+func (a *MediaGroupAggregator) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.groups)
+}
+
+// Handler returns an UpdateHandler that buffers album messages and passes
+// every other update to next. next may be nil.
+func (a *MediaGroupAggregator) Handler(next UpdateHandler) UpdateHandler {
+	return UpdateHandlerFunc(func(ctx context.Context, update TelegramUpdate) error {
+		if a.Deliver(update) || next == nil {
+			return nil
+		}
+		return next.HandleUpdate(ctx, update)
+	})
+}
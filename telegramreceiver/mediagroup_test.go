@@ -0,0 +1,129 @@
+package telegramreceiver
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func albumUpdate(messageID int, groupID string) TelegramUpdate {
+	return TelegramUpdate{UpdateID: messageID, Message: &Message{
+		MessageID:    messageID,
+		Chat:         &Chat{ID: 10, Type: "private"},
+		MediaGroupID: groupID,
+		Photo:        []PhotoSize{{FileID: "photo"}},
+	}}
+}
+
+func receiveGroup(t *testing.T, groups <-chan MediaGroup) MediaGroup {
+	t.Helper()
+	select {
+	case g := <-groups:
+		return g
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for media group")
+		return MediaGroup{}
+	}
+}
+
+func TestMediaGroupAggregator_Album(t *testing.T) {
+	groups := make(chan MediaGroup, 4)
+	agg := NewMediaGroupAggregator(50*time.Millisecond, func(g MediaGroup) { groups <- g })
+
+	var passed []int
+	handler := agg.Handler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+		passed = append(passed, u.UpdateID)
+		return nil
+	}))
+
+	// Album messages may arrive out of order, mixed with other updates
+	for _, upd := range []TelegramUpdate{
+		albumUpdate(3, "album"),
+		{UpdateID: 99, Message: &Message{MessageID: 99, Chat: &Chat{ID: 10}, Text: "hi"}},
+		albumUpdate(1, "album"),
+		albumUpdate(2, "album"),
+	} {
+		if err := handler.HandleUpdate(context.Background(), upd); err != nil {
+			t.Fatalf("handler failed: %v", err)
+		}
+	}
+	if len(passed) != 1 || passed[0] != 99 {
+		t.Errorf("expected only the text update to pass through, got %v", passed)
+	}
+
+	g := receiveGroup(t, groups)
+	if g.ID != "album" || len(g.Messages) != 3 {
+		t.Fatalf("unexpected group %q with %d messages", g.ID, len(g.Messages))
+	}
+	for i, msg := range g.Messages {
+		if msg.MessageID != i+1 {
+			t.Errorf("message %d has ID %d, want %d", i, msg.MessageID, i+1)
+		}
+	}
+	select {
+	case extra := <-groups:
+		t.Errorf("expected a single delivery, got another group %q", extra.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if agg.Pending() != 0 {
+		t.Errorf("expected no pending groups, got %d", agg.Pending())
+	}
+}
+
+func TestMediaGroupAggregator_FullGroupDeliveredImmediately(t *testing.T) {
+	groups := make(chan MediaGroup, 1)
+	agg := NewMediaGroupAggregator(time.Hour, func(g MediaGroup) { groups <- g })
+
+	for i := 1; i <= maxMediaGroupSize; i++ {
+		agg.Deliver(albumUpdate(i, "big"))
+	}
+	if g := receiveGroup(t, groups); len(g.Messages) != maxMediaGroupSize {
+		t.Errorf("expected %d messages, got %d", maxMediaGroupSize, len(g.Messages))
+	}
+}
+
+func TestMediaGroupAggregator_IncompleteGroups(t *testing.T) {
+	groups := make(chan MediaGroup, 4)
+	agg := NewMediaGroupAggregator(time.Hour, func(g MediaGroup) { groups <- g })
+
+	agg.Deliver(albumUpdate(1, "a"))
+	agg.Deliver(albumUpdate(2, "b"))
+	if agg.Pending() != 2 {
+		t.Fatalf("expected 2 pending groups, got %d", agg.Pending())
+	}
+
+	// A group that never completes is released by Flush
+	agg.Flush()
+	seen := map[string]int{}
+	for range 2 {
+		g := receiveGroup(t, groups)
+		seen[g.ID] = len(g.Messages)
+	}
+	if seen["a"] != 1 || seen["b"] != 1 {
+		t.Errorf("unexpected flushed groups %v", seen)
+	}
+	if agg.Pending() != 0 {
+		t.Errorf("expected no pending groups after Flush, got %d", agg.Pending())
+	}
+}
+
+func TestMediaGroupAggregator_LateMessage(t *testing.T) {
+	groups := make(chan MediaGroup, 4)
+	agg := NewMediaGroupAggregator(20*time.Millisecond, func(g MediaGroup) { groups <- g })
+
+	agg.Deliver(albumUpdate(1, "album"))
+	agg.Deliver(albumUpdate(2, "album"))
+	if g := receiveGroup(t, groups); len(g.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(g.Messages))
+	}
+
+	// A straggler after delivery forms its own partial group
+	agg.Deliver(albumUpdate(3, "album"))
+	if g := receiveGroup(t, groups); len(g.Messages) != 1 || g.Messages[0].MessageID != 3 {
+		t.Errorf("expected a partial group with message 3, got %+v", g)
+	}
+
+	if agg.Deliver(TelegramUpdate{Message: &Message{MessageID: 4, Chat: &Chat{ID: 10}}}) {
+		t.Error("a message outside an album must not be consumed")
+	}
+}
@@ -0,0 +1,159 @@
+package telegramreceiver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMetricsPushJob     = "telegramreceiver"
+	defaultMetricsPushTimeout = 10 * time.Second
+)
+
+// metricsPusher periodically sends the client's Stats to a Prometheus
+// Pushgateway in the text exposition format, for deployments that cannot
+// be scraped. Failed pushes are logged and retried at the next interval.
+type metricsPusher struct {
+	url      string // Grouping key URL: <gateway>/metrics/job/<job>[/instance/<name>]
+	interval time.Duration
+	client   httpClient
+	logger   *slog.Logger
+	collect  func() []byte
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// newMetricsPusher creates a pusher for gateway and starts its goroutine.
+// instance, when set, is added to the grouping key so several bots can
+// push under one job.
+func newMetricsPusher(gateway, job, instance string, interval time.Duration, client httpClient, logger *slog.Logger, collect func() []byte) *metricsPusher {
+	if job == "" {
+		job = defaultMetricsPushJob
+	}
+	target := strings.TrimRight(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	if instance != "" {
+		target += "/instance/" + url.PathEscape(instance)
+	}
+	p := &metricsPusher{
+		url:      target,
+		interval: interval,
+		client:   client,
+		logger:   logger,
+		collect:  collect,
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+func (p *metricsPusher) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopCh:
+			// Final values, so short-lived processes report their last state
+			p.pushLogged()
+			return
+		case <-ticker.C:
+			p.pushLogged()
+		}
+	}
+}
+
+// pushLogged pushes once, logging instead of returning a failure.
+func (p *metricsPusher) pushLogged() {
+	if err := p.push(); err != nil {
+		p.logger.Warn("failed to push metrics", "url", redactURL(p.url), "error", err)
+	}
+}
+
+// push replaces the metrics of the grouping key with a fresh snapshot.
+func (p *metricsPusher) push() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultMetricsPushTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.url, bytes.NewReader(p.collect()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("pushgateway returned %s", resp.Status)
+	}
+	return nil
+}
+
+// stop ends the push loop after a final push.
+func (p *metricsPusher) stop() {
+	p.closeOnce.Do(func() { close(p.stopCh) })
+	<-p.done
+}
+
+// redactURL hides credentials in u for logging.
+func redactURL(u string) string {
+	if parsed, err := url.Parse(u); err == nil {
+		return parsed.Redacted()
+	}
+	return u
+}
+
+// breakerStateValues maps Stats.BreakerState to the numeric gauge value.
+var breakerStateValues = map[string]int{"closed": 0, "half-open": 1, "open": 2}
+
+// formatMetrics renders s, and the spool stats when spooled is true, in the
+// Prometheus text exposition format.
+func formatMetrics(s Stats, spool SpoolStats, spooled bool) []byte {
+	var b bytes.Buffer
+	metric := func(name, kind, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, kind, name, value)
+	}
+	boolValue := func(v bool) int {
+		if v {
+			return 1
+		}
+		return 0
+	}
+
+	metric("telegramreceiver_up", "gauge", "Whether the receiver is running.", boolValue(s.Running))
+	metric("telegramreceiver_updates_received_total", "counter", "Updates decoded from Telegram, including dropped ones.", s.UpdatesReceived)
+	metric("telegramreceiver_updates_dropped_total", "counter", "Updates dropped because the updates channel was full.", s.UpdatesDropped)
+	metric("telegramreceiver_handler_panics_total", "counter", "Panics recovered from the update handler or receive callback.", s.HandlerPanics)
+	metric("telegramreceiver_handler_timeouts_total", "counter", "Handler calls abandoned after the handler timeout.", s.HandlerTimeouts)
+	metric("telegramreceiver_consecutive_errors", "gauge", "Current getUpdates error streak.", s.ConsecutiveErrors)
+	metric("telegramreceiver_connections_reused_total", "counter", "getUpdates calls on a pooled connection.", s.ConnectionsReused)
+	metric("telegramreceiver_connections_new_total", "counter", "getUpdates calls that opened a connection.", s.ConnectionsNew)
+	if state, ok := breakerStateValues[s.BreakerState]; ok {
+		metric("telegramreceiver_breaker_state", "gauge", "Circuit breaker state: 0 closed, 1 half-open, 2 open.", state)
+	}
+	if !s.LastUpdateAt.IsZero() {
+		metric("telegramreceiver_last_update_timestamp_seconds", "gauge", "Unix time of the last received update.", s.LastUpdateAt.Unix())
+	}
+	if spooled {
+		metric("telegramreceiver_spool_depth", "gauge", "Updates waiting in the spool.", spool.Depth)
+		metric("telegramreceiver_spool_dropped_total", "counter", "Updates dropped because the spool was full.", spool.Dropped)
+	}
+	return b.Bytes()
+}
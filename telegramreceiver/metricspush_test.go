@@ -0,0 +1,135 @@
+package telegramreceiver
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockPushgateway records pushed requests and answers with status.
+type mockPushgateway struct {
+	mu     sync.Mutex
+	pushes []string // "METHOD path\nbody"
+	status int
+}
+
+func (g *mockPushgateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	g.mu.Lock()
+	g.pushes = append(g.pushes, r.Method+" "+r.URL.Path+"\n"+string(body))
+	status := g.status
+	g.mu.Unlock()
+	if status != 0 {
+		w.WriteHeader(status)
+	}
+}
+
+func (g *mockPushgateway) received() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.pushes...)
+}
+
+func TestMetricsPusher_PushesAtInterval(t *testing.T) {
+	gateway := &mockPushgateway{}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	pusher := newMetricsPusher(server.URL+"/", "bots", "alpha", 20*time.Millisecond, server.Client(), newTestLogger(),
+		func() []byte { return formatMetrics(Stats{Running: true, UpdatesReceived: 7}, SpoolStats{}, false) })
+
+	waitFor(t, "two pushes", func() bool { return len(gateway.received()) >= 2 })
+	pusher.stop()
+
+	pushes := gateway.received()
+	for _, push := range pushes {
+		if !strings.HasPrefix(push, "PUT /metrics/job/bots/instance/alpha\n") {
+			t.Errorf("unexpected push target: %q", strings.SplitN(push, "\n", 2)[0])
+		}
+		if !strings.Contains(push, "\ntelegramreceiver_updates_received_total 7\n") {
+			t.Errorf("push missing received counter:\n%s", push)
+		}
+	}
+}
+
+func TestMetricsPusher_FailuresAreLogged(t *testing.T) {
+	gateway := &mockPushgateway{status: http.StatusInternalServerError}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	var logs lockedBuffer
+	pusher := newMetricsPusher(server.URL, "", "", 10*time.Millisecond, server.Client(), slog.New(slog.NewTextHandler(&logs, nil)),
+		func() []byte { return formatMetrics(Stats{}, SpoolStats{}, false) })
+
+	// Keeps pushing after failures
+	waitFor(t, "repeated pushes", func() bool { return len(gateway.received()) >= 3 })
+	pusher.stop()
+
+	if push := gateway.received()[0]; !strings.HasPrefix(push, "PUT /metrics/job/telegramreceiver\n") {
+		t.Errorf("expected the default job, got %q", strings.SplitN(push, "\n", 2)[0])
+	}
+	var warned bool
+	for _, line := range logs.lines() {
+		warned = warned || strings.Contains(line, "failed to push metrics")
+	}
+	if !warned {
+		t.Error("expected push failures to be logged")
+	}
+}
+
+func TestFormatMetrics(t *testing.T) {
+	out := string(formatMetrics(Stats{
+		Running:           true,
+		BreakerState:      "open",
+		LastUpdateAt:      time.Unix(1700000000, 0),
+		ConnectionsReused: 4,
+	}, SpoolStats{Depth: 3, Dropped: 1}, true))
+
+	for _, want := range []string{
+		"# TYPE telegramreceiver_up gauge\ntelegramreceiver_up 1\n",
+		"telegramreceiver_breaker_state 2\n",
+		"telegramreceiver_last_update_timestamp_seconds 1700000000\n",
+		"telegramreceiver_spool_depth 3\n",
+		"# TYPE telegramreceiver_connections_reused_total counter\ntelegramreceiver_connections_reused_total 4\n",
+		"# TYPE telegramreceiver_spool_dropped_total counter\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestClient_MetricsPushGateway(t *testing.T) {
+	gateway := &mockPushgateway{}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	client, err := New(testBotToken,
+		WithWebhook(8443, "secret"),
+		WithLogger(newTestLogger()),
+		WithMetricsPushGateway(server.URL, time.Hour, "bots"),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := client.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// The interval is long, so the push comes from Stop
+	client.Stop()
+	pushes := gateway.received()
+	if len(pushes) != 1 || !strings.Contains(pushes[0], "telegramreceiver_up 1\n") {
+		t.Errorf("expected one final push, got %q", pushes)
+	}
+
+	if _, err := New(testBotToken, WithMetricsPushGateway("ftp://gateway", time.Minute, "")); err == nil || !strings.Contains(err.Error(), "metrics_push_url") {
+		t.Errorf("expected metrics_push_url error, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package telegramreceiver
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RecordingHandler is an UpdateHandler that records every update it
+// handles, for asserting in tests what a receiver or handler chain passed
+// on. It is safe for concurrent use. The zero value is ready to use.
+type RecordingHandler struct {
+	mu      sync.Mutex
+	updates []TelegramUpdate
+	changed chan struct{} // Closed and replaced on each recorded update
+}
+
+var _ UpdateHandler = (*RecordingHandler)(nil)
+
+// HandleUpdate records update and returns nil.
+func (h *RecordingHandler) HandleUpdate(_ context.Context, update TelegramUpdate) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.updates = append(h.updates, update)
+	if h.changed != nil {
+		close(h.changed)
+		h.changed = nil
+	}
+	return nil
+}
+
+// Updates returns a copy of the recorded updates in the order handled.
+func (h *RecordingHandler) Updates() []TelegramUpdate {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]TelegramUpdate(nil), h.updates...)
+}
+
+// WaitFor blocks until at least n updates were recorded and reports
+// whether that happened within timeout.
+func (h *RecordingHandler) WaitFor(n int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		h.mu.Lock()
+		if len(h.updates) >= n {
+			h.mu.Unlock()
+			return true
+		}
+		if h.changed == nil {
+			h.changed = make(chan struct{})
+		}
+		changed := h.changed
+		h.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		}
+	}
+}
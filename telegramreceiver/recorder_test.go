@@ -0,0 +1,57 @@
+package telegramreceiver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRecordingHandler_WebhookUpdates(t *testing.T) {
+	var rec RecordingHandler
+	handler := newTestHandler(make(chan TelegramUpdate, 1), WithUpdateHandler(&rec))
+
+	go func() {
+		for _, id := range []int{1, 2, 3} {
+			body, _ := json.Marshal(TelegramUpdate{UpdateID: id})
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}
+	}()
+
+	if !rec.WaitFor(3, 2*time.Second) {
+		t.Fatalf("WaitFor(3) timed out with %d updates", len(rec.Updates()))
+	}
+	got := rec.Updates()
+	for i, upd := range got {
+		if upd.UpdateID != i+1 {
+			t.Errorf("update %d has ID %d, want %d", i, upd.UpdateID, i+1)
+		}
+	}
+
+	// The returned slice is a copy
+	got[0].UpdateID = 99
+	if rec.Updates()[0].UpdateID != 1 {
+		t.Error("Updates() must not expose the internal slice")
+	}
+}
+
+func TestRecordingHandler_WaitForTimeout(t *testing.T) {
+	var rec RecordingHandler
+	rec.HandleUpdate(context.Background(), TelegramUpdate{UpdateID: 1})
+
+	if !rec.WaitFor(1, 0) {
+		t.Error("WaitFor should succeed at once when enough updates were recorded")
+	}
+	start := time.Now()
+	if rec.WaitFor(2, 20*time.Millisecond) {
+		t.Error("WaitFor(2) should time out with one update")
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("WaitFor returned after %v, before the timeout", elapsed)
+	}
+}
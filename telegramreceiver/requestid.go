@@ -0,0 +1,48 @@
+package telegramreceiver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// defaultRequestIDHeader is the header read and echoed for request IDs.
+const defaultRequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds incoming request IDs kept for logging.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the webhook request ID stored in ctx. The
+// context passed to an UpdateHandler always carries one.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// newRequestID returns a random 128-bit hex ID.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID reports whether an incoming ID is safe to log: non-empty,
+// bounded and limited to printable ASCII.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
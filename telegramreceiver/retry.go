@@ -0,0 +1,60 @@
+package telegramreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// retryPolicy describes how retryCall repeats a failed Bot API call.
+type retryPolicy struct {
+	maxAttempts  int           // Total calls (< 1 = 1)
+	initialDelay time.Duration // Wait after the first failure, doubled per retry
+	maxDelay     time.Duration // Cap for the doubled delay (0 = none)
+	retryable    func(error) bool
+	clock        clock
+
+	// Called before each wait, e.g. to log the failure (optional)
+	onRetry func(err error, attempt int, wait time.Duration)
+}
+
+// retryCall calls fn until it succeeds, fails with an error the policy
+// does not retry, or the attempts run out. A longer retry_after from
+// Telegram takes precedence over the computed delay. Cancelling ctx ends a
+// wait with ctx's error, naming the last failure.
+func retryCall[T any](ctx context.Context, p retryPolicy, fn func() (T, error)) (T, error) {
+	maxAttempts := max(p.maxAttempts, 1)
+	delay := p.initialDelay
+
+	for attempt := 1; ; attempt++ {
+		v, err := fn()
+		if err == nil {
+			return v, nil
+		}
+		if attempt >= maxAttempts || !p.retryable(err) {
+			return v, err
+		}
+
+		wait := delay
+		var apiErr *TelegramAPIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+		if p.onRetry != nil {
+			p.onRetry(err, attempt, wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-p.clock.After(wait):
+		}
+
+		delay *= 2
+		if p.maxDelay > 0 && delay > p.maxDelay {
+			delay = p.maxDelay
+		}
+	}
+}
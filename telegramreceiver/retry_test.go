@@ -0,0 +1,47 @@
+package telegramreceiver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetryCall_Backoff(t *testing.T) {
+	clk := newFakeClock()
+	transient := &TelegramAPIError{Code: 502, Description: "Bad Gateway"}
+
+	calls := 0
+	policy := retryPolicy{
+		maxAttempts:  5,
+		initialDelay: time.Second,
+		maxDelay:     3 * time.Second,
+		retryable:    isTransientAPIError,
+		clock:        clk,
+	}
+	_, err := retryCall(context.Background(), policy, func() (bool, error) {
+		calls++
+		return false, transient
+	})
+	if !errors.Is(err, transient) || calls != 5 {
+		t.Fatalf("retryCall() = %v after %d calls, want the last error after 5", err, calls)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	if got := clk.recorded(); !slices.Equal(got, want) {
+		t.Errorf("waits = %v, want %v (doubling, capped at maxDelay)", got, want)
+	}
+}
+
+func TestRetryCall_NotRetryable(t *testing.T) {
+	clk := newFakeClock()
+	calls := 0
+	policy := retryPolicy{maxAttempts: 3, retryable: isTransientAPIError, clock: clk}
+	_, err := retryCall(context.Background(), policy, func() (int, error) {
+		calls++
+		return 0, &TelegramAPIError{Code: 400, Description: "Bad Request"}
+	})
+	if err == nil || calls != 1 || len(clk.recorded()) != 0 {
+		t.Errorf("retryCall() = %v after %d calls and waits %v, want one call without waiting", err, calls, clk.recorded())
+	}
+}
@@ -0,0 +1,84 @@
+package telegramreceiver
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SendMessageParams are the supported sendMessage arguments. This covers
+// simple replies only; use a full Bot API client for anything else.
+type SendMessageParams struct {
+	ChatID           int64
+	Text             string
+	ParseMode        string       // "MarkdownV2", "HTML" or "Markdown" (empty = plain text)
+	ReplyToMessageID int          // Message to reply to (0 = none)
+	ReplyMarkup      *ReplyMarkup // Keyboard or force-reply markup (nil = none)
+}
+
+// sendMessageRequest is the request body for sendMessage API call.
+type sendMessageRequest struct {
+	ChatID          int64            `json:"chat_id"`
+	Text            string           `json:"text"`
+	ParseMode       string           `json:"parse_mode,omitempty"`
+	ReplyParameters *replyParameters `json:"reply_parameters,omitempty"`
+	ReplyMarkup     *ReplyMarkup     `json:"reply_markup,omitempty"`
+}
+
+// replyParameters identifies the message being replied to.
+type replyParameters struct {
+	MessageID int `json:"message_id"`
+}
+
+// sendMessageMaxAttempts bounds the sendMessage calls made by SendMessage.
+const sendMessageMaxAttempts = 3
+
+// sendRetryInitialDelay is the first retry delay for rejected sendMessage
+// calls; it doubles per attempt unless Telegram asks for a longer wait.
+const sendRetryInitialDelay = time.Second
+
+// SendMessage sends a text message and returns it as stored by Telegram.
+// Rate limiting (429) and server errors (5xx) are retried with backoff,
+// honoring retry_after. Network failures are not retried because the
+// message may already have been delivered.
+func SendMessage(ctx context.Context, botToken SecretToken, params SendMessageParams) (*Message, error) {
+	return SendMessageWithClient(ctx, defaultHTTPClient(), botToken, params)
+}
+
+// SendMessageWithClient sends a text message using a custom HTTP client.
+// Use this for testing or when you need custom HTTP configuration.
+func SendMessageWithClient(ctx context.Context, client httpClient, botToken SecretToken, params SendMessageParams) (*Message, error) {
+	return sendMessage(ctx, client, botToken, params, realClock{})
+}
+
+// sendMessage implements SendMessageWithClient with an injectable clock
+// for the retry waits.
+func sendMessage(ctx context.Context, client httpClient, botToken SecretToken, params SendMessageParams, clk clock) (*Message, error) {
+	req := sendMessageRequest{
+		ChatID:      params.ChatID,
+		Text:        params.Text,
+		ParseMode:   params.ParseMode,
+		ReplyMarkup: params.ReplyMarkup,
+	}
+	if params.ReplyToMessageID != 0 {
+		req.ReplyParameters = &replyParameters{MessageID: params.ReplyToMessageID}
+	}
+
+	policy := retryPolicy{
+		maxAttempts:  sendMessageMaxAttempts,
+		initialDelay: sendRetryInitialDelay,
+		retryable: func(err error) bool {
+			// Network failures are not retried: the message may have been sent
+			var apiErr *TelegramAPIError
+			return errors.As(err, &apiErr) && apiErr.IsRetryable()
+		},
+		clock: clk,
+	}
+	msg, err := retryCall(ctx, policy, func() (Message, error) {
+		return call[Message](ctx, client, botToken, "sendMessage", req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
@@ -0,0 +1,122 @@
+package telegramreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSendMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/sendMessage") {
+			t.Errorf("expected sendMessage in path, got %s", r.URL.Path)
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]any
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Fatalf("invalid request body: %v", err)
+		}
+		if req["chat_id"] != float64(42) || req["text"] != "*hi*" || req["parse_mode"] != "MarkdownV2" {
+			t.Errorf("unexpected request fields: %s", body)
+		}
+		if reply, _ := req["reply_parameters"].(map[string]any); reply["message_id"] != float64(7) {
+			t.Errorf("expected reply_parameters.message_id 7, got %s", body)
+		}
+		if markup, _ := req["reply_markup"].(map[string]any); markup["force_reply"] != true {
+			t.Errorf("expected force_reply markup, got %s", body)
+		}
+
+		w.Write([]byte(`{"ok":true,"result":{"message_id":8,"date":1700000000,"chat":{"id":42,"type":"private"},"text":"hi","entities":[{"type":"bold","offset":0,"length":2}],"reply_to_message":{"message_id":7,"date":1699999990,"chat":{"id":42,"type":"private"},"text":"ping"}}}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg, err := SendMessageWithClient(ctx, newTestAPIClient(server), SecretToken("test-token"), SendMessageParams{
+		ChatID:           42,
+		Text:             "*hi*",
+		ParseMode:        "MarkdownV2",
+		ReplyToMessageID: 7,
+		ReplyMarkup:      &ReplyMarkup{ForceReply: &ForceReply{ForceReply: true}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.MessageID != 8 || msg.Text != "hi" || len(msg.Entities) != 1 {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if msg.ReplyToMessage == nil || msg.ReplyToMessage.MessageID != 7 {
+		t.Errorf("expected reply to message 7, got %+v", msg.ReplyToMessage)
+	}
+}
+
+func TestSendMessage_OmitsUnsetFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := string(body); got != `{"chat_id":42,"text":"hi"}` {
+			t.Errorf("unexpected request body: %s", got)
+		}
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":1700000000,"chat":{"id":42,"type":"private"},"text":"hi"}}`))
+	}))
+	defer server.Close()
+
+	if _, err := SendMessageWithClient(context.Background(), newTestAPIClient(server), SecretToken("test-token"), SendMessageParams{ChatID: 42, Text: "hi"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSendMessage_Retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantCalls int32
+		wantWaits []time.Duration
+		wantErr   bool
+	}{
+		{"server error retried", http.StatusBadGateway, `{"ok":false,"error_code":502,"description":"Bad Gateway"}`, 2, []time.Duration{time.Second}, false},
+		{"retry_after honored", http.StatusTooManyRequests, `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":5}}`, 2, []time.Duration{5 * time.Second}, false},
+		{"bad request not retried", http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`, 1, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+					return
+				}
+				w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":1700000000,"chat":{"id":42,"type":"private"},"text":"hi"}}`))
+			}))
+			defer server.Close()
+
+			clk := newFakeClock()
+			_, err := sendMessage(context.Background(), newTestAPIClient(server), SecretToken("test-token"), SendMessageParams{ChatID: 42, Text: "hi"}, clk)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error result: %v", err)
+			}
+			var apiErr *TelegramAPIError
+			if tt.wantErr && (!errors.As(err, &apiErr) || apiErr.Code != tt.status) {
+				t.Errorf("expected TelegramAPIError %d, got %v", tt.status, err)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+			if got := clk.recorded(); !slices.Equal(got, tt.wantWaits) {
+				t.Errorf("retry waits = %v, want %v", got, tt.wantWaits)
+			}
+		})
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -14,47 +17,246 @@ import (
 // ServerState tracks the shutdown state for health endpoints.
 type ServerState struct {
 	isShuttingDown atomic.Bool
+
+	// State the handler had before the server attached this one; its
+	// shutdown is reported too
+	linked *ServerState
 }
 
 // IsShuttingDown returns true if the server is in shutdown mode.
 func (s *ServerState) IsShuttingDown() bool {
-	return s.isShuttingDown.Load()
+	return s.isShuttingDown.Load() || (s.linked != nil && s.linked.IsShuttingDown())
 }
 
 // StartWebhookServer starts the HTTPS webhook server with Kubernetes-aware
 // graceful shutdown. It wraps the handler with health endpoints:
 //   - /healthz - liveness probe (always 200 unless shutting down)
-//   - /readyz  - readiness probe (503 during shutdown drain)
+//   - /readyz  - readiness probe (503 during shutdown drain or while a
+//     WithReadinessCheck check of the WebhookHandler fails)
 //
 // If WebhookURL and BotToken are configured, it automatically registers
-// the webhook with Telegram before starting the server.
+// the webhook with Telegram before starting the server. Transient failures
+// (network errors, 429, 5xx) are retried with exponential backoff up to
+// WebhookRegisterMaxAttempts times. When WebhookVerifyTimeout is set, the
+// server then waits until getWebhookInfo reports no delivery error and
+// fails with ErrWebhookUnreachable if Telegram cannot reach it in time.
+//
+// StartWebhookServer blocks until ctx is cancelled; use NewWebhookServer
+// for a handle to shut the server down or read its address.
 //
 // Deprecated: Use New() or NewFromConfig() with WithMode(ModeWebhook) instead.
 // This function will be removed in v4.
 func StartWebhookServer(ctx context.Context, cfg *Config, handler http.Handler, logger *slog.Logger) error {
+	return startWebhookServer(ctx, cfg, handler, logger, defaultHTTPClient(), realClock{})
+}
+
+// startWebhookServer implements StartWebhookServer with an injectable
+// client for the Telegram API and clock for the drain delay.
+func startWebhookServer(ctx context.Context, cfg *Config, handler http.Handler, logger *slog.Logger, apiClient httpClient, clk clock) error {
+	s, err := NewWebhookServer(cfg, handler, logger)
+	if err != nil {
+		return err
+	}
+	s.apiClient = apiClient
+	s.clock = clk
+	return s.Start(ctx)
+}
+
+// WebhookServer is the HTTPS webhook server behind StartWebhookServer,
+// with the same health endpoints, webhook registration and shutdown
+// sequence, as a value that can be shut down programmatically and reports
+// the address it listens on. Create it with NewWebhookServer.
+type WebhookServer struct {
+	cfg       *Config
+	logger    *slog.Logger
+	apiClient httpClient
+	clock     clock
+
+	state  *ServerState
+	server *http.Server
+
+	started      atomic.Bool
+	addr         atomic.Pointer[string]
+	shutdownOnce sync.Once
+	shutdownErr  error
+}
+
+// NewWebhookServer validates cfg and prepares a server for handler
+// without listening yet. A WebhookPort of 0 listens on a free port,
+// reported by Addr once Start is listening.
+func NewWebhookServer(cfg *Config, handler http.Handler, logger *slog.Logger) (*WebhookServer, error) {
 	if err := validateConfig(cfg); err != nil {
 		logger.Error("Configuration validation failed", "error", err)
-		return err
+		return nil, err
 	}
 
+	state := &ServerState{}
+	if wh, ok := handler.(*WebhookHandler); ok {
+		// Optionally have the webhook handler reject new updates during the
+		// drain window, still honouring a state it already rejects on
+		if cfg.RejectOnShutdown {
+			state.linked = wh.state
+			WithServerState(state)(wh)
+		}
+		// A limit the handler was built with takes precedence
+		if cfg.MaxConcurrentRequests > 0 {
+			sem := make(chan struct{}, cfg.MaxConcurrentRequests)
+			wh.inflight.CompareAndSwap(nil, &sem)
+		}
+	}
+
+	server, err := newWebhookServer(cfg, newHealthMux(state, handler))
+	if err != nil {
+		logger.Error("Failed to configure webhook server", "error", err)
+		return nil, err
+	}
+
+	return &WebhookServer{
+		cfg:       cfg,
+		logger:    logger,
+		apiClient: defaultHTTPClient(),
+		clock:     realClock{},
+		state:     state,
+		server:    server,
+	}, nil
+}
+
+// Start registers the webhook when configured, listens and serves until
+// ctx is cancelled or Shutdown is called. On cancellation it runs the
+// shutdown sequence itself; after a Shutdown call it returns nil once the
+// server stopped accepting connections, while Shutdown may still be
+// draining. A server can only be started once.
+func (s *WebhookServer) Start(ctx context.Context) error {
+	if !s.started.CompareAndSwap(false, true) {
+		return errors.New("webhook server already started")
+	}
+	cfg, logger := s.cfg, s.logger
+
 	if err := ensureLogPath(cfg.LogFilePath); err != nil {
 		logger.Error("Failed to create log directory", "error", err)
 		return err
 	}
 
+	// Registration and verification share the startup deadline
+	startCtx := ctx
+	if cfg.StartupTimeout > 0 {
+		var cancel context.CancelFunc
+		startCtx, cancel = context.WithTimeout(ctx, cfg.StartupTimeout)
+		defer cancel()
+	}
+	startupErr := func(err error) error {
+		if ctx.Err() == nil && errors.Is(startCtx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w after %v: %w", ErrStartupTimeout, cfg.StartupTimeout, err)
+		}
+		return err
+	}
+
 	// Auto-register webhook if URL and bot token are provided
-	if cfg.WebhookURL != "" && cfg.BotToken.Value() != "" {
+	autoRegister := cfg.WebhookURL != "" && cfg.BotToken.Value() != ""
+	if autoRegister {
 		logger.Info("Registering webhook with Telegram", "url", cfg.WebhookURL)
-		if err := SetWebhook(ctx, cfg.BotToken, cfg.WebhookURL, cfg.WebhookSecret); err != nil {
+		if err := registerWebhook(startCtx, s.apiClient, s.clock, cfg, logger); err != nil {
 			logger.Error("Failed to register webhook", "error", err)
-			return fmt.Errorf("failed to register webhook: %w", err)
+			return fmt.Errorf("failed to register webhook: %w", startupErr(err))
 		}
 		logger.Info("Webhook registered successfully")
 	}
 
-	state := &ServerState{}
+	ln, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		logger.Error("Failed to listen", "addr", s.server.Addr, "error", err)
+		return err
+	}
+	addr := ln.Addr().String()
+	s.addr.Store(&addr)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("Webhook server starting", "addr", addr)
+		certPath, keyPath := cfg.TLSCertPath, cfg.TLSKeyPath
+		if s.server.TLSConfig.GetCertificate != nil {
+			// Certificates were loaded for SNI selection
+			certPath, keyPath = "", ""
+		}
+		serveErr <- s.server.ServeTLS(ln, certPath, keyPath)
+	}()
+
+	// Telegram can only confirm delivery once this instance is listening
+	if autoRegister && cfg.WebhookVerifyTimeout > 0 {
+		if err := verifyWebhook(startCtx, s.apiClient, s.clock, cfg, logger); err != nil && ctx.Err() == nil {
+			logger.Error("Failed to verify webhook", "error", err)
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+			defer cancel()
+			s.server.Shutdown(shutdownCtx)
+			return fmt.Errorf("failed to verify webhook: %w", startupErr(err))
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return s.Shutdown(context.WithoutCancel(ctx))
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		logger.Error("Webhook server error", "error", err)
+		return err
+	}
+}
+
+// Addr returns the address the server listens on, such as "[::]:8443",
+// or "" before Start is listening.
+func (s *WebhookServer) Addr() string {
+	if addr := s.addr.Load(); addr != nil {
+		return *addr
+	}
+	return ""
+}
+
+// Shutdown runs the Kubernetes-aware shutdown sequence: health endpoints
+// report 503, the drain delay lets load balancers stop routing to this
+// instance, then open connections are drained. ctx bounds the drain delay
+// and the connection drain, which defaults to ShutdownTimeout when ctx has
+// no deadline. Later calls return the result of the first.
+func (s *WebhookServer) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		cfg, logger := s.cfg, s.logger
+
+		// Kubernetes-aware shutdown sequence:
+		// 1. Mark as shutting down (health endpoints return 503)
+		// 2. Wait for drain delay (allows LB to stop routing new requests)
+		// 3. Gracefully shutdown (drain existing connections)
+		s.state.isShuttingDown.Store(true)
+		logger.Info("Shutdown initiated, starting drain delay", "delay", cfg.DrainDelay)
+
+		select {
+		case <-ctx.Done():
+			logger.Warn("Drain delay cut short", "error", ctx.Err())
+		case <-s.clock.After(cfg.DrainDelay):
+			logger.Info("Drain delay complete, shutting down server")
+		}
+
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.ShutdownTimeout)
+			defer cancel()
+		}
+
+		if err := s.server.Shutdown(ctx); err != nil {
+			logger.Error("Graceful shutdown failed", "error", err)
+			s.shutdownErr = err
+			return
+		}
+		logger.Info("Webhook server stopped gracefully")
+	})
+	return s.shutdownErr
+}
+
+// newHealthMux wraps handler with the /healthz and /readyz endpoints.
+// Readiness also reflects the checks of a *WebhookHandler.
+func newHealthMux(state *ServerState, handler http.Handler) *http.ServeMux {
+	wh, _ := handler.(*WebhookHandler)
 
-	// Wrap handler with health endpoints
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		if state.isShuttingDown.Load() {
@@ -69,56 +271,218 @@ func StartWebhookServer(ctx context.Context, cfg *Config, handler http.Handler,
 			http.Error(w, "shutting down", http.StatusServiceUnavailable)
 			return
 		}
+		if wh != nil {
+			if err := wh.Ready(); err != nil {
+				http.Error(w, "not ready: "+err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
 	mux.Handle("/", handler)
+	return mux
+}
 
-	server := &http.Server{
+// newWebhookServer builds the HTTPS server for StartWebhookServer from cfg,
+// applying secure defaults for unset hardening fields. With TLSClientCAs
+// set, clients must present a certificate signed by one of those CAs. With
+// TLSSNICerts set, all certificates are loaded here and chosen per
+// handshake by server name.
+func newWebhookServer(cfg *Config, handler http.Handler) (*http.Server, error) {
+	maxHeaderBytes := cfg.MaxHeaderBytes
+	if maxHeaderBytes == 0 {
+		maxHeaderBytes = 1 << 20 // 1 MB
+	}
+	minVersion := cfg.TLSMinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cfg.CipherSuites,
+		CurvePreferences: []tls.CurveID{
+			tls.X25519,    // Fast, secure, preferred
+			tls.CurveP256, // Wide compatibility fallback
+		},
+	}
+	if len(cfg.TLSClientCAs) > 0 {
+		pool, err := clientCAPool(cfg.TLSClientCAs)
+		if err != nil {
+			return nil, fmt.Errorf("client CAs: %w", err)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = cfg.TLSClientAuth
+		if tlsConfig.ClientAuth == tls.NoClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	if len(cfg.TLSSNICerts) > 0 {
+		pairs := append([]TLSCertKeyPair{{CertPath: cfg.TLSCertPath, KeyPath: cfg.TLSKeyPath}}, cfg.TLSSNICerts...)
+		certs := make([]tls.Certificate, 0, len(pairs))
+		for _, pair := range pairs {
+			cert, err := tls.LoadX509KeyPair(pair.CertPath, pair.KeyPath)
+			if err != nil {
+				return nil, fmt.Errorf("loading certificate %s: %w", pair.CertPath, err)
+			}
+			certs = append(certs, cert)
+		}
+		// Clients without SNI get the default certificate
+		tlsConfig.Certificates = certs[:1]
+		tlsConfig.GetCertificate = sniCertificate(certs)
+	}
+
+	return &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.WebhookPort),
-		Handler:           mux,
+		Handler:           handler,
 		ReadTimeout:       cfg.ReadTimeout,
 		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
 		WriteTimeout:      cfg.WriteTimeout,
 		IdleTimeout:       cfg.IdleTimeout,
-		MaxHeaderBytes:    1 << 20, // 1 MB
-		TLSConfig: &tls.Config{
-			MinVersion: tls.VersionTLS12,
-			CurvePreferences: []tls.CurveID{
-				tls.X25519,    // Fast, secure, preferred
-				tls.CurveP256, // Wide compatibility fallback
-			},
-		},
-	}
+		MaxHeaderBytes:    maxHeaderBytes,
+		TLSConfig:         tlsConfig,
+	}, nil
+}
 
-	go func() {
-		logger.Info("Webhook server starting", "port", cfg.WebhookPort)
-		if err := server.ListenAndServeTLS(cfg.TLSCertPath, cfg.TLSKeyPath); !errors.Is(err, http.ErrServerClosed) {
-			logger.Error("Webhook server error", "error", err)
+// sniCertificate returns a tls.Config.GetCertificate callback choosing the
+// certificate whose DNS names match the SNI server name exactly, then by
+// wildcard, falling back to the first certificate.
+func sniCertificate(certs []tls.Certificate) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	byName := make(map[string]*tls.Certificate)
+	for i := range certs {
+		if certs[i].Leaf == nil {
+			continue
 		}
-	}()
+		for _, name := range certs[i].Leaf.DNSNames {
+			name = strings.ToLower(name)
+			if _, ok := byName[name]; !ok {
+				byName[name] = &certs[i]
+			}
+		}
+	}
 
-	<-ctx.Done()
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		name := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+		if cert, ok := byName[name]; ok {
+			return cert, nil
+		}
+		if _, parent, ok := strings.Cut(name, "."); ok {
+			if cert, ok := byName["*."+parent]; ok {
+				return cert, nil
+			}
+		}
+		return &certs[0], nil
+	}
+}
 
-	// Kubernetes-aware shutdown sequence:
-	// 1. Mark as shutting down (health endpoints return 503)
-	// 2. Wait for drain delay (allows LB to stop routing new requests)
-	// 3. Gracefully shutdown (drain existing connections)
-	state.isShuttingDown.Store(true)
-	logger.Info("Shutdown initiated, starting drain delay", "delay", cfg.DrainDelay)
+// registerWebhook calls setWebhook, retrying transient failures with
+// exponential backoff. A longer retry_after from Telegram takes precedence
+// over the computed delay. Waits use clk.
+func registerWebhook(ctx context.Context, client httpClient, clk clock, cfg *Config, logger *slog.Logger) error {
+	if cfg.WebhookSkipRedundantRegistration {
+		info, err := GetWebhookInfoWithClient(ctx, client, cfg.BotToken)
+		switch {
+		case err != nil:
+			logger.Warn("Failed to read current webhook, registering anyway", "error", err)
+		case webhookMatches(info, cfg):
+			logger.Info("Webhook already registered with the same settings, skipping setWebhook",
+				"pending_update_count", info.PendingUpdateCount,
+			)
+			return nil
+		}
+	}
 
-	time.Sleep(cfg.DrainDelay)
+	policy := retryPolicy{
+		maxAttempts:  cfg.WebhookRegisterMaxAttempts,
+		initialDelay: cfg.WebhookRegisterInitialDelay,
+		maxDelay:     cfg.WebhookRegisterMaxDelay,
+		retryable:    isTransientAPIError,
+		clock:        clk,
+		onRetry: func(err error, attempt int, wait time.Duration) {
+			logger.Warn("Webhook registration failed, retrying",
+				"error", err,
+				"attempt", attempt,
+				"max_attempts", max(cfg.WebhookRegisterMaxAttempts, 1),
+				"retry_delay", wait,
+			)
+		},
+	}
+	_, err := retryCall(ctx, policy, func() (bool, error) {
+		return call[bool](ctx, client, cfg.BotToken, "setWebhook", webhookRequest(cfg))
+	})
+	return err
+}
 
-	logger.Info("Drain delay complete, shutting down server")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+// verifyWebhook polls getWebhookInfo until Telegram reports no delivery
+// error for the webhook or cfg.WebhookVerifyTimeout elapses on clk.
+// Hosting providers often answer 502 for a few seconds after a deploy, so
+// a reported error is only fatal if it persists.
+func verifyWebhook(ctx context.Context, client httpClient, clk clock, cfg *Config, logger *slog.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.WebhookVerifyTimeout) // bounds the API calls
 	defer cancel()
+	deadline := clk.Now().Add(cfg.WebhookVerifyTimeout)
 
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		logger.Error("Graceful shutdown failed", "error", err)
-		return err
+	interval := cfg.WebhookVerifyInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	var lastErr string
+	timedOut := func() error {
+		if lastErr != "" {
+			return fmt.Errorf("%w after %v: %s", ErrWebhookUnreachable, cfg.WebhookVerifyTimeout, lastErr)
+		}
+		return fmt.Errorf("webhook verification: %w", context.DeadlineExceeded)
+	}
+	for {
+		info, err := GetWebhookInfoWithClient(ctx, client, cfg.BotToken)
+		switch {
+		case err != nil && !isTransientAPIError(err):
+			return err
+		case err != nil:
+			logger.Warn("Failed to read webhook info, retrying", "error", err)
+		case info.LastErrorMessage == "":
+			logger.Info("Webhook verified", "pending_update_count", info.PendingUpdateCount)
+			return nil
+		default:
+			lastErr = info.LastErrorMessage
+			logger.Warn("Telegram reports a webhook delivery error, waiting",
+				"last_error_message", info.LastErrorMessage,
+				"last_error_date", info.LastErrorDate,
+			)
+		}
+
+		remaining := deadline.Sub(clk.Now())
+		if remaining <= 0 {
+			return timedOut()
+		}
+		select {
+		case <-ctx.Done():
+			if lastErr != "" {
+				return timedOut()
+			}
+			return fmt.Errorf("webhook verification: %w", ctx.Err())
+		case <-clk.After(min(interval, remaining)):
+		}
+	}
+}
+
+// webhookMatches reports whether setWebhook with cfg would change nothing
+// Telegram reports (see WebhookDiff).
+func webhookMatches(info *WebhookInfo, cfg *Config) bool {
+	return len(diffWebhook(info, cfg)) == 0
+}
+
+// isTransientAPIError reports whether a failed API call is worth retrying:
+// network failures plus the codes accepted by TelegramAPIError.IsRetryable.
+func isTransientAPIError(err error) bool {
+	var apiErr *TelegramAPIError
+	if !errors.As(err, &apiErr) {
+		return true
 	}
-	logger.Info("Webhook server stopped gracefully")
-	return nil
+	return apiErr.Code == 0 || apiErr.IsRetryable()
 }
 
 // StartLongPolling creates and starts a long polling client.
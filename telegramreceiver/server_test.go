@@ -0,0 +1,826 @@
+package telegramreceiver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 and the
+// given DNS names and returns the certificate and key paths.
+func writeTestCert(t *testing.T, dnsNames ...string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshalling key: %v", err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+// freePort returns a TCP port that is currently unused.
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("finding free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestStartWebhookServer_RetriesRegistration(t *testing.T) {
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer api.Close()
+
+	certPath, keyPath := writeTestCert(t)
+	port := freePort(t)
+	cfg := &Config{
+		ReceiverMode:                ModeWebhook,
+		BotToken:                    SecretToken(testBotToken),
+		WebhookPort:                 port,
+		TLSCertPath:                 certPath,
+		TLSKeyPath:                  keyPath,
+		WebhookURL:                  "https://example.com/webhook",
+		WebhookRegisterMaxAttempts:  3,
+		WebhookRegisterInitialDelay: 10 * time.Millisecond,
+		WebhookRegisterMaxDelay:     50 * time.Millisecond,
+		LogFilePath:                 filepath.Join(t.TempDir(), "test.log"),
+		DrainDelay:                  time.Hour, // skipped by the fake clock
+		ShutdownTimeout:             time.Second,
+	}
+
+	clk := newFakeClock()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- startWebhookServer(ctx, cfg, http.NotFoundHandler(), newTestLogger(), newTestAPIClient(api), clk)
+	}()
+
+	client := &http.Client{
+		Timeout:   time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	healthURL := fmt.Sprintf("https://127.0.0.1:%d/healthz", port)
+
+	var listening bool
+	deadline := time.Now().Add(5 * time.Second)
+	for !listening && time.Now().Before(deadline) {
+		select {
+		case err := <-done:
+			t.Fatalf("server returned early: %v", err)
+		default:
+		}
+		if resp, err := client.Get(healthURL); err == nil {
+			resp.Body.Close()
+			listening = resp.StatusCode == http.StatusOK
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if !listening {
+		t.Fatal("server did not start listening after registration retry")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 setWebhook calls, got %d", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+	// One registration backoff, then the drain delay, both on the fake clock.
+	if waits := clk.recorded(); len(waits) != 2 || waits[0] != 10*time.Millisecond || waits[1] != time.Hour {
+		t.Errorf("expected waits [10ms 1h], got %v", waits)
+	}
+}
+
+func TestStartWebhookServer_StartupTimeout(t *testing.T) {
+	release := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // hang until the test ends
+	}))
+	defer api.Close()
+	defer close(release)
+
+	certPath, keyPath := writeTestCert(t)
+	cfg := &Config{
+		ReceiverMode:               ModeWebhook,
+		BotToken:                   SecretToken(testBotToken),
+		WebhookPort:                freePort(t),
+		TLSCertPath:                certPath,
+		TLSKeyPath:                 keyPath,
+		WebhookURL:                 "https://example.com/webhook",
+		WebhookRegisterMaxAttempts: 5,
+		StartupTimeout:             100 * time.Millisecond,
+		LogFilePath:                filepath.Join(t.TempDir(), "test.log"),
+		ShutdownTimeout:            time.Second,
+	}
+
+	start := time.Now()
+	err := startWebhookServer(context.Background(), cfg, http.NotFoundHandler(), newTestLogger(), newTestAPIClient(api), newFakeClock())
+	if !errors.Is(err, ErrStartupTimeout) {
+		t.Fatalf("expected ErrStartupTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Start took %v, expected to return near the 100ms timeout", elapsed)
+	}
+}
+
+func TestNewWebhookServer_MaxConcurrentRequests(t *testing.T) {
+	certPath, keyPath := writeTestCert(t)
+	cfg := &Config{
+		ReceiverMode:          ModeWebhook,
+		TLSCertPath:           certPath,
+		TLSKeyPath:            keyPath,
+		LogFilePath:           filepath.Join(t.TempDir(), "test.log"),
+		ShutdownTimeout:       time.Second,
+		MaxConcurrentRequests: 5,
+	}
+
+	tests := []struct {
+		name    string
+		opts    []WebhookOption
+		wantCap int
+	}{
+		{"handler without a limit", nil, 5},
+		{"handler with its own limit", []WebhookOption{WithMaxConcurrentRequests(1)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(make(chan TelegramUpdate, 1), tt.opts...)
+			if _, err := NewWebhookServer(cfg, handler, newTestLogger()); err != nil {
+				t.Fatalf("NewWebhookServer: %v", err)
+			}
+			sem := handler.inflight.Load()
+			if sem == nil || cap(*sem) != tt.wantCap {
+				t.Errorf("expected a limit of %d", tt.wantCap)
+			}
+		})
+	}
+}
+
+func TestNewWebhookServer_RejectOnShutdownKeepsHandlerState(t *testing.T) {
+	certPath, keyPath := writeTestCert(t)
+	cfg := &Config{
+		ReceiverMode:     ModeWebhook,
+		TLSCertPath:      certPath,
+		TLSKeyPath:       keyPath,
+		LogFilePath:      filepath.Join(t.TempDir(), "test.log"),
+		ShutdownTimeout:  time.Second,
+		RejectOnShutdown: true,
+	}
+
+	own := &ServerState{}
+	handler := newTestHandler(make(chan TelegramUpdate, 1), WithServerState(own))
+	s, err := NewWebhookServer(cfg, handler, newTestLogger())
+	if err != nil {
+		t.Fatalf("NewWebhookServer: %v", err)
+	}
+	if handler.state.IsShuttingDown() {
+		t.Fatal("handler rejects before any shutdown")
+	}
+
+	own.isShuttingDown.Store(true)
+	if !handler.state.IsShuttingDown() {
+		t.Error("handler stopped rejecting on its own state")
+	}
+	if s.state.isShuttingDown.Load() {
+		t.Error("handler state marked the server as shutting down")
+	}
+
+	own.isShuttingDown.Store(false)
+	s.state.isShuttingDown.Store(true)
+	if !handler.state.IsShuttingDown() {
+		t.Error("handler does not reject on the server state")
+	}
+}
+
+func TestWebhookServer_AddrAndShutdown(t *testing.T) {
+	certPath, keyPath := writeTestCert(t)
+	cfg := &Config{
+		ReceiverMode:    ModeWebhook,
+		WebhookPort:     0,
+		TLSCertPath:     certPath,
+		TLSKeyPath:      keyPath,
+		LogFilePath:     filepath.Join(t.TempDir(), "test.log"),
+		ShutdownTimeout: time.Second,
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("update"))
+	})
+
+	srv, err := NewWebhookServer(cfg, handler, newTestLogger())
+	if err != nil {
+		t.Fatalf("NewWebhookServer: %v", err)
+	}
+	if srv.Addr() != "" {
+		t.Errorf("expected no address before Start, got %q", srv.Addr())
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(context.Background()) }()
+	waitFor(t, "server to listen", func() bool { return srv.Addr() != "" })
+
+	_, port, err := net.SplitHostPort(srv.Addr())
+	if err != nil || port == "0" {
+		t.Fatalf("expected a bound port, got %q (%v)", srv.Addr(), err)
+	}
+	client := &http.Client{
+		Timeout:   time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	resp, err := client.Post("https://127.0.0.1:"+port+"/", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "update" {
+		t.Errorf("expected the handler response, got %q", body)
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start returned %v after Shutdown", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after Shutdown")
+	}
+	if _, err := client.Get("https://127.0.0.1:" + port + "/healthz"); err == nil {
+		t.Error("expected the server to stop listening")
+	}
+	if err := srv.Start(context.Background()); err == nil {
+		t.Error("expected an error when starting twice")
+	}
+}
+
+func TestWebhookServer_ShutdownDeadlineBeforeDrainDelay(t *testing.T) {
+	certPath, keyPath := writeTestCert(t)
+	cfg := &Config{
+		ReceiverMode:    ModeWebhook,
+		WebhookPort:     0,
+		TLSCertPath:     certPath,
+		TLSKeyPath:      keyPath,
+		LogFilePath:     filepath.Join(t.TempDir(), "test.log"),
+		ShutdownTimeout: time.Second,
+		DrainDelay:      time.Hour,
+	}
+	srv, err := NewWebhookServer(cfg, http.NotFoundHandler(), newTestLogger())
+	if err != nil {
+		t.Fatalf("NewWebhookServer: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Start(context.Background()) }()
+	waitFor(t, "server to listen", func() bool { return srv.Addr() != "" })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(ctx) }()
+
+	select {
+	case err := <-shutdown:
+		// Without open connections the server still stops cleanly
+		if err != nil {
+			t.Errorf("Shutdown: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown waited out the drain delay past its deadline")
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after Shutdown")
+	}
+}
+
+func TestRegisterWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		maxAttempts int
+		wantCalls   int32
+		wantWaits   []time.Duration
+	}{
+		{"permanent error is not retried", 400, `{"ok":false,"error_code":400,"description":"Bad Request: bad webhook"}`, 5, 1, nil},
+		{"server error retried until max attempts", 502, `bad gateway`, 4, 4, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}},
+		{"zero attempts means a single call", 500, `{"ok":false,"error_code":500,"description":"Internal"}`, 0, 1, nil},
+		{"retry_after overrides backoff", 429, `{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":30}}`, 2, 2, []time.Duration{30 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer api.Close()
+
+			cfg := &Config{
+				BotToken:                    SecretToken(testBotToken),
+				WebhookURL:                  "https://example.com/webhook",
+				WebhookRegisterMaxAttempts:  tt.maxAttempts,
+				WebhookRegisterInitialDelay: time.Second,
+				WebhookRegisterMaxDelay:     3 * time.Second,
+			}
+			clk := newFakeClock()
+			err := registerWebhook(context.Background(), newTestAPIClient(api), clk, cfg, newTestLogger())
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, got)
+			}
+			if waits := clk.recorded(); !slices.Equal(waits, tt.wantWaits) {
+				t.Errorf("expected waits %v, got %v", tt.wantWaits, waits)
+			}
+		})
+	}
+}
+
+func TestRegisterWebhook_ContextCancelled(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests","parameters":{"retry_after":60}}`))
+	}))
+	defer api.Close()
+
+	cfg := &Config{
+		BotToken:                    SecretToken(testBotToken),
+		WebhookURL:                  "https://example.com/webhook",
+		WebhookRegisterMaxAttempts:  5,
+		WebhookRegisterInitialDelay: time.Millisecond,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := registerWebhook(ctx, newTestAPIClient(api), realClock{}, cfg, newTestLogger())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("retry_after wait did not honor cancellation (took %v)", elapsed)
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		errorCalls int32 // getWebhookInfo calls reporting a delivery error
+		wantErr    bool
+		wantWaits  int
+	}{
+		{"error clears", 2, false, 2},
+		{"error persists", 1000, true, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.errorCalls {
+					w.Write([]byte(`{"ok":true,"result":{"url":"https://example.com/webhook","pending_update_count":1,"last_error_date":1700000000,"last_error_message":"Wrong response from the webhook: 502 Bad Gateway"}}`))
+					return
+				}
+				w.Write([]byte(`{"ok":true,"result":{"url":"https://example.com/webhook","pending_update_count":0}}`))
+			}))
+			defer api.Close()
+
+			cfg := &Config{
+				BotToken:              SecretToken(testBotToken),
+				WebhookURL:            "https://example.com/webhook",
+				WebhookVerifyTimeout:  10 * time.Second,
+				WebhookVerifyInterval: 2 * time.Second,
+			}
+			clk := newFakeClock()
+			err := verifyWebhook(context.Background(), newTestAPIClient(api), clk, cfg, newTestLogger())
+
+			waits := clk.recorded()
+			if len(waits) != tt.wantWaits {
+				t.Errorf("expected %d waits, got %v", tt.wantWaits, waits)
+			}
+			for _, w := range waits {
+				if w != 2*time.Second {
+					t.Errorf("expected 2s waits, got %v", waits)
+					break
+				}
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := calls.Load(); got != tt.errorCalls+1 {
+					t.Errorf("expected %d getWebhookInfo calls, got %d", tt.errorCalls+1, got)
+				}
+				return
+			}
+			if !errors.Is(err, ErrWebhookUnreachable) {
+				t.Fatalf("expected ErrWebhookUnreachable, got %v", err)
+			}
+			if !strings.Contains(err.Error(), "502 Bad Gateway") {
+				t.Errorf("expected Telegram's error message in %q", err)
+			}
+		})
+	}
+}
+
+func TestRegisterWebhook_SkipRedundant(t *testing.T) {
+	tests := []struct {
+		name           string
+		registeredURL  string
+		registeredArgs string
+		allowed        []string
+		wantSet        bool
+	}{
+		{"matching URL", "https://example.com/webhook", `[]`, nil, false},
+		{"matching URL and allowed updates", "https://example.com/webhook", `["poll","message"]`, []string{"message", "poll"}, false},
+		{"different URL", "https://old.example.com/webhook", `[]`, nil, true},
+		{"different allowed updates", "https://example.com/webhook", `["message"]`, []string{"message", "poll"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var setCalls atomic.Int32
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch filepath.Base(r.URL.Path) {
+				case "getWebhookInfo":
+					fmt.Fprintf(w, `{"ok":true,"result":{"url":%q,"pending_update_count":3,"allowed_updates":%s}}`, tt.registeredURL, tt.registeredArgs)
+				case "setWebhook":
+					setCalls.Add(1)
+					w.Write([]byte(`{"ok":true,"result":true}`))
+				default:
+					t.Errorf("unexpected call %s", r.URL.Path)
+				}
+			}))
+			defer api.Close()
+
+			cfg := &Config{
+				BotToken:                         SecretToken(testBotToken),
+				WebhookURL:                       "https://example.com/webhook",
+				AllowedUpdates:                   tt.allowed,
+				WebhookSkipRedundantRegistration: true,
+			}
+			if err := registerWebhook(context.Background(), newTestAPIClient(api), realClock{}, cfg, newTestLogger()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotSet := setCalls.Load() > 0; gotSet != tt.wantSet {
+				t.Errorf("setWebhook called = %v, want %v", gotSet, tt.wantSet)
+			}
+		})
+	}
+}
+
+func TestRegisterWebhook_SkipRedundantInfoFailure(t *testing.T) {
+	var setCalls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if filepath.Base(r.URL.Path) == "getWebhookInfo" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		setCalls.Add(1)
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer api.Close()
+
+	cfg := &Config{
+		BotToken:                         SecretToken(testBotToken),
+		WebhookURL:                       "https://example.com/webhook",
+		WebhookSkipRedundantRegistration: true,
+	}
+	if err := registerWebhook(context.Background(), newTestAPIClient(api), realClock{}, cfg, newTestLogger()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if setCalls.Load() != 1 {
+		t.Errorf("expected setWebhook fallback call, got %d", setCalls.Load())
+	}
+}
+
+func TestHealthMux_ReadinessCheck(t *testing.T) {
+	var dbErr atomic.Pointer[error]
+	handler := newTestHandler(make(chan TelegramUpdate, 1), WithReadinessCheck(func() error {
+		if p := dbErr.Load(); p != nil {
+			return *p
+		}
+		return nil
+	}))
+	state := &ServerState{}
+	mux := newHealthMux(state, handler)
+
+	probe := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, _ := probe("/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz with passing check: expected 200, got %d", code)
+	}
+
+	down := errors.New("database unreachable")
+	dbErr.Store(&down)
+	if code, body := probe("/readyz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "database unreachable") {
+		t.Errorf("/readyz with failing check: got %d %q", code, body)
+	}
+	if code, _ := probe("/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz must ignore readiness checks, got %d", code)
+	}
+
+	dbErr.Store(nil)
+	state.isShuttingDown.Store(true)
+	if code, _ := probe("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz during shutdown: expected 503, got %d", code)
+	}
+}
+
+func TestNewWebhookServer_Hardening(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		srv, err := newWebhookServer(&Config{WebhookPort: 8443}, http.NotFoundHandler())
+		if err != nil {
+			t.Fatalf("newWebhookServer: %v", err)
+		}
+		if srv.MaxHeaderBytes != 1<<20 {
+			t.Errorf("MaxHeaderBytes = %d, want %d", srv.MaxHeaderBytes, 1<<20)
+		}
+		if srv.TLSConfig.MinVersion != tls.VersionTLS12 {
+			t.Errorf("MinVersion = %x, want TLS 1.2", srv.TLSConfig.MinVersion)
+		}
+		if srv.TLSConfig.CipherSuites != nil {
+			t.Errorf("expected Go default cipher suites, got %v", srv.TLSConfig.CipherSuites)
+		}
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		suites := []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384}
+		srv, err := newWebhookServer(&Config{
+			WebhookPort:    9443,
+			MaxHeaderBytes: 8 << 10,
+			TLSMinVersion:  tls.VersionTLS13,
+			CipherSuites:   suites,
+		}, http.NotFoundHandler())
+		if err != nil {
+			t.Fatalf("newWebhookServer: %v", err)
+		}
+
+		if srv.Addr != ":9443" {
+			t.Errorf("Addr = %q, want :9443", srv.Addr)
+		}
+		if srv.MaxHeaderBytes != 8<<10 {
+			t.Errorf("MaxHeaderBytes = %d, want %d", srv.MaxHeaderBytes, 8<<10)
+		}
+		if srv.TLSConfig.MinVersion != tls.VersionTLS13 {
+			t.Errorf("MinVersion = %x, want TLS 1.3", srv.TLSConfig.MinVersion)
+		}
+		if len(srv.TLSConfig.CipherSuites) != 2 || srv.TLSConfig.CipherSuites[0] != suites[0] {
+			t.Errorf("CipherSuites = %v, want %v", srv.TLSConfig.CipherSuites, suites)
+		}
+	})
+}
+
+// newTestCA returns a CA certificate and a client certificate it signed.
+func newTestCA(t *testing.T, name string) ([]byte, tls.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating CA key: %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("creating CA certificate: %v", err)
+	}
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating client key: %v", err)
+	}
+	clientTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name + " proxy"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTmpl, caTmpl, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("creating client certificate: %v", err)
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	return caPEM, tls.Certificate{Certificate: [][]byte{clientDER}, PrivateKey: clientKey}
+}
+
+func TestNewWebhookServer_ClientCertificates(t *testing.T) {
+	caPEM, trusted := newTestCA(t, "proxy CA")
+	_, untrusted := newTestCA(t, "rogue CA")
+
+	srv, err := newWebhookServer(&Config{WebhookPort: 8443, TLSClientCAs: caPEM}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("newWebhookServer: %v", err)
+	}
+	if srv.TLSConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("ClientAuth = %v, want RequireAndVerifyClientCert", srv.TLSConfig.ClientAuth)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = srv.TLSConfig
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(certs ...tls.Certificate) error {
+		// A fresh transport per request so no verified connection is reused
+		transport := server.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.Certificates = certs
+		defer transport.CloseIdleConnections()
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if err := get(trusted); err != nil {
+		t.Errorf("trusted client certificate rejected: %v", err)
+	}
+	if err := get(untrusted); err == nil {
+		t.Error("expected an untrusted client certificate to be rejected")
+	}
+	if err := get(); err == nil {
+		t.Error("expected a request without client certificate to be rejected")
+	}
+}
+
+func TestNewWebhookServer_SNICertificates(t *testing.T) {
+	defaultCert, defaultKey := writeTestCert(t, "bot.example.com")
+	aCert, aKey := writeTestCert(t, "a.example.com")
+	bCert, bKey := writeTestCert(t, "*.b.example.com")
+
+	srv, err := newWebhookServer(&Config{
+		WebhookPort: 8443,
+		TLSCertPath: defaultCert,
+		TLSKeyPath:  defaultKey,
+		TLSSNICerts: []TLSCertKeyPair{
+			{CertPath: aCert, KeyPath: aKey},
+			{CertPath: bCert, KeyPath: bKey},
+		},
+	}, http.NotFoundHandler())
+	if err != nil {
+		t.Fatalf("newWebhookServer: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = srv.TLSConfig
+	server.StartTLS()
+	defer server.Close()
+
+	tests := []struct {
+		serverName string
+		want       string
+	}{
+		{"a.example.com", "a.example.com"},
+		{"A.Example.COM", "a.example.com"},
+		{"hook.b.example.com", "*.b.example.com"},
+		{"unknown.example.org", "bot.example.com"},
+		{"", "bot.example.com"},
+	}
+	for _, tt := range tests {
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{
+			ServerName:         tt.serverName,
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			t.Fatalf("handshake with SNI %q: %v", tt.serverName, err)
+		}
+		got := conn.ConnectionState().PeerCertificates[0].DNSNames
+		conn.Close()
+		if len(got) != 1 || got[0] != tt.want {
+			t.Errorf("SNI %q: got certificate for %v, want %s", tt.serverName, got, tt.want)
+		}
+	}
+
+	if _, err := newWebhookServer(&Config{
+		TLSCertPath: defaultCert,
+		TLSKeyPath:  defaultKey,
+		TLSSNICerts: []TLSCertKeyPair{{CertPath: aCert, KeyPath: "missing.key"}},
+	}, http.NotFoundHandler()); err == nil {
+		t.Error("expected an error for an unreadable SNI key")
+	}
+}
+
+func TestValidateWebhookConfig_ClientCAs(t *testing.T) {
+	base := Config{
+		ReceiverMode: ModeWebhook,
+		WebhookPort:  8443,
+		TLSCertPath:  "cert.pem",
+		TLSKeyPath:   "key.pem",
+		LogFilePath:  "logs/test.log",
+	}
+
+	invalid := base
+	invalid.TLSClientCAs = []byte("not a certificate")
+	if err := validateConfig(&invalid); err == nil || !strings.Contains(err.Error(), "TLS_CLIENT_CA_PATH") {
+		t.Errorf("expected TLS_CLIENT_CA_PATH error for invalid PEM, got %v", err)
+	}
+
+	// Verification without CAs would silently trust the system roots
+	missing := base
+	missing.TLSClientAuth = tls.RequireAndVerifyClientCert
+	if err := validateConfig(&missing); err == nil || !strings.Contains(err.Error(), "TLS_CLIENT_CA_PATH") {
+		t.Errorf("expected TLS_CLIENT_CA_PATH error without CAs, got %v", err)
+	}
+}
+
+func TestValidateWebhookConfig_TLSMinVersion(t *testing.T) {
+	cfg := &Config{
+		ReceiverMode:  ModeWebhook,
+		WebhookPort:   8443,
+		TLSCertPath:   "cert.pem",
+		TLSKeyPath:    "key.pem",
+		LogFilePath:   "logs/test.log",
+		TLSMinVersion: tls.VersionTLS11,
+	}
+	if err := validateConfig(cfg); err == nil || !strings.Contains(err.Error(), "TLS_MIN_VERSION") {
+		t.Errorf("expected TLS_MIN_VERSION error, got %v", err)
+	}
+
+	cfg.TLSMinVersion = tls.VersionTLS13
+	if err := validateConfig(cfg); err != nil {
+		t.Errorf("unexpected error for TLS 1.3: %v", err)
+	}
+}
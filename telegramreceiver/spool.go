@@ -0,0 +1,122 @@
+package telegramreceiver
+
+import (
+	"sync"
+)
+
+// SpoolStats reports the state of the update spool.
+type SpoolStats struct {
+	Capacity  int    // Maximum number of spooled updates
+	Depth     int    // Updates currently waiting in the spool
+	HighWater int    // Largest depth observed since the client was created
+	Dropped   uint64 // Updates dropped because the spool was full
+}
+
+// spool is a bounded FIFO between the receivers and the Updates() channel.
+// It absorbs bursts while the consumer is stalled and replays them in
+// order once the channel drains.
+type spool struct {
+	in  chan TelegramUpdate // Receivers write here
+	out chan TelegramUpdate // Consumer reads here
+
+	mu        sync.Mutex
+	ring      []TelegramUpdate
+	head      int
+	depth     int
+	highWater int
+	dropped   uint64
+
+	startOnce sync.Once
+	stopCh    chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// newSpool creates a spool forwarding to out. Call start to run it.
+func newSpool(capacity int, out chan TelegramUpdate) *spool {
+	s := &spool{
+		in:     make(chan TelegramUpdate, cap(out)),
+		out:    out,
+		ring:   make([]TelegramUpdate, capacity),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	return s
+}
+
+// start runs the forwarding goroutine. It is a no-op after the first call
+// or after stop.
+func (s *spool) start() {
+	s.startOnce.Do(func() { go s.run() })
+}
+
+func (s *spool) run() {
+	defer close(s.done)
+
+	for {
+		// Only offer the head update when there is one
+		var out chan TelegramUpdate
+		var next TelegramUpdate
+		s.mu.Lock()
+		if s.depth > 0 {
+			out = s.out
+			next = s.ring[s.head]
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.stopCh:
+			return
+		case upd := <-s.in:
+			s.push(upd)
+		case out <- next:
+			s.pop()
+		}
+	}
+}
+
+// push appends an update, dropping it when the spool is full.
+func (s *spool) push(upd TelegramUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.depth == len(s.ring) {
+		s.dropped++
+		return
+	}
+	s.ring[(s.head+s.depth)%len(s.ring)] = upd
+	s.depth++
+	if s.depth > s.highWater {
+		s.highWater = s.depth
+	}
+}
+
+// pop removes the head update after it was delivered.
+func (s *spool) pop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ring[s.head] = TelegramUpdate{} // release references
+	s.head = (s.head + 1) % len(s.ring)
+	s.depth--
+}
+
+// stats returns a snapshot of the spool counters.
+func (s *spool) stats() SpoolStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return SpoolStats{
+		Capacity:  len(s.ring),
+		Depth:     s.depth,
+		HighWater: s.highWater,
+		Dropped:   s.dropped,
+	}
+}
+
+// stop terminates the forwarding goroutine. Spooled updates are discarded.
+func (s *spool) stop() {
+	s.closeOnce.Do(func() { close(s.stopCh) })
+	s.startOnce.Do(func() { close(s.done) }) // never started
+	<-s.done
+}
@@ -0,0 +1,176 @@
+package telegramreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForDepth polls the spool until its input is drained and its depth
+// reaches want.
+func waitForDepth(t *testing.T, s *spool, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(s.in) == 0 && s.stats().Depth == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("spool depth did not reach %d, stats: %+v", want, s.stats())
+}
+
+func TestSpool_AbsorbsBurstAndPreservesOrder(t *testing.T) {
+	out := make(chan TelegramUpdate, 5)
+	s := newSpool(50, out)
+	s.start()
+	defer s.stop()
+
+	// Consumer is stalled: 5 fit in out, the rest must be spooled
+	for i := 1; i <= 40; i++ {
+		s.in <- TelegramUpdate{UpdateID: i}
+	}
+	waitForDepth(t, s, 35)
+
+	for i := 1; i <= 40; i++ {
+		select {
+		case upd := <-out:
+			if upd.UpdateID != i {
+				t.Fatalf("expected update %d, got %d", i, upd.UpdateID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for update %d", i)
+		}
+	}
+
+	stats := s.stats()
+	if stats.Depth != 0 {
+		t.Errorf("expected empty spool after replay, got depth %d", stats.Depth)
+	}
+	if stats.HighWater != 35 {
+		t.Errorf("expected high-water mark 35, got %d", stats.HighWater)
+	}
+	if stats.Dropped != 0 {
+		t.Errorf("expected no drops, got %d", stats.Dropped)
+	}
+}
+
+func TestSpool_DropsWhenFull(t *testing.T) {
+	out := make(chan TelegramUpdate, 1)
+	s := newSpool(3, out)
+	s.start()
+	defer s.stop()
+
+	for i := 1; i <= 10; i++ {
+		s.in <- TelegramUpdate{UpdateID: i}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for s.stats().Dropped < 6 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	stats := s.stats()
+	if stats.Depth != 3 || stats.Dropped != 6 || stats.Capacity != 3 {
+		t.Fatalf("expected depth 3, dropped 6, capacity 3, got %+v", stats)
+	}
+
+	// The oldest updates are kept
+	for i := 1; i <= 4; i++ {
+		if upd := <-out; upd.UpdateID != i {
+			t.Fatalf("expected update %d, got %d", i, upd.UpdateID)
+		}
+	}
+}
+
+func TestClient_WithSpoolWebhook(t *testing.T) {
+	client, err := New(testBotToken,
+		WithWebhook(8443, ""),
+		WithRateLimit(1000, 1000),
+		WithSpool(500),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Stop()
+	handler := client.WebhookHandler()
+
+	// Without a spool the 100-slot channel would reject the tail with 503
+	const total = 300
+	for i := 1; i <= total; i++ {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(fmt.Sprintf(`{"update_id":%d}`, i)))
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("update %d: expected 200, got %d", i, rec.Code)
+		}
+		// Let the spool keep up with the spool input channel
+		if i%50 == 0 {
+			waitForDepth(t, client.spool, max(0, i-cap(client.updates)))
+		}
+	}
+
+	for i := 1; i <= total; i++ {
+		select {
+		case upd := <-client.Updates():
+			if upd.UpdateID != i {
+				t.Fatalf("expected update %d, got %d", i, upd.UpdateID)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for update %d", i)
+		}
+	}
+
+	stats, ok := client.SpoolStats()
+	if !ok {
+		t.Fatal("expected spool stats")
+	}
+	if stats.HighWater != total-cap(client.updates) {
+		t.Errorf("expected high-water mark %d, got %d", total-cap(client.updates), stats.HighWater)
+	}
+}
+
+func TestClient_SpoolStatsDisabled(t *testing.T) {
+	client, err := New(testBotToken)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := client.SpoolStats(); ok {
+		t.Error("expected no spool stats without WithSpool")
+	}
+}
+
+func TestClient_SpoolLifecycle(t *testing.T) {
+	client, err := New(testBotToken,
+		WithWebhook(8443, ""),
+		WithSpool(10),
+		WithTypedChannels(),
+		WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Stop must not block on goroutines that New no longer starts
+	stopped := make(chan struct{})
+	go func() {
+		client.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop blocked on a client that was never started")
+	}
+
+	if err := client.Start(context.Background()); !errors.Is(err, ErrClientStopped) {
+		t.Fatalf("expected ErrClientStopped, got %v", err)
+	}
+}
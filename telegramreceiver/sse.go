@@ -0,0 +1,260 @@
+package telegramreceiver
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultSSEBufferSize = 64
+	defaultSSEKeepAlive  = 30 * time.Second
+)
+
+// SSEOption configures an SSEHandler.
+type SSEOption func(*SSEHandler)
+
+// WithSSEBufferSize sets how many updates are queued per connected client
+// before it counts as a slow consumer. Default 64.
+func WithSSEBufferSize(n int) SSEOption {
+	return func(h *SSEHandler) {
+		if n > 0 {
+			h.bufferSize = n
+		}
+	}
+}
+
+// WithSSECloseSlowClients disconnects a client whose queue is full instead
+// of dropping the update for it, so the client can reconnect and notice the
+// gap rather than miss updates silently.
+func WithSSECloseSlowClients() SSEOption {
+	return func(h *SSEHandler) {
+		h.closeSlow = true
+	}
+}
+
+// WithSSEKeepAlive sets the interval of comment lines sent to idle clients
+// so proxies keep the connection open. Default 30s; 0 disables them.
+func WithSSEKeepAlive(interval time.Duration) SSEOption {
+	return func(h *SSEHandler) {
+		h.keepAlive = interval
+	}
+}
+
+// WithSSEDropLogInterval sets how often updates dropped for slow clients,
+// and slow clients disconnected, are summarized in a single log line
+// (default: 10s). The first drop after a quiet period is always logged.
+// Use 0 to log every drop.
+func WithSSEDropLogInterval(d time.Duration) SSEOption {
+	return func(h *SSEHandler) {
+		h.dropLogInterval = d
+	}
+}
+
+// SSEHandler streams updates to any number of HTTP clients as Server-Sent
+// Events, one "update" event per update with the update ID as event ID and
+// the update JSON as data. It lets several internal services subscribe to
+// one receiver; it is not a Telegram webhook endpoint.
+//
+// Updates are read only while at least one client is connected, so they
+// queue in the source channel until the first subscriber arrives. Each
+// update is delivered to the clients connected at that moment.
+type SSEHandler struct {
+	updates <-chan TelegramUpdate
+	logger  *slog.Logger
+
+	bufferSize int
+	closeSlow  bool
+	keepAlive  time.Duration
+
+	dropLogInterval time.Duration
+	drops           *dropLogger // Updates dropped for a slow client
+	disconnects     *dropLogger // Slow clients disconnected
+
+	register   chan *sseClient
+	unregister chan *sseClient
+	clients    atomic.Int32
+	dropped    atomic.Uint64
+
+	stopCh    chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// sseClient is the queue of one connected subscriber. The broadcaster
+// closes events when it removes the client.
+type sseClient struct {
+	events chan TelegramUpdate
+}
+
+// NewSSEHandler creates a handler streaming updates, typically
+// Client.Updates() or the channel passed to a receiver, and starts its
+// broadcast goroutine. Call Close to disconnect all clients.
+func NewSSEHandler(updates <-chan TelegramUpdate, logger *slog.Logger, opts ...SSEOption) *SSEHandler {
+	if updates == nil {
+		panic("telegramreceiver: NewSSEHandler: updates channel is nil")
+	}
+	h := &SSEHandler{
+		updates:    updates,
+		logger:     logger,
+		bufferSize: defaultSSEBufferSize,
+		keepAlive:  defaultSSEKeepAlive,
+		register:   make(chan *sseClient),
+		unregister: make(chan *sseClient),
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+
+		dropLogInterval: defaultDropLogInterval,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.drops = newDropLogger(logger, "SSE client queue full, dropping update", h.dropLogInterval)
+	h.disconnects = newDropLogger(logger, "disconnecting slow SSE client", h.dropLogInterval)
+	h.disconnects.summary = "disconnected %d slow SSE clients in last %s"
+	go h.run()
+	return h
+}
+
+func (h *SSEHandler) run() {
+	defer close(h.done)
+
+	clients := make(map[*sseClient]struct{})
+	remove := func(c *sseClient) {
+		if _, ok := clients[c]; ok {
+			delete(clients, c)
+			h.clients.Store(int32(len(clients)))
+			close(c.events)
+		}
+	}
+	defer func() {
+		for c := range clients {
+			remove(c)
+		}
+	}()
+
+	for {
+		// Leave updates queued in the source while nobody listens
+		var updates <-chan TelegramUpdate
+		if len(clients) > 0 {
+			updates = h.updates
+		}
+
+		select {
+		case <-h.stopCh:
+			return
+		case c := <-h.register:
+			clients[c] = struct{}{}
+			h.clients.Store(int32(len(clients)))
+		case c := <-h.unregister:
+			remove(c)
+		case upd, ok := <-updates:
+			if !ok {
+				h.logger.Info("SSE source channel closed, disconnecting clients")
+				return
+			}
+			for c := range clients {
+				select {
+				case c.events <- upd:
+				default:
+					h.dropped.Add(1)
+					if h.closeSlow {
+						h.disconnects.drop(upd.UpdateID)
+						remove(c)
+					} else {
+						h.drops.drop(upd.UpdateID)
+					}
+				}
+			}
+		}
+	}
+}
+
+// ServeHTTP streams events until the client disconnects, it is removed as
+// a slow consumer, or the handler is closed.
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	c := &sseClient{events: make(chan TelegramUpdate, h.bufferSize)}
+	select {
+	case h.register <- c:
+	case <-h.done:
+		http.Error(w, "SSE handler closed", http.StatusServiceUnavailable)
+		return
+	}
+	defer func() {
+		select {
+		case h.unregister <- c:
+		case <-h.done:
+		}
+	}()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	h.logger.Debug("SSE client connected", "remote_addr", r.RemoteAddr)
+
+	var keepAlive <-chan time.Time
+	if h.keepAlive > 0 {
+		ticker := time.NewTicker(h.keepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			h.logger.Debug("SSE client disconnected", "remote_addr", r.RemoteAddr)
+			return
+		case <-keepAlive:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case upd, ok := <-c.events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(upd)
+			if err != nil {
+				h.logger.Error("failed to encode update for SSE", "update_id", upd.UpdateID, "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: update\ndata: %s\n\n", upd.UpdateID, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// Clients returns the number of connected clients.
+func (h *SSEHandler) Clients() int {
+	return int(h.clients.Load())
+}
+
+// Dropped returns how many per-client deliveries were skipped because a
+// client's queue was full.
+func (h *SSEHandler) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+// Close stops broadcasting and ends all open streams. Updates not yet read
+// from the source channel stay there.
+func (h *SSEHandler) Close() {
+	h.closeOnce.Do(func() { close(h.stopCh) })
+	<-h.done
+}
@@ -0,0 +1,220 @@
+package telegramreceiver
+
+import (
+	"bufio"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readSSEEvent reads lines up to the next blank line and returns the
+// event's fields, skipping comment lines.
+func readSSEEvent(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSSEHandler_StreamsQueuedUpdates(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	handler := NewSSEHandler(updates, newTestLogger())
+	defer handler.Close()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// Queued before anyone subscribed
+	updates <- TelegramUpdate{UpdateID: 1, Message: &Message{MessageID: 10, Text: "hello"}}
+	updates <- TelegramUpdate{UpdateID: 2}
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	first := readSSEEvent(t, r)
+	if first["id"] != "1" || first["event"] != "update" || !strings.Contains(first["data"], `"text":"hello"`) {
+		t.Errorf("first event = %v", first)
+	}
+	if second := readSSEEvent(t, r); second["id"] != "2" {
+		t.Errorf("second event = %v", second)
+	}
+}
+
+func TestSSEHandler_Disconnect(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	handler := NewSSEHandler(updates, newTestLogger())
+	defer handler.Close()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	waitFor(t, "client registration", func() bool { return handler.Clients() == 1 })
+
+	cancel()
+	waitFor(t, "client removal", func() bool { return handler.Clients() == 0 })
+
+	// Without subscribers updates stay queued in the source
+	updates <- TelegramUpdate{UpdateID: 3}
+	time.Sleep(20 * time.Millisecond)
+	if len(updates) != 1 {
+		t.Errorf("expected the update to stay queued, %d left", len(updates))
+	}
+}
+
+func TestSSEHandler_SlowConsumer(t *testing.T) {
+	t.Run("drop", func(t *testing.T) {
+		updates := make(chan TelegramUpdate)
+		handler := NewSSEHandler(updates, newTestLogger(), WithSSEBufferSize(1))
+		defer handler.Close()
+
+		slow := &sseClient{events: make(chan TelegramUpdate, 1)}
+		handler.register <- slow
+		for i := 1; i <= 3; i++ {
+			updates <- TelegramUpdate{UpdateID: i}
+		}
+		waitFor(t, "drops", func() bool { return handler.Dropped() == 2 })
+		if upd := <-slow.events; upd.UpdateID != 1 {
+			t.Errorf("expected the first update to be kept, got %d", upd.UpdateID)
+		}
+		if handler.Clients() != 1 {
+			t.Errorf("expected the slow client to stay connected, got %d clients", handler.Clients())
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		updates := make(chan TelegramUpdate)
+		handler := NewSSEHandler(updates, newTestLogger(), WithSSEBufferSize(1), WithSSECloseSlowClients())
+		defer handler.Close()
+
+		slow := &sseClient{events: make(chan TelegramUpdate, 1)}
+		handler.register <- slow
+		updates <- TelegramUpdate{UpdateID: 1}
+		updates <- TelegramUpdate{UpdateID: 2}
+
+		<-slow.events
+		select {
+		case _, ok := <-slow.events:
+			if ok {
+				t.Error("expected the slow client's queue to be closed")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("slow client was not disconnected")
+		}
+		if handler.Clients() != 0 {
+			t.Errorf("expected no clients, got %d", handler.Clients())
+		}
+	})
+}
+
+func TestSSEHandler_DropLogAggregation(t *testing.T) {
+	tests := []struct {
+		name      string
+		closeSlow bool
+		summary   string
+	}{
+		{"drop", false, "dropped 4 updates"},
+		{"close", true, "disconnected 4 slow SSE clients"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out lockedBuffer
+			opts := []SSEOption{WithSSEBufferSize(1), WithSSEDropLogInterval(time.Hour)}
+			if tt.closeSlow {
+				opts = append(opts, WithSSECloseSlowClients())
+			}
+			updates := make(chan TelegramUpdate)
+			handler := NewSSEHandler(updates, slog.New(slog.NewTextHandler(&out, nil)), opts...)
+			defer handler.Close()
+
+			// Every client is full after the first update, so the second is
+			// dropped for (or disconnects) all five
+			for range 5 {
+				handler.register <- &sseClient{events: make(chan TelegramUpdate, 1)}
+			}
+			updates <- TelegramUpdate{UpdateID: 1}
+			updates <- TelegramUpdate{UpdateID: 2}
+			waitFor(t, "drops", func() bool { return handler.Dropped() == 5 })
+
+			if lines := out.lines(); len(lines) != 1 {
+				t.Errorf("expected a single log line for %d drops, got %d: %q", handler.Dropped(), len(lines), lines)
+			}
+
+			// The rest are reported in one summary line
+			handler.drops.flush()
+			handler.disconnects.flush()
+			if lines := out.lines(); len(lines) != 2 || !strings.Contains(lines[1], tt.summary) {
+				t.Errorf("expected a %q summary, got %q", tt.summary, lines)
+			}
+		})
+	}
+}
+
+func TestSSEHandler_CloseEndsStreams(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	handler := NewSSEHandler(updates, newTestLogger())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	waitFor(t, "client registration", func() bool { return handler.Clients() == 1 })
+
+	handler.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		bufio.NewReader(resp.Body).ReadString(0)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream not ended by Close")
+	}
+}
@@ -0,0 +1,51 @@
+package telegramreceiver
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of receiver activity, e.g. for a status endpoint.
+type Stats struct {
+	Running           bool      // Polling loop running, or webhook handler created
+	UpdatesReceived   uint64    // Updates decoded from Telegram, including dropped ones
+	UpdatesDropped    uint64    // Updates dropped because the updates channel was full
+	HandlerPanics     uint64    // Panics recovered from the update handler or receive callback
+	HandlerTimeouts   uint64    // Handler calls abandoned after the handler timeout
+	ConsecutiveErrors int       // Current getUpdates error streak (polling only)
+	LastUpdateAt      time.Time // When the last update was received (zero if none)
+	BreakerState      string    // "closed", "half-open" or "open"
+	Offset            int       // Next getUpdates offset (polling only)
+	ConnectionsReused uint64    // getUpdates calls on a pooled connection (polling only)
+	ConnectionsNew    uint64    // getUpdates calls that opened a connection (polling only)
+}
+
+// receiverCounters tracks update counts shared by the receivers.
+// All fields are safe for concurrent use.
+type receiverCounters struct {
+	received     atomic.Uint64
+	dropped      atomic.Uint64
+	panics       atomic.Uint64
+	timeouts     atomic.Uint64
+	lastUpdateAt atomic.Int64 // Unix nanoseconds, 0 = never
+}
+
+func (rc *receiverCounters) recordReceived(at time.Time) {
+	rc.received.Add(1)
+	rc.lastUpdateAt.Store(at.UnixNano())
+}
+
+func (rc *receiverCounters) recordDropped() {
+	rc.dropped.Add(1)
+}
+
+// fill copies the counters into s.
+func (rc *receiverCounters) fill(s *Stats) {
+	s.UpdatesReceived = rc.received.Load()
+	s.UpdatesDropped = rc.dropped.Load()
+	s.HandlerPanics = rc.panics.Load()
+	s.HandlerTimeouts = rc.timeouts.Load()
+	if ns := rc.lastUpdateAt.Load(); ns != 0 {
+		s.LastUpdateAt = time.Unix(0, ns)
+	}
+}
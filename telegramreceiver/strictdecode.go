@@ -0,0 +1,123 @@
+package telegramreceiver
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	messageType         = reflect.TypeFor[Message]()
+)
+
+// unknownUpdateFields returns the dotted paths of fields in a raw update
+// that TelegramUpdate does not model, such as "message.story", sorted and
+// without duplicates. Array elements appear as "[]". It backs the strict
+// decoding options; json.Decoder.DisallowUnknownFields alone would stop
+// at types with their own UnmarshalJSON, such as Message.
+func unknownUpdateFields(data []byte) []string {
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil
+	}
+	var fields []string
+	collectUnknownFields(raw, reflect.TypeFor[TelegramUpdate](), "", &fields)
+	slices.Sort(fields)
+	return slices.Compact(fields)
+}
+
+// warnUnknownFields logs the fields of a raw update that are not modeled.
+// Top-level fields with a custom decoder count as modeled.
+func warnUnknownFields(logger *slog.Logger, updateID int, data []byte, custom map[string]UpdateDecoder) {
+	fields := slices.DeleteFunc(unknownUpdateFields(data), func(f string) bool {
+		_, ok := custom[f]
+		return ok
+	})
+	if len(fields) > 0 {
+		logger.Warn("update has unknown fields", "update_id", updateID, "fields", fields)
+	}
+}
+
+func collectUnknownFields(value any, t reflect.Type, path string, out *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		// Custom decoders may consume any shape; Message decodes its own fields
+		if t != messageType && reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+			return
+		}
+		known := jsonFieldTypes(t)
+		for key, sub := range v {
+			ft, ok := lookupJSONField(known, key)
+			if !ok {
+				*out = append(*out, path+key)
+				continue
+			}
+			collectUnknownFields(sub, ft, path+key+".", out)
+		}
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		elemPath := strings.TrimSuffix(path, ".") + "[]."
+		for _, sub := range v {
+			collectUnknownFields(sub, t.Elem(), elemPath, out)
+		}
+	}
+}
+
+// lookupJSONField finds key like encoding/json does: exact match first,
+// then case-insensitive.
+func lookupJSONField(known map[string]reflect.Type, key string) (reflect.Type, bool) {
+	if ft, ok := known[key]; ok {
+		return ft, true
+	}
+	for name, ft := range known {
+		if strings.EqualFold(name, key) {
+			return ft, true
+		}
+	}
+	return nil, false
+}
+
+// jsonFieldTypes maps the JSON names of a struct's fields to their types,
+// following encoding/json's naming and embedding rules.
+func jsonFieldTypes(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFieldTypes(ft) {
+					fields[k] = v
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
@@ -0,0 +1,100 @@
+package telegramreceiver
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnknownUpdateFields(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"business_connection": {},
+		"message": {
+			"message_id": 2,
+			"date": 1700000000,
+			"chat": {"id": 1, "type": "private", "accent_color_id": 3},
+			"story": {"id": 5},
+			"photo": [{"file_id": "a", "file_unique_id": "ua", "width": 1, "height": 1, "blur": true}],
+			"reply_to_message": {"message_id": 1, "date": 1, "chat": {"id": 1, "type": "private"}, "quote_flag": 1},
+			"reply_markup": {"inline_keyboard": [[{"text": "x", "copy_text": {"text": "y"}}]]},
+			"TEXT": "matched case-insensitively"
+		}
+	}`
+
+	got := unknownUpdateFields([]byte(payload))
+	want := []string{
+		"business_connection",
+		"message.chat.accent_color_id",
+		"message.photo[].blur",
+		"message.reply_to_message.quote_flag",
+		"message.story",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("unknownUpdateFields() = %v, want %v", got, want)
+	}
+
+	if got := unknownUpdateFields([]byte(`{"update_id": 1, "message": {"message_id": 1, "date": 1, "chat": {"id": 1, "type": "private"}}}`)); len(got) != 0 {
+		t.Errorf("expected no unknown fields, got %v", got)
+	}
+}
+
+func TestWebhookHandler_StrictDecoding(t *testing.T) {
+	var logs bytes.Buffer
+	updates := make(chan TelegramUpdate, 10)
+	handler := NewWebhookHandler(
+		slog.New(slog.NewTextHandler(&logs, nil)),
+		"test-secret", "", updates,
+		100, 200, 1<<20,
+		5, 2*time.Minute, 60*time.Second,
+		WithWebhookStrictDecoding(),
+	)
+
+	body := `{"update_id": 9, "message": {"message_id": 1, "date": 1, "chat": {"id": 1, "type": "private"}, "story": {}}}`
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if len(updates) != 1 {
+		t.Error("the update must still be delivered")
+	}
+	if out := logs.String(); !strings.Contains(out, "update has unknown fields") || !strings.Contains(out, "message.story") {
+		t.Errorf("expected an unknown field warning, got:\n%s", out)
+	}
+}
+
+func TestLongPollingClient_StrictDecoding(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true,"result":[{"update_id":4,"chat_boost":{}}]}`))
+	}))
+	defer server.Close()
+
+	var logs lockedBuffer
+	client := newTestPollingClient(server, make(chan TelegramUpdate, 10), WithPollStrictDecoding())
+	client.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	updates, err := client.fetchUpdates(context.Background())
+	if err != nil {
+		t.Fatalf("fetchUpdates: %v", err)
+	}
+	if len(updates) != 1 || updates[0].UpdateID != 4 {
+		t.Fatalf("unexpected updates %+v", updates)
+	}
+	var warned bool
+	for _, line := range logs.lines() {
+		warned = warned || (strings.Contains(line, "update has unknown fields") && strings.Contains(line, "chat_boost"))
+	}
+	if !warned {
+		t.Error("expected an unknown field warning")
+	}
+}
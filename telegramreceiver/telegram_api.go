@@ -1,12 +1,17 @@
 package telegramreceiver
 
 import (
+	"context"
 	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sony/gobreaker/v2"
@@ -17,19 +22,279 @@ import (
 
 type WebhookHandler struct {
 	logger        *slog.Logger
-	webhookSecret string
+	secrets       atomic.Pointer[webhookSecrets] // Reloadable via Client.Reload
 	allowedDomain string
 
 	Updates     chan TelegramUpdate
 	limiter     *rate.Limiter
 	breaker     *gobreaker.CircuitBreaker[any]
 	bufferPool  sync.Pool
-	maxBodySize int64
+	maxBodySize atomic.Int64 // Reloadable via Client.Reload
+
+	// Log levels for per-request lines
+	requestLogLevel slog.Level // forwarded updates
+	rejectLogLevel  slog.Level // client-side rejections (4xx)
+
+	// Optional server state; new updates are rejected once shutdown begins
+	state *ServerState
+
+	// Optional in-flight request limit (nil = unlimited)
+	inflight atomic.Pointer[chan struct{}]
+
+	// Optional success response writer (default: empty 200)
+	respond func(w http.ResponseWriter, u TelegramUpdate)
+
+	// Optional synchronous handler used instead of the Updates channel
+	handler UpdateHandler
+
+	// Optional hook called after a handler panic was recovered
+	onPanic func(u TelegramUpdate, recovered any)
+
+	// Activity counters for Client.Stats
+	counters receiverCounters
+
+	// Highest update ID received, where hybrid mode starts polling
+	lastUpdateID atomic.Int64
+
+	// Aggregated logging of updates rejected because Updates is full
+	dropLogInterval time.Duration
+	drops           *dropLogger
+
+	// Instance name for breaker and log attributes
+	name string
+
+	// Custom readiness checks reported by Ready and /readyz
+	readinessChecks []func() error
+
+	// Header carrying the request ID used for log correlation
+	requestIDHeader string
+	strictDecoding  bool                     // Warn about unmodeled fields (see WithWebhookStrictDecoding)
+	customDecoders  map[string]UpdateDecoder // Decoders filling Extra (see WithWebhookCustomUpdateDecoder)
+	auditLogger     *slog.Logger             // Receipt entries with body checksums (see WithWebhookAuditLog)
+	onMigration     func(MigrationEvent)
+	handlerTimeout  time.Duration // Per-update handler deadline (0 = none)
+
+	// Set by Pause: updates are refused with 503 until Resume
+	suspended atomic.Bool
+}
+
+// webhookSecrets holds the accepted secret tokens. Previous is only set
+// while a rotation is in progress.
+type webhookSecrets struct {
+	current  string
+	previous string
+}
+
+// WebhookOption configures the WebhookHandler.
+type WebhookOption func(*WebhookHandler)
+
+// WithRequestLogLevel sets the level used for per-request log lines
+// (forwarded updates and client-side rejections such as 401/403/429).
+// Use slog.LevelDebug to quiet them without hiding errors elsewhere.
+// Server-side failures (5xx) are always logged at Error.
+func WithRequestLogLevel(level slog.Level) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.requestLogLevel = level
+		wh.rejectLogLevel = level
+	}
+}
+
+// WithWebhookResponse replaces the default empty 200 response written after
+// an update was accepted. The function must write a 2xx status, otherwise
+// Telegram redelivers the update.
+//
+// Telegram also accepts a Bot API method call as the webhook response, which
+// saves a round trip for simple replies:
+//
+//	telegramreceiver.WithWebhookResponse(func(w http.ResponseWriter, u telegramreceiver.TelegramUpdate) {
+//	    if u.Message == nil {
+//	        w.WriteHeader(http.StatusOK)
+//	        return
+//	    }
+//	    w.Header().Set("Content-Type", "application/json")
+//	    json.NewEncoder(w).Encode(map[string]any{
+//	        "method":  "sendMessage",
+//	        "chat_id": u.Message.Chat.ID,
+//	        "text":    "received",
+//	    })
+//	})
+//
+// The result of such a call is not reported back, so use it only for
+// fire-and-forget replies.
+func WithWebhookResponse(fn func(w http.ResponseWriter, u TelegramUpdate)) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.respond = fn
+	}
+}
+
+// WithUpdateHandler processes each update synchronously inside ServeHTTP
+// instead of sending it to the Updates channel. The handler receives the
+// request context, so it can honor cancellation and read request-scoped
+// values such as trace IDs; the context stays valid until the handler
+// returns. A handler error makes the webhook respond with 500 (or the code
+// of a returned *WebhookError) so Telegram redelivers the update.
+func WithUpdateHandler(h UpdateHandler) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.handler = h
+	}
+}
+
+// WithPanicHandler sets a hook called when the update handler panics, for
+// example to report the panic to an error tracker. Panics are always
+// recovered, logged with the update ID and stack, and counted in
+// Stats.HandlerPanics; the request fails with 500 and later requests are
+// processed normally.
+func WithPanicHandler(fn func(u TelegramUpdate, recovered any)) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.onPanic = fn
+	}
+}
+
+// WithWebhookSecrets accepts requests carrying either the current or the
+// previous secret token, allowing zero-downtime rotation: deploy with both,
+// call setWebhook with the current secret, then clear previous (for example
+// via Client.Reload). It replaces the secret passed to NewWebhookHandler.
+func WithWebhookSecrets(current, previous string) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.setSecrets(current, previous)
+	}
+}
+
+// WithMaxConcurrentRequests bounds the number of requests processed at
+// once. Further requests get 503 with Retry-After so Telegram redelivers
+// them later. Unlike the rate limiter this caps memory and goroutines when
+// requests are slow, e.g. during a retry storm. n <= 0 means unlimited.
+func WithMaxConcurrentRequests(n int) WebhookOption {
+	return func(wh *WebhookHandler) {
+		if n <= 0 {
+			wh.inflight.Store(nil)
+			return
+		}
+		sem := make(chan struct{}, n)
+		wh.inflight.Store(&sem)
+	}
+}
+
+// WithWebhookDropLogInterval sets how often updates rejected with 503
+// because the Updates channel is full are summarized in a single log line
+// (default: 10s). The first rejection after a quiet period is always logged.
+// Use 0 to log every rejection.
+func WithWebhookDropLogInterval(d time.Duration) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.dropLogInterval = d
+	}
+}
+
+// WithWebhookName identifies the handler when several run in one process:
+// log lines get a bot=name attribute and the circuit breaker is named
+// "name/WebhookCircuitBreaker".
+func WithWebhookName(name string) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.name = name
+		wh.logger = wh.logger.With("bot", name)
+	}
 }
 
 /* ---------- constructor ---------- */
 
+// WithServerState makes the handler return 503 for new updates once the
+// server state is marked as shutting down, so Telegram redelivers them
+// later instead of enqueuing updates the consumer will never process.
+// StartWebhookServer attaches its state automatically when
+// WEBHOOK_REJECT_ON_SHUTDOWN is enabled; the handler then rejects once
+// either that state or one set with this option is shutting down.
+func WithServerState(state *ServerState) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.state = state
+	}
+}
+
+// WithWebhookOnMigration calls fn when a group is upgraded to a
+// supergroup, so state keyed by the old chat ID can be moved. Telegram
+// announces a migration in both chats, so fn may run twice for the same
+// event and should be idempotent. Migrations are logged either way.
+func WithWebhookOnMigration(fn func(MigrationEvent)) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.onMigration = fn
+	}
+}
+
+// WithWebhookHandlerTimeout runs each WithUpdateHandler call under a
+// context with deadline d. A handler still running at the deadline is
+// logged, counted in Stats.HandlerTimeouts and abandoned: the update is
+// acknowledged with 200 so Telegram does not redeliver it, and the
+// handler keeps running in the background until it returns, since Go
+// cannot stop a goroutine that ignores its context. 0 disables the limit.
+func WithWebhookHandlerTimeout(d time.Duration) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.handlerTimeout = d
+	}
+}
+
+// WithWebhookStrictDecoding logs a warning listing the fields of each
+// update that this package does not model, for example after a Bot API
+// change. The update is still delivered. Meant for debugging: every update
+// is decoded a second time.
+func WithWebhookStrictDecoding() WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.strictDecoding = true
+	}
+}
+
+// WithWebhookCustomUpdateDecoder decodes the top-level update field with
+// decode and stores the result in TelegramUpdate.Extra under the field
+// name. Use it for update types this package does not model yet. If decode
+// fails, the error is logged and the update is delivered without the field.
+func WithWebhookCustomUpdateDecoder(field string, decode UpdateDecoder) WebhookOption {
+	return func(wh *WebhookHandler) {
+		addUpdateDecoder(&wh.customDecoders, field, decode)
+	}
+}
+
+// WithWebhookAuditLog writes an entry to logger for each received update
+// with its update_id, received_at and the SHA-256 of the request body, to
+// prove receipt without retaining the content. The body is never logged.
+func WithWebhookAuditLog(logger *slog.Logger) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.auditLogger = logger
+	}
+}
+
+// WithRequestIDHeader sets the header read for a request ID (default:
+// X-Request-Id). A valid incoming value is kept, otherwise a random ID is
+// generated. The ID is added as request_id to the request's log lines,
+// echoed in the response header and available to the update handler via
+// RequestIDFromContext.
+func WithRequestIDHeader(name string) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.requestIDHeader = http.CanonicalHeaderKey(name)
+	}
+}
+
+// WithReadinessCheck adds a custom readiness check, such as a database
+// ping, reported by Ready. StartWebhookServer answers /readyz with 503
+// while any check fails; /healthz is unaffected. Checks run on every probe
+// and should be fast. The option can be given several times.
+func WithReadinessCheck(check func() error) WebhookOption {
+	return func(wh *WebhookHandler) {
+		wh.readinessChecks = append(wh.readinessChecks, check)
+	}
+}
+
+// Ready runs the readiness checks added with WithReadinessCheck and
+// returns the first failure, or nil when all pass.
+func (wh *WebhookHandler) Ready() error {
+	for _, check := range wh.readinessChecks {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // NewWebhookHandler creates a new webhook handler with all tunables injected.
+// It panics if updates is nil and no WithUpdateHandler is given, since
+// every update would otherwise be rejected.
 //
 // Deprecated: Use New() or NewFromConfig() with WithMode(ModeWebhook) instead.
 // This function will be removed in v4.
@@ -51,6 +316,7 @@ func NewWebhookHandler(
 	breakerMaxReq uint32,
 	breakerInterval time.Duration,
 	breakerTimeout time.Duration,
+	opts ...WebhookOption,
 ) *WebhookHandler {
 
 	cbSettings := gobreaker.Settings{
@@ -60,14 +326,15 @@ func NewWebhookHandler(
 		Timeout:     breakerTimeout,
 	}
 
-	return &WebhookHandler{
-		logger:        logger,
-		webhookSecret: webhookSecret,
-		allowedDomain: allowedDomain,
-		Updates:       updates,
-		limiter:       rate.NewLimiter(rate.Limit(rateLimitReq), rateLimitBurst),
-		breaker:       gobreaker.NewCircuitBreaker[any](cbSettings),
-		maxBodySize:   maxBodySize,
+	wh := &WebhookHandler{
+		logger:          logger,
+		allowedDomain:   allowedDomain,
+		Updates:         updates,
+		limiter:         rate.NewLimiter(rate.Limit(rateLimitReq), rateLimitBurst),
+		requestLogLevel: slog.LevelInfo,
+		rejectLogLevel:  slog.LevelError,
+		dropLogInterval: defaultDropLogInterval,
+		requestIDHeader: defaultRequestIDHeader,
 		bufferPool: sync.Pool{
 			New: func() interface{} {
 				b := make([]byte, maxBodySize)
@@ -75,69 +342,379 @@ func NewWebhookHandler(
 			},
 		},
 	}
+	wh.maxBodySize.Store(maxBodySize)
+	wh.setSecrets(webhookSecret, "")
+
+	// Apply options
+	for _, opt := range opts {
+		opt(wh)
+	}
+	if wh.Updates == nil && wh.handler == nil {
+		panic("telegramreceiver: NewWebhookHandler: updates channel is nil and no WithUpdateHandler was given")
+	}
+	cbSettings.Name = breakerName(wh.name, cbSettings.Name)
+	wh.breaker = gobreaker.NewCircuitBreaker[any](cbSettings)
+	wh.drops = newDropLogger(wh.logger, "updates channel blocked, rejecting update", wh.dropLogInterval)
+
+	return wh
+}
+
+// Pause makes the handler answer 503 so Telegram keeps updates and retries
+// them later, for example during downstream maintenance. Health endpoints
+// are unaffected.
+func (wh *WebhookHandler) Pause() {
+	if !wh.suspended.Swap(true) {
+		wh.logger.Info("webhook receiving paused")
+	}
+}
+
+// Resume accepts updates again after Pause.
+func (wh *WebhookHandler) Resume() {
+	if wh.suspended.Swap(false) {
+		wh.logger.Info("webhook receiving resumed")
+	}
+}
+
+// reload swaps the tunable limits of a running handler. In-flight
+// requests keep the limits they started with.
+func (wh *WebhookHandler) reload(rateLimitReq float64, rateLimitBurst int, maxBodySize int64) {
+	wh.limiter.SetLimit(rate.Limit(rateLimitReq))
+	wh.limiter.SetBurst(rateLimitBurst)
+	wh.maxBodySize.Store(maxBodySize)
+}
+
+// stats returns a snapshot of the webhook activity.
+func (wh *WebhookHandler) stats() Stats {
+	s := Stats{
+		Running:      true,
+		BreakerState: wh.breaker.State().String(),
+	}
+	wh.counters.fill(&s)
+	return s
+}
+
+// recordUpdateID raises the highest received update ID to id.
+func (wh *WebhookHandler) recordUpdateID(id int) {
+	for {
+		last := wh.lastUpdateID.Load()
+		if int64(id) <= last || wh.lastUpdateID.CompareAndSwap(last, int64(id)) {
+			return
+		}
+	}
+}
+
+// setSecrets replaces the accepted secret tokens.
+func (wh *WebhookHandler) setSecrets(current, previous string) {
+	wh.secrets.Store(&webhookSecrets{current: current, previous: previous})
+}
+
+// validSecret reports whether token matches the current or previous secret.
+// Both comparisons always run in constant time so the response time does
+// not reveal which secret matched. No configured secret accepts any token.
+func (wh *WebhookHandler) validSecret(token string) bool {
+	s := wh.secrets.Load()
+	if s.current == "" && s.previous == "" {
+		return true
+	}
+	matchCurrent := s.current != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.current)) == 1
+	matchPrevious := s.previous != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.previous)) == 1
+	return matchCurrent || matchPrevious
 }
 
 /* ---------- HTTP handler ---------- */
 
 func (wh *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	/* request ID for log correlation */
+	requestID := r.Header.Get(wh.requestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	w.Header().Set(wh.requestIDHeader, requestID)
+	logger := wh.logger.With("request_id", requestID)
+	ctx := ContextWithRequestID(r.Context(), requestID)
+
+	/* shutdown check */
+	if wh.state != nil && wh.state.IsShuttingDown() {
+		wh.fail(logger, w, ErrShuttingDown.Message, ErrShuttingDown.Code)
+		return
+	}
+
+	/* paused on request: Telegram redelivers after the 503 */
+	if wh.suspended.Load() {
+		w.Header().Set("Retry-After", "5")
+		wh.fail(logger, w, "receiving paused", http.StatusServiceUnavailable)
+		return
+	}
+
+	/* concurrency limit */
+	// Release to the semaphore that was acquired, even if it is replaced
+	if sem := wh.inflight.Load(); sem != nil {
+		select {
+		case *sem <- struct{}{}:
+			defer func() { <-*sem }()
+		default:
+			w.Header().Set("Retry-After", "1")
+			wh.fail(logger, w, "too many concurrent requests", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
 	/* rate-limit check */
 	if !wh.limiter.Allow() {
-		wh.fail(w, "rate limit exceeded", http.StatusTooManyRequests)
+		wh.fail(logger, w, "rate limit exceeded", http.StatusTooManyRequests)
 		return
 	}
 
 	/* everything else (wrapped by circuit-breaker) */
+	var upd TelegramUpdate
 	_, err := wh.breaker.Execute(func() (interface{}, error) {
 		/* domain + secret + method validation */
 		if wh.allowedDomain != "" && r.Host != wh.allowedDomain {
 			return nil, ErrForbidden
 		}
-		if wh.webhookSecret != "" &&
-			subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Telegram-Bot-Api-Secret-Token")), []byte(wh.webhookSecret)) != 1 {
+		if !wh.validSecret(r.Header.Get("X-Telegram-Bot-Api-Secret-Token")) {
 			return nil, ErrUnauthorized
 		}
 		if r.Method != http.MethodPost {
 			return nil, ErrMethodNotAllowed
 		}
 
-		/* pooled buffer */
+		/* pooled buffer (grown if the body limit was raised by a reload) */
+		maxBodySize := wh.maxBodySize.Load()
 		bufPtr := wh.bufferPool.Get().(*[]byte)
-		buffer := *bufPtr
+		if int64(len(*bufPtr)) < maxBodySize {
+			b := make([]byte, maxBodySize)
+			bufPtr = &b
+		}
+		buffer := (*bufPtr)[:maxBodySize]
 		defer wh.bufferPool.Put(bufPtr)
 
-		r.Body = http.MaxBytesReader(w, r.Body, wh.maxBodySize)
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
 		n, err := io.ReadFull(r.Body, buffer)
+		if err == nil {
+			// The buffer is full: one more byte means the body is over the limit
+			var probe [1]byte
+			if _, err = io.ReadFull(r.Body, probe[:]); err == io.EOF {
+				err = nil
+			}
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			// Updates are small, so an oversized body is worth noting
+			logger.Warn("request body too large",
+				"limit", tooLarge.Limit,
+				"content_length", r.ContentLength,
+				"remote_addr", r.RemoteAddr,
+			)
+			return nil, ErrBodyTooLarge
+		}
 		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 			return nil, &WebhookError{Code: 500, Message: "failed to read request body", Err: err}
 		}
 		defer r.Body.Close()
 
-		var upd TelegramUpdate
-		if err := json.Unmarshal(buffer[:n], &upd); err != nil {
-			return nil, &WebhookError{Code: 400, Message: "invalid JSON payload", Err: err}
+		if upd, err = unmarshalUpdate(logger, buffer[:n], wh.customDecoders, wh.strictDecoding); err != nil {
+			detail, attrs := describeDecodeError(err)
+			logger.Warn("invalid JSON payload", attrs...)
+			return nil, &WebhookError{Code: 400, Message: "invalid JSON payload: " + detail, Err: err}
+		}
+		wh.counters.recordReceived(upd.ReceivedAt)
+		wh.recordUpdateID(upd.UpdateID)
+		auditUpdate(wh.auditLogger, "webhook", upd, buffer[:n])
+		observeMigration(logger, upd, wh.onMigration)
+
+		if wh.handler != nil {
+			if err := wh.handleUpdate(ctx, logger, upd); err != nil {
+				logger.Error("update handler failed", "update_id", upd.UpdateID, "error", err)
+				var whErr *WebhookError
+				if errors.As(err, &whErr) {
+					return nil, whErr
+				}
+				return nil, &WebhookError{Code: 500, Message: "update handler failed", Err: err}
+			}
+			logger.Log(ctx, wh.requestLogLevel, "update handled", "update_id", upd.UpdateID)
+			return nil, nil
 		}
 
 		select {
 		case wh.Updates <- upd:
-			wh.logger.Info("update forwarded", "update_id", upd.UpdateID)
+			logger.Log(ctx, wh.requestLogLevel, "update forwarded", "update_id", upd.UpdateID)
 		default:
+			wh.counters.recordDropped()
+			wh.drops.drop(upd.UpdateID)
 			return nil, ErrChannelBlocked
 		}
 		return nil, nil
 	})
 
 	if err != nil {
+		if err == ErrChannelBlocked {
+			// Already logged (aggregated) by the drop logger
+			http.Error(w, ErrChannelBlocked.Message, ErrChannelBlocked.Code)
+			return
+		}
 		if whErr, ok := err.(*WebhookError); ok {
-			wh.fail(w, whErr.Message, whErr.Code)
+			wh.fail(logger, w, whErr.Message, whErr.Code)
 		} else {
-			wh.fail(w, err.Error(), http.StatusInternalServerError)
+			wh.fail(logger, w, err.Error(), http.StatusInternalServerError)
 		}
 		return
 	}
+	if wh.respond != nil {
+		wh.respond(w, upd)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
-func (wh *WebhookHandler) fail(w http.ResponseWriter, msg string, code int) {
-	wh.logger.Error(msg)
+// UnmarshalUpdate decodes a Telegram update the same way the webhook handler
+// does, for updates received over another transport such as a message queue.
+// ReceivedAt is set to the current time. Errors describe the problem without
+// including the payload.
+func UnmarshalUpdate(data []byte) (TelegramUpdate, error) {
+	return unmarshalUpdate(slog.Default(), data, nil, false)
+}
+
+// UnmarshalUpdateWith is UnmarshalUpdate with custom decoders filling
+// Extra, as registered on a receiver with WithCustomUpdateDecoder. A field
+// whose decoder fails is logged to slog.Default and left out.
+func UnmarshalUpdateWith(data []byte, decoders map[string]UpdateDecoder) (TelegramUpdate, error) {
+	return unmarshalUpdate(slog.Default(), data, decoders, false)
+}
+
+// unmarshalUpdate is the decoding shared by all receivers: the update
+// itself, custom decoders and, in strict mode, warnings about unmodeled
+// fields, all logged to logger.
+func unmarshalUpdate(logger *slog.Logger, data []byte, decoders map[string]UpdateDecoder, strict bool) (TelegramUpdate, error) {
+	var upd TelegramUpdate
+	if err := json.Unmarshal(data, &upd); err != nil {
+		detail, _ := describeDecodeError(err)
+		return TelegramUpdate{}, fmt.Errorf("invalid update: %s: %w", detail, err)
+	}
+	upd.ReceivedAt = time.Now()
+	decodeCustomFields(logger, decoders, data, &upd)
+	if strict {
+		warnUnknownFields(logger, upd.UpdateID, data, decoders)
+	}
+	return upd, nil
+}
+
+// observeMigration logs a group migration announced by upd and calls hook.
+func observeMigration(logger *slog.Logger, upd TelegramUpdate, hook func(MigrationEvent)) {
+	if upd.Message == nil {
+		return
+	}
+	ev, ok := upd.Message.Migration()
+	if !ok {
+		return
+	}
+	logger.Info("group migrated to supergroup",
+		"update_id", upd.UpdateID,
+		"from_chat_id", ev.FromChatID,
+		"to_chat_id", ev.ToChatID,
+	)
+	if hook != nil {
+		hook(ev)
+	}
+}
+
+// callWithTimeout runs fn under a context that expires after d with
+// ErrHandlerTimeout as its cause, and returns ErrHandlerTimeout if fn is
+// still running or failed at that point. fn is not waited for after the
+// deadline, so it must recover its own panics.
+func callWithTimeout(ctx context.Context, d time.Duration, fn func(context.Context) error) error {
+	ctx, cancel := context.WithTimeoutCause(ctx, d, ErrHandlerTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+
+	select {
+	case err := <-done:
+		if err != nil && context.Cause(ctx) == ErrHandlerTimeout {
+			return ErrHandlerTimeout
+		}
+		return err
+	case <-ctx.Done():
+		// fn may have finished just as the deadline fired
+		select {
+		case err := <-done:
+			if err == nil {
+				return nil
+			}
+		default:
+		}
+		return context.Cause(ctx)
+	}
+}
+
+// handleUpdate calls the update handler, applying the handler timeout. A
+// timed-out update counts as handled.
+func (wh *WebhookHandler) handleUpdate(ctx context.Context, logger *slog.Logger, upd TelegramUpdate) error {
+	if wh.handlerTimeout <= 0 {
+		return wh.callHandler(ctx, logger, upd)
+	}
+	err := callWithTimeout(ctx, wh.handlerTimeout, func(ctx context.Context) error {
+		return wh.callHandler(ctx, logger, upd)
+	})
+	if errors.Is(err, ErrHandlerTimeout) {
+		wh.counters.timeouts.Add(1)
+		logger.Warn("update handler timed out, skipping update",
+			"update_id", upd.UpdateID,
+			"timeout", wh.handlerTimeout,
+		)
+		return nil
+	}
+	return err
+}
+
+// callHandler calls the update handler, converting a panic into an error
+// so one bad update cannot take down the server.
+func (wh *WebhookHandler) callHandler(ctx context.Context, logger *slog.Logger, upd TelegramUpdate) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			wh.counters.panics.Add(1)
+			logger.Error("update handler panicked",
+				"update_id", upd.UpdateID,
+				"panic", p,
+				"stack", string(debug.Stack()),
+			)
+			if wh.onPanic != nil {
+				wh.onPanic(upd, p)
+			}
+			err = &WebhookError{Code: 500, Message: "update handler panicked", Err: fmt.Errorf("panic: %v", p)}
+		}
+	}()
+	return wh.handler.HandleUpdate(ctx, upd)
+}
+
+// describeDecodeError returns a sanitized description of a JSON decode error
+// and structured log attributes. The raw payload is never included.
+func describeDecodeError(err error) (string, []any) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "(root)"
+		}
+		detail := fmt.Sprintf("field %q: expected %s, got %s", field, typeErr.Type, typeErr.Value)
+		return detail, []any{"field", field, "expected", typeErr.Type.String(), "got", typeErr.Value, "offset", typeErr.Offset}
+	case errors.As(err, &syntaxErr):
+		detail := fmt.Sprintf("syntax error at offset %d", syntaxErr.Offset)
+		return detail, []any{"offset", syntaxErr.Offset}
+	default:
+		return "malformed body", []any{"error_type", fmt.Sprintf("%T", err)}
+	}
+}
+
+func (wh *WebhookHandler) fail(logger *slog.Logger, w http.ResponseWriter, msg string, code int) {
+	level := wh.rejectLogLevel
+	if code >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logger.Log(context.Background(), level, msg)
 	http.Error(w, msg, code)
 }
@@ -2,10 +2,13 @@ package telegramreceiver
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -15,7 +18,7 @@ func newTestLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
 }
 
-func newTestHandler(updates chan TelegramUpdate) *WebhookHandler {
+func newTestHandler(updates chan TelegramUpdate, opts ...WebhookOption) *WebhookHandler {
 	return NewWebhookHandler(
 		newTestLogger(),
 		"test-secret",
@@ -27,6 +30,7 @@ func newTestHandler(updates chan TelegramUpdate) *WebhookHandler {
 		5,
 		2*time.Minute,
 		60*time.Second,
+		opts...,
 	)
 }
 
@@ -60,6 +64,9 @@ func TestWebhookHandler_ValidRequest(t *testing.T) {
 		if received.UpdateID != 12345 {
 			t.Errorf("expected update_id 12345, got %d", received.UpdateID)
 		}
+		if since := time.Since(received.ReceivedAt); since < 0 || since > time.Second {
+			t.Errorf("expected ReceivedAt close to now, got %v", received.ReceivedAt)
+		}
 		if received.Message.Text != "Hello, World!" {
 			t.Errorf("expected text 'Hello, World!', got '%s'", received.Message.Text)
 		}
@@ -113,6 +120,36 @@ func TestWebhookHandler_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestWebhookHandler_BodyTooLarge(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	handler := newTestHandler(updates)
+
+	// Valid JSON padded past the 1 MB limit
+	body := `{"update_id":1}` + strings.Repeat(" ", 1<<20)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d", rec.Code)
+	}
+	if len(updates) != 0 {
+		t.Error("an oversized update must not be delivered")
+	}
+
+	// A body exactly at the limit is still accepted
+	body = `{"update_id":2}` + strings.Repeat(" ", 1<<20-len(`{"update_id":2}`))
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200 at the limit, got %d", rec.Code)
+	}
+}
+
 func TestWebhookHandler_ChannelBlocked(t *testing.T) {
 	// Unbuffered channel will block
 	updates := make(chan TelegramUpdate)
@@ -298,3 +335,679 @@ func TestWebhookHandler_ConcurrentRequests(t *testing.T) {
 		t.Errorf("expected 10 updates in channel, got %d", count)
 	}
 }
+
+func TestWebhookHandler_RequestLogLevel(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []WebhookOption
+		wantForwarded bool
+	}{
+		{"default level logs forwarded", nil, true},
+		{"debug level suppresses forwarded", []WebhookOption{WithRequestLogLevel(slog.LevelDebug)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+			updates := make(chan TelegramUpdate, 10)
+			handler := NewWebhookHandler(
+				logger,
+				"test-secret",
+				"",
+				updates,
+				100,
+				200,
+				1<<20,
+				5,
+				2*time.Minute,
+				60*time.Second,
+				tt.opts...,
+			)
+
+			body, _ := json.Marshal(TelegramUpdate{UpdateID: 1})
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", rec.Code)
+			}
+			if got := strings.Contains(buf.String(), "update forwarded"); got != tt.wantForwarded {
+				t.Errorf("forwarded line logged = %v, want %v (output: %q)", got, tt.wantForwarded, buf.String())
+			}
+		})
+	}
+}
+
+func TestWebhookHandler_RejectsDuringShutdown(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	state := &ServerState{}
+	handler := newTestHandler(updates, WithServerState(state))
+
+	post := func() int {
+		body, _ := json.Marshal(TelegramUpdate{UpdateID: 1})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post(); code != http.StatusOK {
+		t.Fatalf("expected status 200 before shutdown, got %d", code)
+	}
+	<-updates
+
+	// Simulate the drain window
+	state.isShuttingDown.Store(true)
+
+	if code := post(); code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 during drain, got %d", code)
+	}
+	if len(updates) != 0 {
+		t.Errorf("expected no update enqueued during drain, got %d", len(updates))
+	}
+}
+
+func TestWebhookHandler_DecodeErrorDetails(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantInBody  string
+		wantInLog   string
+		notInOutput string
+	}{
+		{
+			name:        "type mismatch names field",
+			body:        `{"update_id": "secret-value-123"}`,
+			wantInBody:  "update_id",
+			wantInLog:   "field=update_id",
+			notInOutput: "secret-value-123",
+		},
+		{
+			name:        "syntax error reports offset",
+			body:        `{"update_id": 1,, "text": "secret-value-123"}`,
+			wantInBody:  "syntax error at offset",
+			wantInLog:   "offset=",
+			notInOutput: "secret-value-123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, nil))
+			handler := NewWebhookHandler(
+				logger,
+				"test-secret",
+				"",
+				make(chan TelegramUpdate, 10),
+				100,
+				200,
+				1<<20,
+				5,
+				2*time.Minute,
+				60*time.Second,
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d", rec.Code)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantInBody) {
+				t.Errorf("response %q should contain %q", rec.Body.String(), tt.wantInBody)
+			}
+			if !strings.Contains(buf.String(), "level=WARN") || !strings.Contains(buf.String(), tt.wantInLog) {
+				t.Errorf("expected warn log containing %q, got %q", tt.wantInLog, buf.String())
+			}
+			if strings.Contains(rec.Body.String(), tt.notInOutput) || strings.Contains(buf.String(), tt.notInOutput) {
+				t.Error("raw payload content leaked into response or logs")
+			}
+		})
+	}
+}
+
+func TestWebhookHandler_CustomResponse(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	handler := newTestHandler(updates, WithWebhookResponse(func(w http.ResponseWriter, u TelegramUpdate) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"ok": true, "update_id": u.UpdateID})
+	}))
+
+	body, _ := json.Marshal(TelegramUpdate{UpdateID: 77})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON content type, got %q", ct)
+	}
+	var resp struct {
+		OK       bool `json:"ok"`
+		UpdateID int  `json:"update_id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response body %q: %v", rec.Body.String(), err)
+	}
+	if !resp.OK || resp.UpdateID != 77 {
+		t.Errorf("unexpected response body: %s", rec.Body.String())
+	}
+	if len(updates) != 1 {
+		t.Errorf("expected update forwarded, got %d", len(updates))
+	}
+
+	// Rejected requests keep the standard error response
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without secret, got %d", rec.Code)
+	}
+}
+
+type traceIDKey struct{}
+
+func TestWebhookHandler_UpdateHandlerContext(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+
+	var (
+		gotErr     error
+		gotTraceID any
+		gotUpdate  int
+	)
+	handler := newTestHandler(updates, WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+		gotErr = ctx.Err()
+		gotTraceID = ctx.Value(traceIDKey{})
+		gotUpdate = u.UpdateID
+		return nil
+	})))
+
+	body, _ := json.Marshal(TelegramUpdate{UpdateID: 9})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	req = req.WithContext(context.WithValue(req.Context(), traceIDKey{}, "trace-123"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if gotErr != nil {
+		t.Errorf("expected non-cancelled context, got %v", gotErr)
+	}
+	if gotTraceID != "trace-123" {
+		t.Errorf("expected request-scoped value, got %v", gotTraceID)
+	}
+	if gotUpdate != 9 {
+		t.Errorf("expected update 9, got %d", gotUpdate)
+	}
+	if len(updates) != 0 {
+		t.Errorf("expected handler to replace the channel, got %d queued", len(updates))
+	}
+}
+
+func TestWebhookHandler_UpdateHandlerError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"plain error", errors.New("db down"), http.StatusInternalServerError},
+		{"webhook error", &WebhookError{Code: http.StatusServiceUnavailable, Message: "busy"}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(make(chan TelegramUpdate, 1), WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+				return tt.err
+			})))
+
+			body, _ := json.Marshal(TelegramUpdate{UpdateID: 1})
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d", tt.wantCode, rec.Code)
+			}
+			if strings.Contains(rec.Body.String(), "db down") {
+				t.Error("handler error details must not be returned to the caller")
+			}
+		})
+	}
+}
+
+func TestWebhookHandler_HandlerTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var handled []int
+	handler := newTestHandler(make(chan TelegramUpdate, 1),
+		WithWebhookHandlerTimeout(20*time.Millisecond),
+		WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+			if u.UpdateID == 1 {
+				<-release // Ignores ctx
+				return nil
+			}
+			handled = append(handled, u.UpdateID)
+			return nil
+		})),
+	)
+
+	for _, id := range []int{1, 2} {
+		body, _ := json.Marshal(TelegramUpdate{UpdateID: id})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("update %d: expected status 200, got %d", id, rec.Code)
+		}
+	}
+	if len(handled) != 1 || handled[0] != 2 {
+		t.Errorf("expected update 2 to be handled after the timeout, got %v", handled)
+	}
+	if got := handler.stats().HandlerTimeouts; got != 1 {
+		t.Errorf("HandlerTimeouts = %d, want 1", got)
+	}
+}
+
+func TestCallWithTimeout(t *testing.T) {
+	t.Run("deadline exceeded", func(t *testing.T) {
+		var sawCause error
+		done := make(chan struct{})
+		err := callWithTimeout(context.Background(), 10*time.Millisecond, func(ctx context.Context) error {
+			<-ctx.Done()
+			sawCause = context.Cause(ctx)
+			close(done)
+			return ctx.Err()
+		})
+		<-done
+		if !errors.Is(err, ErrHandlerTimeout) {
+			t.Errorf("expected ErrHandlerTimeout, got %v", err)
+		}
+		if sawCause != ErrHandlerTimeout {
+			t.Errorf("expected context cause ErrHandlerTimeout, got %v", sawCause)
+		}
+	})
+
+	t.Run("returns in time", func(t *testing.T) {
+		want := errors.New("db down")
+		err := callWithTimeout(context.Background(), time.Second, func(ctx context.Context) error {
+			return want
+		})
+		if err != want {
+			t.Errorf("expected handler error, got %v", err)
+		}
+	})
+
+	t.Run("parent cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		release := make(chan struct{})
+		defer close(release)
+		err := callWithTimeout(ctx, time.Second, func(context.Context) error {
+			<-release
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestWebhookHandler_PanicRecovery(t *testing.T) {
+	var hookID int
+	var hookValue any
+	handler := newTestHandler(make(chan TelegramUpdate, 1),
+		WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+			if u.UpdateID == 1 {
+				panic("boom")
+			}
+			return nil
+		})),
+		WithPanicHandler(func(u TelegramUpdate, recovered any) {
+			hookID = u.UpdateID
+			hookValue = recovered
+		}),
+	)
+
+	post := func(id int) int {
+		body, _ := json.Marshal(TelegramUpdate{UpdateID: id})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post(1); code != http.StatusInternalServerError {
+		t.Errorf("panicking update: expected status 500, got %d", code)
+	}
+	if hookID != 1 || hookValue != "boom" {
+		t.Errorf("panic hook got update %d, value %v", hookID, hookValue)
+	}
+	if code := post(2); code != http.StatusOK {
+		t.Errorf("next update: expected status 200, got %d", code)
+	}
+	if got := handler.stats().HandlerPanics; got != 1 {
+		t.Errorf("expected 1 recovered panic, got %d", got)
+	}
+}
+
+func TestWebhookHandler_RequestID(t *testing.T) {
+	var ctxID string
+	handler := newTestHandler(make(chan TelegramUpdate, 1),
+		WithRequestIDHeader("X-Correlation-Id"),
+		WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+			ctxID, _ = RequestIDFromContext(ctx)
+			return errors.New("db down")
+		})),
+	)
+	var logs bytes.Buffer
+	handler.logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	post := func(id string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TelegramUpdate{UpdateID: 1})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+		if id != "" {
+			req.Header.Set("X-Correlation-Id", id)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// A provided ID is preserved in logs, the response and the handler context
+	rec := post("req-abc")
+	if got := rec.Header().Get("X-Correlation-Id"); got != "req-abc" {
+		t.Errorf("expected echoed request ID req-abc, got %q", got)
+	}
+	if ctxID != "req-abc" {
+		t.Errorf("expected handler context request ID req-abc, got %q", ctxID)
+	}
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected several log lines for the request, got %q", lines)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "request_id=req-abc") {
+			t.Errorf("log line missing request ID: %s", line)
+		}
+	}
+
+	// Without a header an ID is generated and shared by the request's lines
+	logs.Reset()
+	rec = post("")
+	generated := rec.Header().Get("X-Correlation-Id")
+	if len(generated) != 32 || generated != ctxID {
+		t.Fatalf("expected a generated ID passed to the handler, got %q (context %q)", generated, ctxID)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if !strings.Contains(line, "request_id="+generated) {
+			t.Errorf("log line missing generated request ID: %s", line)
+		}
+	}
+
+	// Unsafe values are replaced
+	if got := post("bad id\nwith newline").Header().Get("X-Correlation-Id"); strings.Contains(got, " ") {
+		t.Errorf("expected unsafe request ID to be replaced, got %q", got)
+	}
+}
+
+func TestWebhookHandler_PauseResume(t *testing.T) {
+	updates := make(chan TelegramUpdate, 10)
+	handler := newTestHandler(updates)
+
+	post := func(id int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(TelegramUpdate{UpdateID: id})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	handler.Pause()
+	rec := post(1)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("paused: expected 503 with Retry-After, got %d", rec.Code)
+	}
+	if len(updates) != 0 {
+		t.Error("paused handler must not forward updates")
+	}
+
+	handler.Resume()
+	if rec := post(2); rec.Code != http.StatusOK {
+		t.Errorf("resumed: expected 200, got %d", rec.Code)
+	}
+	if upd := <-updates; upd.UpdateID != 2 {
+		t.Errorf("expected update 2, got %d", upd.UpdateID)
+	}
+}
+
+func TestNewWebhookHandler_NilUpdates(t *testing.T) {
+	func() {
+		defer func() {
+			r := recover()
+			if msg, _ := r.(string); !strings.Contains(msg, "updates channel is nil") {
+				t.Errorf("expected a clear panic for a nil updates channel, got %v", r)
+			}
+		}()
+		newTestHandler(nil)
+	}()
+
+	// A synchronous handler does not need the channel
+	handler := newTestHandler(nil, WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+		return nil
+	})))
+	body, _ := json.Marshal(TelegramUpdate{UpdateID: 1})
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with an update handler, got %d", rec.Code)
+	}
+}
+
+func TestWebhookHandler_SecretRotation(t *testing.T) {
+	handler := newTestHandler(make(chan TelegramUpdate, 10), WithWebhookSecrets("new-secret", "old-secret"))
+
+	post := func(secret string) int {
+		body, _ := json.Marshal(TelegramUpdate{UpdateID: 1})
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		if secret != "" {
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		secret string
+		want   int
+	}{
+		{"new-secret", http.StatusOK},
+		{"old-secret", http.StatusOK},
+		{"other-secret", http.StatusUnauthorized},
+		{"test-secret", http.StatusUnauthorized}, // constructor secret is replaced
+		{"", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if got := post(tt.secret); got != tt.want {
+			t.Errorf("secret %q: expected status %d, got %d", tt.secret, tt.want, got)
+		}
+	}
+
+	// Finishing the rotation drops the previous secret
+	handler.setSecrets("new-secret", "")
+	if got := post("old-secret"); got != http.StatusUnauthorized {
+		t.Errorf("expected previous secret rejected after rotation, got %d", got)
+	}
+	if got := post("new-secret"); got != http.StatusOK {
+		t.Errorf("expected current secret accepted after rotation, got %d", got)
+	}
+}
+
+func TestUnmarshalUpdate(t *testing.T) {
+	t.Run("message", func(t *testing.T) {
+		upd, err := UnmarshalUpdate([]byte(`{"update_id":10,"message":{"message_id":1,"date":1700000000,"chat":{"id":42,"type":"private"},"text":"hi"}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if upd.UpdateID != 10 || upd.Type() != UpdateTypeMessage || upd.Message.Text != "hi" || upd.Message.Chat.ID != 42 {
+			t.Errorf("unexpected update: %+v", upd)
+		}
+		if upd.ReceivedAt.IsZero() {
+			t.Error("expected ReceivedAt to be set")
+		}
+	})
+
+	t.Run("callback query", func(t *testing.T) {
+		upd, err := UnmarshalUpdate([]byte(`{"update_id":11,"callback_query":{"id":"cb1","from":{"id":7,"is_bot":false,"first_name":"A"},"data":"yes"}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if upd.Type() != UpdateTypeCallbackQuery || upd.CallbackQuery.ID != "cb1" || upd.CallbackQuery.Data != "yes" {
+			t.Errorf("unexpected update: %+v", upd)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := UnmarshalUpdate([]byte(`{"update_id":"secret-payload"}`))
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			t.Errorf("expected wrapped *json.UnmarshalTypeError, got %T", err)
+		}
+		if strings.Contains(err.Error(), "secret-payload") {
+			t.Errorf("error leaks payload: %v", err)
+		}
+	})
+}
+
+func TestWebhookHandler_MaxConcurrentRequests(t *testing.T) {
+	const limit = 2
+	entered := make(chan struct{}, limit)
+	release := make(chan struct{})
+	handler := newTestHandler(make(chan TelegramUpdate, 10),
+		WithMaxConcurrentRequests(limit),
+		WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+			entered <- struct{}{}
+			<-release
+			return nil
+		})),
+	)
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"update_id":1}`))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < limit; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := post(); rec.Code != http.StatusOK {
+				t.Errorf("held request: expected 200, got %d", rec.Code)
+			}
+		}()
+	}
+	for i := 0; i < limit; i++ {
+		<-entered
+	}
+
+	rec := post()
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 over the limit, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+
+	close(release)
+	wg.Wait()
+
+	if rec := post(); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 once requests finished, got %d", rec.Code)
+	}
+}
+
+func TestWebhookHandler_MaxConcurrentRequestsReplaced(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := newTestHandler(make(chan TelegramUpdate, 10),
+		WithMaxConcurrentRequests(1),
+		WithUpdateHandler(UpdateHandlerFunc(func(ctx context.Context, u TelegramUpdate) error {
+			entered <- struct{}{}
+			<-release
+			return nil
+		})),
+	)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"update_id":1}`))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+	<-entered
+
+	// The held request must release the semaphore it acquired
+	WithMaxConcurrentRequests(1)(handler)
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("request blocked releasing a replaced semaphore")
+	}
+}
+
+func TestWebhookHandler_OnMigration(t *testing.T) {
+	var events []MigrationEvent
+	updates := make(chan TelegramUpdate, 10)
+	handler := newTestHandler(updates, WithWebhookOnMigration(func(ev MigrationEvent) {
+		events = append(events, ev)
+	}))
+
+	for _, body := range []string{
+		`{"update_id": 1, "message": {"message_id": 2, "date": 1, "chat": {"id": -4001, "type": "group"}, "migrate_to_chat_id": -1009001}}`,
+		`{"update_id": 2, "message": {"message_id": 3, "date": 1, "chat": {"id": -1009001, "type": "supergroup"}, "text": "hello"}}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", "test-secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+	}
+
+	if len(events) != 1 || events[0] != (MigrationEvent{FromChatID: -4001, ToChatID: -1009001}) {
+		t.Errorf("unexpected migration events %+v", events)
+	}
+	if len(updates) != 2 {
+		t.Errorf("expected both updates delivered, got %d", len(updates))
+	}
+}
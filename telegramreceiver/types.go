@@ -1,30 +1,400 @@
 package telegramreceiver
 
+import (
+	"encoding/json"
+	"time"
+	"unicode/utf16"
+)
+
 // TelegramUpdate represents an incoming update from Telegram webhook.
 // See https://core.telegram.org/bots/api#update
 type TelegramUpdate struct {
-	UpdateID      int            `json:"update_id"`
-	Message       *Message       `json:"message,omitempty"`
-	EditedMessage *Message       `json:"edited_message,omitempty"`
-	CallbackQuery *CallbackQuery `json:"callback_query,omitempty"`
+	UpdateID              int            `json:"update_id"`
+	Message               *Message       `json:"message,omitempty"`
+	EditedMessage         *Message       `json:"edited_message,omitempty"`
+	ChannelPost           *Message       `json:"channel_post,omitempty"`
+	EditedChannelPost     *Message       `json:"edited_channel_post,omitempty"`
+	BusinessMessage       *Message       `json:"business_message,omitempty"` // Sent on behalf of a connected business account
+	EditedBusinessMessage *Message       `json:"edited_business_message,omitempty"`
+	CallbackQuery         *CallbackQuery `json:"callback_query,omitempty"`
+
+	// ReceivedAt is when this process decoded the update (not serialized).
+	ReceivedAt time.Time `json:"-"`
+
+	// Extra holds the values of top-level fields decoded by custom
+	// decoders, keyed by field name (see WithCustomUpdateDecoder).
+	Extra map[string]any `json:"-"`
+}
+
+// UpdateType identifies which optional field of a TelegramUpdate is set.
+// Values match the names used in allowed_updates.
+type UpdateType string
+
+// Update types defined by the Bot API. Type only reports the ones this
+// package models; all of them can be used to filter allowed_updates.
+const (
+	UpdateTypeUnknown                 UpdateType = ""
+	UpdateTypeMessage                 UpdateType = "message"
+	UpdateTypeEditedMessage           UpdateType = "edited_message"
+	UpdateTypeChannelPost             UpdateType = "channel_post"
+	UpdateTypeEditedChannelPost       UpdateType = "edited_channel_post"
+	UpdateTypeBusinessConnection      UpdateType = "business_connection"
+	UpdateTypeBusinessMessage         UpdateType = "business_message"
+	UpdateTypeEditedBusinessMessage   UpdateType = "edited_business_message"
+	UpdateTypeDeletedBusinessMessages UpdateType = "deleted_business_messages"
+	UpdateTypeMessageReaction         UpdateType = "message_reaction"
+	UpdateTypeMessageReactionCount    UpdateType = "message_reaction_count"
+	UpdateTypeInlineQuery             UpdateType = "inline_query"
+	UpdateTypeChosenInlineResult      UpdateType = "chosen_inline_result"
+	UpdateTypeCallbackQuery           UpdateType = "callback_query"
+	UpdateTypeShippingQuery           UpdateType = "shipping_query"
+	UpdateTypePreCheckoutQuery        UpdateType = "pre_checkout_query"
+	UpdateTypePurchasedPaidMedia      UpdateType = "purchased_paid_media"
+	UpdateTypePoll                    UpdateType = "poll"
+	UpdateTypePollAnswer              UpdateType = "poll_answer"
+	UpdateTypeMyChatMember            UpdateType = "my_chat_member"
+	UpdateTypeChatMember              UpdateType = "chat_member"
+	UpdateTypeChatJoinRequest         UpdateType = "chat_join_request"
+	UpdateTypeChatBoost               UpdateType = "chat_boost"
+	UpdateTypeRemovedChatBoost        UpdateType = "removed_chat_boost"
+)
+
+// IsEdit reports whether the update type carries an edited message.
+func (t UpdateType) IsEdit() bool {
+	switch t {
+	case UpdateTypeEditedMessage, UpdateTypeEditedChannelPost, UpdateTypeEditedBusinessMessage:
+		return true
+	default:
+		return false
+	}
+}
+
+// Type classifies the update by the variant it carries.
+// It returns UpdateTypeUnknown for update kinds this package does not model.
+func (u TelegramUpdate) Type() UpdateType {
+	switch {
+	case u.Message != nil:
+		return UpdateTypeMessage
+	case u.EditedMessage != nil:
+		return UpdateTypeEditedMessage
+	case u.ChannelPost != nil:
+		return UpdateTypeChannelPost
+	case u.EditedChannelPost != nil:
+		return UpdateTypeEditedChannelPost
+	case u.BusinessMessage != nil:
+		return UpdateTypeBusinessMessage
+	case u.EditedBusinessMessage != nil:
+		return UpdateTypeEditedBusinessMessage
+	case u.CallbackQuery != nil:
+		return UpdateTypeCallbackQuery
+	default:
+		return UpdateTypeUnknown
+	}
+}
+
+// UpdateChatID returns the ID of the chat an update belongs to, taken from
+// whichever variant is present. It returns false when the update carries
+// no chat, e.g. a callback query on an inline message.
+func UpdateChatID(u TelegramUpdate) (int64, bool) {
+	chat := u.EffectiveChat()
+	if chat == nil && u.CallbackQuery != nil && u.CallbackQuery.Message != nil {
+		chat = u.CallbackQuery.Message.Chat
+	}
+	if chat == nil {
+		return 0, false
+	}
+	return chat.ID, true
+}
+
+// EffectiveMessage returns the message the update carries, checking
+// Message, EditedMessage, ChannelPost, EditedChannelPost, BusinessMessage
+// and EditedBusinessMessage in that order. It returns nil for updates
+// without one, such as callback queries, whose message is the bot's own.
+func (u TelegramUpdate) EffectiveMessage() *Message {
+	for _, msg := range []*Message{
+		u.Message, u.EditedMessage,
+		u.ChannelPost, u.EditedChannelPost,
+		u.BusinessMessage, u.EditedBusinessMessage,
+	} {
+		if msg != nil {
+			return msg
+		}
+	}
+	return nil
+}
+
+// EffectiveChat returns the chat of EffectiveMessage, or nil.
+func (u TelegramUpdate) EffectiveChat() *Chat {
+	if msg := u.EffectiveMessage(); msg != nil {
+		return msg.Chat
+	}
+	return nil
+}
+
+// EffectiveUser returns the user who caused the update: the sender of
+// EffectiveMessage, or the user who pressed a callback button. It returns
+// nil when there is none, e.g. for channel posts. For anonymous admins
+// From is a placeholder; see Message.EffectiveSender.
+func (u TelegramUpdate) EffectiveUser() *User {
+	if msg := u.EffectiveMessage(); msg != nil {
+		return msg.From
+	}
+	if u.CallbackQuery != nil {
+		return u.CallbackQuery.From
+	}
+	return nil
+}
+
+// Contact returns the contact shared in the update's EffectiveMessage, and
+// false if there is none.
+func (u TelegramUpdate) Contact() (*Contact, bool) {
+	if msg := u.EffectiveMessage(); msg != nil && msg.Contact != nil {
+		return msg.Contact, true
+	}
+	return nil, false
+}
+
+// Location returns the location shared in the update's EffectiveMessage,
+// and false if there is none. Live location updates arrive as edited
+// messages (see Message.IsLiveLocation).
+func (u TelegramUpdate) Location() (*Location, bool) {
+	if msg := u.EffectiveMessage(); msg != nil && msg.Location != nil {
+		return msg.Location, true
+	}
+	return nil, false
 }
 
 // Message represents a Telegram message.
 // See https://core.telegram.org/bots/api#message
 type Message struct {
 	MessageID       int             `json:"message_id"`
+	MessageThreadID int             `json:"message_thread_id,omitempty"`
+	IsTopicMessage  bool            `json:"is_topic_message,omitempty"`
 	From            *User           `json:"from,omitempty"`
+	SenderChat      *Chat           `json:"sender_chat,omitempty"` // Set for anonymous admins and channel senders
 	Chat            *Chat           `json:"chat"`
+	ViaBot          *User           `json:"via_bot,omitempty"` // Bot whose inline mode produced the message
 	Date            int             `json:"date"`
+	EditDate        int64           `json:"edit_date,omitempty"` // Unix time of the last edit
 	Text            string          `json:"text,omitempty"`
 	ReplyToMessage  *Message        `json:"reply_to_message,omitempty"`
 	Entities        []MessageEntity `json:"entities,omitempty"`
 	Photo           []PhotoSize     `json:"photo,omitempty"`
 	Document        *Document       `json:"document,omitempty"`
+	Animation       *Animation      `json:"animation,omitempty"` // Document is also set for backward compatibility
 	Caption         string          `json:"caption,omitempty"`
 	CaptionEntities []MessageEntity `json:"caption_entities,omitempty"`
 	Contact         *Contact        `json:"contact,omitempty"`
 	Location        *Location       `json:"location,omitempty"`
+
+	// Albums, signatures and forwarding flags
+	MediaGroupID        string `json:"media_group_id,omitempty"`        // Shared by the messages of one album
+	AuthorSignature     string `json:"author_signature,omitempty"`      // Post author in channels, custom title of anonymous admins
+	HasProtectedContent bool   `json:"has_protected_content,omitempty"` // Cannot be forwarded or saved
+	IsAutomaticForward  bool   `json:"is_automatic_forward,omitempty"`  // Channel post auto-forwarded to the linked discussion group
+
+	Poll              *Poll              `json:"poll,omitempty"`
+	Invoice           *Invoice           `json:"invoice,omitempty"`
+	SuccessfulPayment *SuccessfulPayment `json:"successful_payment,omitempty"`
+
+	// Service messages about chat membership and settings
+	NewChatMembers []User      `json:"new_chat_members,omitempty"`
+	LeftChatMember *User       `json:"left_chat_member,omitempty"`
+	NewChatTitle   string      `json:"new_chat_title,omitempty"`
+	NewChatPhoto   []PhotoSize `json:"new_chat_photo,omitempty"`
+
+	// Group upgraded to a supergroup: sent in the old group with the new ID
+	// and in the new supergroup with the old ID
+	MigrateToChatID   int64 `json:"migrate_to_chat_id,omitempty"`
+	MigrateFromChatID int64 `json:"migrate_from_chat_id,omitempty"`
+
+	ReplyMarkup *ReplyMarkup `json:"reply_markup,omitempty"`
+}
+
+// maxReplyDepth is the number of reply_to_message levels kept when decoding.
+// Telegram only includes one level; deeper nesting in a crafted payload is
+// discarded instead of being decoded recursively.
+const maxReplyDepth = 1
+
+// UnmarshalJSON decodes a Message, capping the reply_to_message chain at maxReplyDepth.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	return m.unmarshalWithDepth(data, maxReplyDepth)
+}
+
+func (m *Message) unmarshalWithDepth(data []byte, depth int) error {
+	type plain Message // drops methods to avoid recursing into UnmarshalJSON
+	aux := struct {
+		*plain
+		ReplyToMessage json.RawMessage `json:"reply_to_message,omitempty"`
+	}{plain: (*plain)(m)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	m.ReplyToMessage = nil
+	if depth > 0 && len(aux.ReplyToMessage) > 0 && string(aux.ReplyToMessage) != "null" {
+		reply := &Message{}
+		if err := reply.unmarshalWithDepth(aux.ReplyToMessage, depth-1); err != nil {
+			return err
+		}
+		m.ReplyToMessage = reply
+	}
+	return nil
+}
+
+// Age returns the time elapsed since Telegram's message Date.
+// Compare with TelegramUpdate.ReceivedAt to measure processing lag.
+func (m *Message) Age() time.Duration {
+	return time.Since(time.Unix(int64(m.Date), 0))
+}
+
+// ThreadID returns the forum topic the message belongs to. Pass it as
+// message_thread_id when replying so the answer lands in the same topic.
+// The second result is false for messages outside a topic.
+func (m *Message) ThreadID() (int, bool) {
+	if !m.IsTopicMessage || m.MessageThreadID == 0 {
+		return 0, false
+	}
+	return m.MessageThreadID, true
+}
+
+// EffectiveSender returns who the message should be attributed to: the
+// sender chat for anonymous group admins and messages sent on behalf of a
+// channel, otherwise the user. In those cases Telegram fills From with a
+// placeholder bot account, so From alone mis-attributes the message.
+// Exactly one result is non-nil unless the message has no sender at all.
+func (m *Message) EffectiveSender() (*User, *Chat) {
+	if m.SenderChat != nil {
+		return nil, m.SenderChat
+	}
+	return m.From, nil
+}
+
+// WasEdited reports whether the message has been edited since it was sent.
+func (m *Message) WasEdited() bool {
+	return m.EditDate != 0
+}
+
+// IsServiceMessage reports whether the message is a service message
+// (members joined or left, chat title or photo changed, group migrated,
+// payment received) rather than content sent by a user.
+func (m *Message) IsServiceMessage() bool {
+	return len(m.NewChatMembers) > 0 ||
+		m.LeftChatMember != nil ||
+		m.NewChatTitle != "" ||
+		len(m.NewChatPhoto) > 0 ||
+		m.MigrateToChatID != 0 ||
+		m.MigrateFromChatID != 0 ||
+		m.SuccessfulPayment != nil
+}
+
+// MigrationEvent describes a group upgraded to a supergroup. Updates for
+// the chat use ToChatID afterwards, so state keyed by FromChatID should be
+// moved.
+type MigrationEvent struct {
+	FromChatID int64
+	ToChatID   int64
+}
+
+// Migration reports the group migration announced by this service
+// message. Telegram sends two such messages, one in each chat; both
+// report the same event.
+func (m *Message) Migration() (MigrationEvent, bool) {
+	if m.Chat == nil {
+		return MigrationEvent{}, false
+	}
+	switch {
+	case m.MigrateToChatID != 0:
+		return MigrationEvent{FromChatID: m.Chat.ID, ToChatID: m.MigrateToChatID}, true
+	case m.MigrateFromChatID != 0:
+		return MigrationEvent{FromChatID: m.MigrateFromChatID, ToChatID: m.Chat.ID}, true
+	default:
+		return MigrationEvent{}, false
+	}
+}
+
+// SentViaBot reports whether the message was sent through a bot's inline mode.
+func (m *Message) SentViaBot() bool {
+	return m.ViaBot != nil
+}
+
+// IsLiveLocation reports whether the message carries a live location.
+func (m *Message) IsLiveLocation() bool {
+	return m.Location != nil && m.Location.LivePeriod > 0
+}
+
+// EffectiveText returns the message text, or the caption for media messages.
+// Use it with EffectiveEntities to parse commands regardless of message kind.
+func (m *Message) EffectiveText() string {
+	if m.Text != "" {
+		return m.Text
+	}
+	return m.Caption
+}
+
+// EffectiveEntities returns the entities matching EffectiveText.
+func (m *Message) EffectiveEntities() []MessageEntity {
+	if m.Text != "" {
+		return m.Entities
+	}
+	return m.CaptionEntities
+}
+
+// URLs returns the links in the message text: "url" entities as written
+// and the targets of "text_link" entities.
+func (m *Message) URLs() []string {
+	return entityURLs(m.Text, m.Entities)
+}
+
+// Mentions returns the @username and text_mention entities in the message text.
+func (m *Message) Mentions() []string {
+	return entityMentions(m.Text, m.Entities)
+}
+
+// CustomEmojiIDs returns the custom emoji IDs used in the message text, or
+// in the caption for media messages, in order of appearance.
+func (m *Message) CustomEmojiIDs() []string {
+	var ids []string
+	for _, e := range m.EffectiveEntities() {
+		if e.Type == "custom_emoji" && e.CustomEmojiID != "" {
+			ids = append(ids, e.CustomEmojiID)
+		}
+	}
+	return ids
+}
+
+// CodeBlock is a code snippet from a "pre" or "code" entity. Language is
+// only set for pre blocks written with one, such as ```go in MarkdownV2.
+type CodeBlock struct {
+	Language string
+	Code     string
+}
+
+// CodeBlocks returns the pre blocks and inline code spans in the message
+// text, or in the caption for media messages, in order of appearance.
+func (m *Message) CodeBlocks() []CodeBlock {
+	text := m.EffectiveText()
+	var blocks []CodeBlock
+	for _, e := range m.EffectiveEntities() {
+		if e.Type != "pre" && e.Type != "code" {
+			continue
+		}
+		if code := e.Text(text); code != "" {
+			blocks = append(blocks, CodeBlock{Language: e.Language, Code: code})
+		}
+	}
+	return blocks
+}
+
+// CaptionURLs is URLs for the caption of a media message.
+func (m *Message) CaptionURLs() []string {
+	return entityURLs(m.Caption, m.CaptionEntities)
+}
+
+// CaptionMentions is Mentions for the caption of a media message.
+func (m *Message) CaptionMentions() []string {
+	return entityMentions(m.Caption, m.CaptionEntities)
 }
 
 // User represents a Telegram user or bot.
@@ -39,6 +409,7 @@ type User struct {
 }
 
 // Chat represents a Telegram chat.
+// Fields below LastName are only populated by GetChat.
 // See https://core.telegram.org/bots/api#chat
 type Chat struct {
 	ID        int64  `json:"id"`
@@ -47,6 +418,69 @@ type Chat struct {
 	Username  string `json:"username,omitempty"`
 	FirstName string `json:"first_name,omitempty"`
 	LastName  string `json:"last_name,omitempty"`
+	IsForum   bool   `json:"is_forum,omitempty"`
+
+	Bio         string `json:"bio,omitempty"`
+	Description string `json:"description,omitempty"`
+	InviteLink  string `json:"invite_link,omitempty"`
+}
+
+// Chat types reported in Chat.Type.
+const (
+	ChatTypePrivate    = "private"
+	ChatTypeGroup      = "group"
+	ChatTypeSupergroup = "supergroup"
+	ChatTypeChannel    = "channel"
+)
+
+// IsPrivate reports whether the chat is a one-on-one chat with a user.
+func (c *Chat) IsPrivate() bool {
+	return c != nil && c.Type == ChatTypePrivate
+}
+
+// IsGroup reports whether the chat is a group or a supergroup.
+func (c *Chat) IsGroup() bool {
+	return c != nil && (c.Type == ChatTypeGroup || c.Type == ChatTypeSupergroup)
+}
+
+// IsChannel reports whether the chat is a channel.
+func (c *Chat) IsChannel() bool {
+	return c != nil && c.Type == ChatTypeChannel
+}
+
+// Chat member statuses returned by getChatMember.
+const (
+	ChatMemberStatusCreator       = "creator"
+	ChatMemberStatusAdministrator = "administrator"
+	ChatMemberStatusMember        = "member"
+	ChatMemberStatusRestricted    = "restricted"
+	ChatMemberStatusLeft          = "left"
+	ChatMemberStatusKicked        = "kicked"
+)
+
+// ChatMember contains information about one member of a chat.
+// Permission fields are only meaningful for the matching Status.
+// See https://core.telegram.org/bots/api#chatmember
+type ChatMember struct {
+	Status      string `json:"status"`
+	User        *User  `json:"user"`
+	CustomTitle string `json:"custom_title,omitempty"`
+	IsAnonymous bool   `json:"is_anonymous,omitempty"`
+	UntilDate   int64  `json:"until_date,omitempty"`
+	IsMember    bool   `json:"is_member,omitempty"`
+
+	CanManageChat      bool `json:"can_manage_chat,omitempty"`
+	CanDeleteMessages  bool `json:"can_delete_messages,omitempty"`
+	CanRestrictMembers bool `json:"can_restrict_members,omitempty"`
+	CanPromoteMembers  bool `json:"can_promote_members,omitempty"`
+	CanChangeInfo      bool `json:"can_change_info,omitempty"`
+	CanInviteUsers     bool `json:"can_invite_users,omitempty"`
+	CanPinMessages     bool `json:"can_pin_messages,omitempty"`
+}
+
+// IsAdmin reports whether the member is the chat creator or an administrator.
+func (m *ChatMember) IsAdmin() bool {
+	return m.Status == ChatMemberStatusCreator || m.Status == ChatMemberStatusAdministrator
 }
 
 // CallbackQuery represents an incoming callback query from a callback button.
@@ -63,12 +497,54 @@ type CallbackQuery struct {
 // MessageEntity represents a special entity in a text message (hashtag, URL, etc.).
 // See https://core.telegram.org/bots/api#messageentity
 type MessageEntity struct {
-	Type     string `json:"type"`
-	Offset   int    `json:"offset"`
-	Length   int    `json:"length"`
-	URL      string `json:"url,omitempty"`
-	User     *User  `json:"user,omitempty"`
-	Language string `json:"language,omitempty"`
+	Type          string `json:"type"`
+	Offset        int    `json:"offset"`
+	Length        int    `json:"length"`
+	URL           string `json:"url,omitempty"`             // For "text_link"
+	User          *User  `json:"user,omitempty"`            // For "text_mention"
+	Language      string `json:"language,omitempty"`        // For "pre"
+	CustomEmojiID string `json:"custom_emoji_id,omitempty"` // For "custom_emoji"; resolve with getCustomEmojiStickers
+}
+
+// Text returns the part of s covered by the entity. Offsets and lengths
+// count UTF-16 code units, as Telegram sends them; pass the Text or Caption
+// the entity belongs to. An entity outside s yields "".
+func (e MessageEntity) Text(s string) string {
+	units := utf16.Encode([]rune(s))
+	if e.Offset < 0 || e.Length < 0 || e.Offset+e.Length > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[e.Offset : e.Offset+e.Length]))
+}
+
+func entityURLs(text string, entities []MessageEntity) []string {
+	var urls []string
+	for _, e := range entities {
+		switch e.Type {
+		case "url":
+			if u := e.Text(text); u != "" {
+				urls = append(urls, u)
+			}
+		case "text_link":
+			if e.URL != "" {
+				urls = append(urls, e.URL)
+			}
+		}
+	}
+	return urls
+}
+
+func entityMentions(text string, entities []MessageEntity) []string {
+	var mentions []string
+	for _, e := range entities {
+		if e.Type != "mention" && e.Type != "text_mention" {
+			continue
+		}
+		if m := e.Text(text); m != "" {
+			mentions = append(mentions, m)
+		}
+	}
+	return mentions
 }
 
 // PhotoSize represents one size of a photo or file/sticker thumbnail.
@@ -92,6 +568,20 @@ type Document struct {
 	FileSize     int64      `json:"file_size,omitempty"`
 }
 
+// Animation represents an animation file (GIF or H.264/MPEG-4 AVC video without sound).
+// See https://core.telegram.org/bots/api#animation
+type Animation struct {
+	FileID       string     `json:"file_id"`
+	FileUniqueID string     `json:"file_unique_id"`
+	Width        int        `json:"width"`
+	Height       int        `json:"height"`
+	Duration     int        `json:"duration"` // Seconds
+	Thumbnail    *PhotoSize `json:"thumbnail,omitempty"`
+	FileName     string     `json:"file_name,omitempty"`
+	MimeType     string     `json:"mime_type,omitempty"`
+	FileSize     int64      `json:"file_size,omitempty"`
+}
+
 // Contact represents a phone contact.
 // See https://core.telegram.org/bots/api#contact
 type Contact struct {
@@ -103,8 +593,216 @@ type Contact struct {
 }
 
 // Location represents a point on the map.
+// Live locations also carry LivePeriod, Heading and ProximityAlertRadius,
+// and are re-delivered as edited_message updates while the user moves.
 // See https://core.telegram.org/bots/api#location
 type Location struct {
-	Longitude float64 `json:"longitude"`
-	Latitude  float64 `json:"latitude"`
+	Longitude            float64 `json:"longitude"`
+	Latitude             float64 `json:"latitude"`
+	HorizontalAccuracy   float64 `json:"horizontal_accuracy,omitempty"`
+	LivePeriod           int     `json:"live_period,omitempty"`
+	Heading              int     `json:"heading,omitempty"`
+	ProximityAlertRadius int     `json:"proximity_alert_radius,omitempty"`
+}
+
+// Poll types reported in Poll.Type.
+const (
+	PollTypeRegular = "regular"
+	PollTypeQuiz    = "quiz"
+)
+
+// Poll contains information about a poll or quiz. Top-level poll updates
+// are not modeled; decode them into a Poll with WithCustomUpdateDecoder.
+// See https://core.telegram.org/bots/api#poll
+type Poll struct {
+	ID                    string          `json:"id"`
+	Question              string          `json:"question"`
+	QuestionEntities      []MessageEntity `json:"question_entities,omitempty"`
+	Options               []PollOption    `json:"options"`
+	TotalVoterCount       int             `json:"total_voter_count"`
+	IsClosed              bool            `json:"is_closed"`
+	IsAnonymous           bool            `json:"is_anonymous"`
+	Type                  string          `json:"type"` // PollTypeRegular or PollTypeQuiz
+	AllowsMultipleAnswers bool            `json:"allows_multiple_answers"`
+
+	// Quiz fields. Telegram only sends CorrectOptionID to the bot that
+	// created the quiz, or once the quiz is closed; nil means not known.
+	CorrectOptionID     *int            `json:"correct_option_id,omitempty"` // Index into Options
+	Explanation         string          `json:"explanation,omitempty"`       // Shown after a wrong answer
+	ExplanationEntities []MessageEntity `json:"explanation_entities,omitempty"`
+
+	OpenPeriod int   `json:"open_period,omitempty"` // Seconds the poll is active after creation
+	CloseDate  int64 `json:"close_date,omitempty"`  // Unix time the poll closes automatically
+}
+
+// IsQuiz reports whether the poll is a quiz with one correct answer.
+func (p *Poll) IsQuiz() bool {
+	return p != nil && p.Type == PollTypeQuiz
+}
+
+// PollOption is one answer option of a poll.
+// See https://core.telegram.org/bots/api#polloption
+type PollOption struct {
+	Text         string          `json:"text"`
+	TextEntities []MessageEntity `json:"text_entities,omitempty"`
+	VoterCount   int             `json:"voter_count"`
+}
+
+// Invoice contains basic information about an invoice.
+// See https://core.telegram.org/bots/api#invoice
+type Invoice struct {
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	StartParameter string `json:"start_parameter"`
+	Currency       string `json:"currency"`
+	TotalAmount    int    `json:"total_amount"`
+}
+
+// SuccessfulPayment contains basic information about a successful payment.
+// TotalAmount is expressed in the smallest units of the currency.
+// See https://core.telegram.org/bots/api#successfulpayment
+type SuccessfulPayment struct {
+	Currency                string `json:"currency"`
+	TotalAmount             int    `json:"total_amount"`
+	InvoicePayload          string `json:"invoice_payload"`
+	TelegramPaymentChargeID string `json:"telegram_payment_charge_id"`
+	ProviderPaymentChargeID string `json:"provider_payment_charge_id"`
+}
+
+// MarkupType identifies the variant carried by a ReplyMarkup.
+type MarkupType string
+
+// Reply markup variants, named after the field that identifies them.
+const (
+	MarkupNone           MarkupType = ""
+	MarkupInlineKeyboard MarkupType = "inline_keyboard"
+	MarkupReplyKeyboard  MarkupType = "keyboard"
+	MarkupRemoveKeyboard MarkupType = "remove_keyboard"
+	MarkupForceReply     MarkupType = "force_reply"
+)
+
+// ReplyMarkup holds the reply_markup of a message. Telegram does not tag the
+// object, so the variant is inferred from its identifying field and exactly
+// one of the typed fields is set. Raw keeps the original JSON, including
+// shapes this package does not know.
+type ReplyMarkup struct {
+	InlineKeyboard *InlineKeyboardMarkup
+	Keyboard       *ReplyKeyboardMarkup
+	RemoveKeyboard *ReplyKeyboardRemove
+	ForceReply     *ForceReply
+
+	Raw json.RawMessage
+}
+
+// UnmarshalJSON decodes the variant identified by the object's keys.
+func (r *ReplyMarkup) UnmarshalJSON(data []byte) error {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+	*r = ReplyMarkup{Raw: append(json.RawMessage(nil), data...)}
+
+	var target any
+	switch {
+	case keys[string(MarkupInlineKeyboard)] != nil:
+		r.InlineKeyboard = &InlineKeyboardMarkup{}
+		target = r.InlineKeyboard
+	case keys[string(MarkupReplyKeyboard)] != nil:
+		r.Keyboard = &ReplyKeyboardMarkup{}
+		target = r.Keyboard
+	case keys[string(MarkupRemoveKeyboard)] != nil:
+		r.RemoveKeyboard = &ReplyKeyboardRemove{}
+		target = r.RemoveKeyboard
+	case keys[string(MarkupForceReply)] != nil:
+		r.ForceReply = &ForceReply{}
+		target = r.ForceReply
+	default:
+		return nil // Unknown variant, available through Raw
+	}
+	return json.Unmarshal(data, target)
+}
+
+// MarshalJSON encodes the typed variant, falling back to Raw.
+func (r ReplyMarkup) MarshalJSON() ([]byte, error) {
+	switch {
+	case r.InlineKeyboard != nil:
+		return json.Marshal(r.InlineKeyboard)
+	case r.Keyboard != nil:
+		return json.Marshal(r.Keyboard)
+	case r.RemoveKeyboard != nil:
+		return json.Marshal(r.RemoveKeyboard)
+	case r.ForceReply != nil:
+		return json.Marshal(r.ForceReply)
+	case len(r.Raw) > 0:
+		return r.Raw, nil
+	default:
+		return []byte("null"), nil
+	}
+}
+
+// MarkupType returns the variant held by the markup, or MarkupNone for a
+// nil or unrecognized markup.
+func (r *ReplyMarkup) MarkupType() MarkupType {
+	switch {
+	case r == nil:
+		return MarkupNone
+	case r.InlineKeyboard != nil:
+		return MarkupInlineKeyboard
+	case r.Keyboard != nil:
+		return MarkupReplyKeyboard
+	case r.RemoveKeyboard != nil:
+		return MarkupRemoveKeyboard
+	case r.ForceReply != nil:
+		return MarkupForceReply
+	default:
+		return MarkupNone
+	}
+}
+
+// InlineKeyboardMarkup is an inline keyboard attached to a message.
+// See https://core.telegram.org/bots/api#inlinekeyboardmarkup
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+// InlineKeyboardButton is one button of an inline keyboard.
+// See https://core.telegram.org/bots/api#inlinekeyboardbutton
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
+}
+
+// ReplyKeyboardMarkup is a custom keyboard with reply options.
+// See https://core.telegram.org/bots/api#replykeyboardmarkup
+type ReplyKeyboardMarkup struct {
+	Keyboard              [][]KeyboardButton `json:"keyboard"`
+	IsPersistent          bool               `json:"is_persistent,omitempty"`
+	ResizeKeyboard        bool               `json:"resize_keyboard,omitempty"`
+	OneTimeKeyboard       bool               `json:"one_time_keyboard,omitempty"`
+	InputFieldPlaceholder string             `json:"input_field_placeholder,omitempty"`
+	Selective             bool               `json:"selective,omitempty"`
+}
+
+// KeyboardButton is one button of a reply keyboard.
+// See https://core.telegram.org/bots/api#keyboardbutton
+type KeyboardButton struct {
+	Text            string `json:"text"`
+	RequestContact  bool   `json:"request_contact,omitempty"`
+	RequestLocation bool   `json:"request_location,omitempty"`
+}
+
+// ReplyKeyboardRemove asks clients to remove the current custom keyboard.
+// See https://core.telegram.org/bots/api#replykeyboardremove
+type ReplyKeyboardRemove struct {
+	RemoveKeyboard bool `json:"remove_keyboard"`
+	Selective      bool `json:"selective,omitempty"`
+}
+
+// ForceReply asks clients to display a reply interface to the user.
+// See https://core.telegram.org/bots/api#forcereply
+type ForceReply struct {
+	ForceReply            bool   `json:"force_reply"`
+	InputFieldPlaceholder string `json:"input_field_placeholder,omitempty"`
+	Selective             bool   `json:"selective,omitempty"`
 }
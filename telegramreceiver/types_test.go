@@ -0,0 +1,1031 @@
+package telegramreceiver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessage_SuccessfulPayment(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 10,
+			"chat": {"id": 100, "type": "private"},
+			"date": 1700000000,
+			"successful_payment": {
+				"currency": "USD",
+				"total_amount": 1999,
+				"invoice_payload": "order-42",
+				"telegram_payment_charge_id": "tg_charge_123",
+				"provider_payment_charge_id": "provider_charge_456"
+			}
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	sp := upd.Message.SuccessfulPayment
+	if sp == nil {
+		t.Fatal("expected SuccessfulPayment to be decoded")
+	}
+	if sp.TelegramPaymentChargeID != "tg_charge_123" {
+		t.Errorf("TelegramPaymentChargeID = %q, want %q", sp.TelegramPaymentChargeID, "tg_charge_123")
+	}
+	if sp.ProviderPaymentChargeID != "provider_charge_456" {
+		t.Errorf("ProviderPaymentChargeID = %q, want %q", sp.ProviderPaymentChargeID, "provider_charge_456")
+	}
+	if sp.TotalAmount != 1999 || sp.Currency != "USD" || sp.InvoicePayload != "order-42" {
+		t.Errorf("unexpected payment fields: %+v", sp)
+	}
+}
+
+func TestMessage_Invoice(t *testing.T) {
+	payload := `{
+		"message_id": 11,
+		"chat": {"id": 100, "type": "private"},
+		"date": 1700000000,
+		"invoice": {
+			"title": "Premium",
+			"description": "One month of premium",
+			"start_parameter": "premium-1m",
+			"currency": "EUR",
+			"total_amount": 500
+		}
+	}`
+
+	var msg Message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	if msg.Invoice == nil {
+		t.Fatal("expected Invoice to be decoded")
+	}
+	if msg.Invoice.Title != "Premium" || msg.Invoice.StartParameter != "premium-1m" || msg.Invoice.TotalAmount != 500 {
+		t.Errorf("unexpected invoice fields: %+v", msg.Invoice)
+	}
+}
+
+func TestMessage_QuizPoll(t *testing.T) {
+	payload := `{
+		"message_id": 12,
+		"chat": {"id": 100, "type": "group"},
+		"date": 1700000000,
+		"poll": {
+			"id": "5001",
+			"question": "Capital of France?",
+			"options": [
+				{"text": "Berlin", "voter_count": 1},
+				{"text": "Paris", "voter_count": 3}
+			],
+			"total_voter_count": 4,
+			"is_closed": true,
+			"is_anonymous": false,
+			"type": "quiz",
+			"allows_multiple_answers": false,
+			"correct_option_id": 1,
+			"explanation": "Paris has been the capital since 987",
+			"explanation_entities": [{"type": "bold", "offset": 0, "length": 5}],
+			"open_period": 60,
+			"close_date": 1700000060
+		}
+	}`
+
+	var msg Message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	poll := msg.Poll
+	if poll == nil {
+		t.Fatal("expected Poll to be decoded")
+	}
+	if !poll.IsQuiz() {
+		t.Errorf("IsQuiz() = false for type %q", poll.Type)
+	}
+	if poll.CorrectOptionID == nil || *poll.CorrectOptionID != 1 {
+		t.Fatalf("CorrectOptionID = %v, want 1", poll.CorrectOptionID)
+	}
+	if got := poll.Options[*poll.CorrectOptionID].Text; got != "Paris" {
+		t.Errorf("correct option = %q, want Paris", got)
+	}
+	if poll.Explanation != "Paris has been the capital since 987" || len(poll.ExplanationEntities) != 1 {
+		t.Errorf("unexpected explanation: %q %+v", poll.Explanation, poll.ExplanationEntities)
+	}
+	if poll.OpenPeriod != 60 || poll.CloseDate != 1700000060 {
+		t.Errorf("OpenPeriod, CloseDate = %d, %d; want 60, 1700000060", poll.OpenPeriod, poll.CloseDate)
+	}
+
+	// Option 0 is a valid answer, so a missing correct_option_id stays nil
+	var regular Poll
+	if err := json.Unmarshal([]byte(`{"id":"5002","type":"regular","options":[]}`), &regular); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if regular.CorrectOptionID != nil || regular.IsQuiz() {
+		t.Errorf("regular poll decoded as quiz: %+v", regular)
+	}
+}
+
+func TestEditedMessage_LiveLocation(t *testing.T) {
+	payload := `{
+		"update_id": 2,
+		"edited_message": {
+			"message_id": 20,
+			"chat": {"id": 100, "type": "private"},
+			"date": 1700000000,
+			"location": {
+				"latitude": 52.5200,
+				"longitude": 13.4050,
+				"horizontal_accuracy": 12.5,
+				"live_period": 900,
+				"heading": 270,
+				"proximity_alert_radius": 100
+			}
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	msg := upd.EditedMessage
+	if msg == nil || msg.Location == nil {
+		t.Fatal("expected edited message with location")
+	}
+	if !msg.IsLiveLocation() {
+		t.Error("expected IsLiveLocation() to be true")
+	}
+
+	loc := msg.Location
+	if loc.HorizontalAccuracy != 12.5 || loc.LivePeriod != 900 || loc.Heading != 270 || loc.ProximityAlertRadius != 100 {
+		t.Errorf("unexpected live location fields: %+v", loc)
+	}
+}
+
+func TestMessage_IsLiveLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *Message
+		want bool
+	}{
+		{"no location", &Message{}, false},
+		{"static location", &Message{Location: &Location{Latitude: 1, Longitude: 2}}, false},
+		{"live location", &Message{Location: &Location{LivePeriod: 60}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.msg.IsLiveLocation(); got != tt.want {
+				t.Errorf("IsLiveLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage_ReplyChainDepthLimit(t *testing.T) {
+	const depth = 1000
+
+	var b strings.Builder
+	for i := 0; i < depth; i++ {
+		fmt.Fprintf(&b, `{"message_id": %d, "chat": {"id": 1, "type": "private"}, "date": 0, "reply_to_message": `, i)
+	}
+	b.WriteString(`{"message_id": -1, "chat": {"id": 1, "type": "private"}, "date": 0}`)
+	b.WriteString(strings.Repeat("}", depth))
+
+	var msg Message
+	if err := json.Unmarshal([]byte(b.String()), &msg); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	if msg.MessageID != 0 {
+		t.Errorf("expected outer message_id 0, got %d", msg.MessageID)
+	}
+	if msg.ReplyToMessage == nil {
+		t.Fatal("expected first reply level to be kept")
+	}
+	if msg.ReplyToMessage.MessageID != 1 {
+		t.Errorf("expected reply message_id 1, got %d", msg.ReplyToMessage.MessageID)
+	}
+	if msg.ReplyToMessage.ReplyToMessage != nil {
+		t.Error("expected reply chain to be truncated after one level")
+	}
+}
+
+func TestMessage_ReplyToMessageNull(t *testing.T) {
+	var msg Message
+	if err := json.Unmarshal([]byte(`{"message_id": 1, "text": "hi", "reply_to_message": null}`), &msg); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if msg.ReplyToMessage != nil {
+		t.Error("expected nil ReplyToMessage for null")
+	}
+	if msg.Text != "hi" {
+		t.Errorf("expected text 'hi', got %q", msg.Text)
+	}
+}
+
+func TestMessage_Age(t *testing.T) {
+	msg := &Message{Date: int(time.Now().Add(-time.Minute).Unix())}
+
+	age := msg.Age()
+	if age < 59*time.Second || age > 62*time.Second {
+		t.Errorf("expected age around 1m, got %v", age)
+	}
+}
+
+func TestTelegramUpdate_ReceivedAtNotSerialized(t *testing.T) {
+	data, err := json.Marshal(TelegramUpdate{UpdateID: 1, ReceivedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if strings.Contains(string(data), "ReceivedAt") || strings.Contains(string(data), "received") {
+		t.Errorf("ReceivedAt should not be serialized, got %s", data)
+	}
+}
+
+func TestChat_TypePredicates(t *testing.T) {
+	tests := []struct {
+		chatType                   string
+		isPrivate, isGroup, isChan bool
+	}{
+		{ChatTypePrivate, true, false, false},
+		{ChatTypeGroup, false, true, false},
+		{ChatTypeSupergroup, false, true, false},
+		{ChatTypeChannel, false, false, true},
+		{"unknown", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.chatType, func(t *testing.T) {
+			c := &Chat{Type: tt.chatType}
+			if got := c.IsPrivate(); got != tt.isPrivate {
+				t.Errorf("IsPrivate() = %v, want %v", got, tt.isPrivate)
+			}
+			if got := c.IsGroup(); got != tt.isGroup {
+				t.Errorf("IsGroup() = %v, want %v", got, tt.isGroup)
+			}
+			if got := c.IsChannel(); got != tt.isChan {
+				t.Errorf("IsChannel() = %v, want %v", got, tt.isChan)
+			}
+		})
+	}
+
+	var nilChat *Chat
+	if nilChat.IsPrivate() || nilChat.IsGroup() || nilChat.IsChannel() {
+		t.Error("nil chat must not match any type")
+	}
+}
+
+func TestUpdateChatID(t *testing.T) {
+	msg := &Message{MessageID: 1, Chat: &Chat{ID: 42, Type: ChatTypePrivate}}
+
+	tests := []struct {
+		name   string
+		update TelegramUpdate
+		wantID int64
+		wantOK bool
+	}{
+		{"message", TelegramUpdate{Message: msg}, 42, true},
+		{"edited_message", TelegramUpdate{EditedMessage: msg}, 42, true},
+		{"channel_post", TelegramUpdate{ChannelPost: msg}, 42, true},
+		{"callback_query", TelegramUpdate{CallbackQuery: &CallbackQuery{ID: "1", Message: msg}}, 42, true},
+		{"inline callback_query", TelegramUpdate{CallbackQuery: &CallbackQuery{ID: "1", InlineMessageID: "abc"}}, 0, false},
+		{"message without chat", TelegramUpdate{Message: &Message{MessageID: 1}}, 0, false},
+		{"empty", TelegramUpdate{UpdateID: 1}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := UpdateChatID(tt.update)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("UpdateChatID() = (%d, %v), want (%d, %v)", id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTelegramUpdate_Effective(t *testing.T) {
+	chat := &Chat{ID: 42, Type: ChatTypeGroup}
+	channel := &Chat{ID: -100, Type: ChatTypeChannel}
+	sender := &User{ID: 7, FirstName: "Ada"}
+	presser := &User{ID: 8, FirstName: "Grace"}
+	msg := &Message{MessageID: 1, From: sender, Chat: chat}
+	post := &Message{MessageID: 2, SenderChat: channel, Chat: channel}
+	botMsg := &Message{MessageID: 3, From: &User{ID: 99, IsBot: true}, Chat: chat}
+
+	tests := []struct {
+		name     string
+		update   TelegramUpdate
+		wantMsg  *Message
+		wantChat *Chat
+		wantUser *User
+	}{
+		{"message", TelegramUpdate{Message: msg}, msg, chat, sender},
+		{"edited_message", TelegramUpdate{EditedMessage: msg}, msg, chat, sender},
+		{"channel_post", TelegramUpdate{ChannelPost: post}, post, channel, nil},
+		{"edited_channel_post", TelegramUpdate{EditedChannelPost: post}, post, channel, nil},
+		{"business_message", TelegramUpdate{BusinessMessage: msg}, msg, chat, sender},
+		{"edited_business_message", TelegramUpdate{EditedBusinessMessage: msg}, msg, chat, sender},
+		{"message before channel_post", TelegramUpdate{Message: msg, ChannelPost: post}, msg, chat, sender},
+		{"callback_query", TelegramUpdate{CallbackQuery: &CallbackQuery{ID: "1", From: presser, Message: botMsg}}, nil, nil, presser},
+		{"empty", TelegramUpdate{UpdateID: 1}, nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.update.EffectiveMessage(); got != tt.wantMsg {
+				t.Errorf("EffectiveMessage() = %+v, want %+v", got, tt.wantMsg)
+			}
+			if got := tt.update.EffectiveChat(); got != tt.wantChat {
+				t.Errorf("EffectiveChat() = %+v, want %+v", got, tt.wantChat)
+			}
+			if got := tt.update.EffectiveUser(); got != tt.wantUser {
+				t.Errorf("EffectiveUser() = %+v, want %+v", got, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestTelegramUpdate_EffectiveNoMessage(t *testing.T) {
+	payload := `{"update_id": 1, "poll_answer": {"poll_id": "5001", "user": {"id": 7, "is_bot": false, "first_name": "Ada"}, "option_ids": [1]}}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if msg, chat, user := upd.EffectiveMessage(), upd.EffectiveChat(), upd.EffectiveUser(); msg != nil || chat != nil || user != nil {
+		t.Errorf("poll_answer: got message %+v, chat %+v, user %+v; want all nil", msg, chat, user)
+	}
+}
+
+func TestTelegramUpdate_DecodeChannelAndBusiness(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"channel_post": {"message_id": 5, "date": 1, "chat": {"id": -100, "type": "channel"}, "text": "news"}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if upd.Type() != UpdateTypeChannelPost || upd.EffectiveMessage().Text != "news" || !upd.EffectiveChat().IsChannel() {
+		t.Errorf("channel_post decoded as %+v", upd)
+	}
+
+	upd = TelegramUpdate{}
+	payload = `{"update_id": 2, "edited_business_message": {"message_id": 6, "date": 1, "chat": {"id": 9, "type": "private"}, "text": "fixed"}}`
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if upd.Type() != UpdateTypeEditedBusinessMessage || upd.EffectiveMessage().Text != "fixed" {
+		t.Errorf("edited_business_message decoded as %+v", upd)
+	}
+}
+
+func TestTelegramUpdate_ContactShare(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 10,
+			"chat": {"id": 100, "type": "private"},
+			"date": 1700000000,
+			"contact": {"phone_number": "+15550100", "first_name": "Ada", "user_id": 77}
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	contact, ok := upd.Contact()
+	if !ok || contact.PhoneNumber != "+15550100" || contact.UserID != 77 {
+		t.Errorf("Contact() = %+v, %v; want the shared contact", contact, ok)
+	}
+	if loc, ok := upd.Location(); ok {
+		t.Errorf("Location() = %+v, want none", loc)
+	}
+}
+
+func TestTelegramUpdate_LocationShare(t *testing.T) {
+	loc := &Location{Latitude: 52.52, Longitude: 13.405, LivePeriod: 900}
+
+	tests := []struct {
+		name   string
+		update TelegramUpdate
+		wantOK bool
+	}{
+		{"message", TelegramUpdate{Message: &Message{MessageID: 1, Location: loc}}, true},
+		{"live location edit", TelegramUpdate{EditedMessage: &Message{MessageID: 1, Location: loc}}, true},
+		{"channel_post", TelegramUpdate{ChannelPost: &Message{MessageID: 1, Location: loc}}, true},
+		{"business_message", TelegramUpdate{BusinessMessage: &Message{MessageID: 1, Location: loc}}, true},
+		{"text message", TelegramUpdate{Message: &Message{MessageID: 1, Text: "hi"}}, false},
+		{"callback_query", TelegramUpdate{CallbackQuery: &CallbackQuery{ID: "1", Message: &Message{Location: loc}}}, false},
+		{"empty", TelegramUpdate{UpdateID: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.update.Location()
+			if ok != tt.wantOK || (ok && got != loc) || (!ok && got != nil) {
+				t.Errorf("Location() = %+v, %v; want ok %v", got, ok, tt.wantOK)
+			}
+			if contact, ok := tt.update.Contact(); ok {
+				t.Errorf("Contact() = %+v, want none", contact)
+			}
+		})
+	}
+}
+
+func TestMessage_ReplyMarkupVariants(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		want   MarkupType
+		check  func(t *testing.T, r *ReplyMarkup)
+	}{
+		{
+			name:   "reply keyboard",
+			markup: `{"keyboard":[[{"text":"Yes"},{"text":"Share phone","request_contact":true}]],"resize_keyboard":true,"one_time_keyboard":true}`,
+			want:   MarkupReplyKeyboard,
+			check: func(t *testing.T, r *ReplyMarkup) {
+				kb := r.Keyboard
+				if len(kb.Keyboard) != 1 || len(kb.Keyboard[0]) != 2 {
+					t.Fatalf("unexpected keyboard layout: %+v", kb.Keyboard)
+				}
+				if !kb.Keyboard[0][1].RequestContact || !kb.ResizeKeyboard || !kb.OneTimeKeyboard {
+					t.Errorf("unexpected keyboard: %+v", kb)
+				}
+			},
+		},
+		{
+			name:   "remove keyboard",
+			markup: `{"remove_keyboard":true,"selective":true}`,
+			want:   MarkupRemoveKeyboard,
+			check: func(t *testing.T, r *ReplyMarkup) {
+				if !r.RemoveKeyboard.RemoveKeyboard || !r.RemoveKeyboard.Selective {
+					t.Errorf("unexpected remove keyboard: %+v", r.RemoveKeyboard)
+				}
+			},
+		},
+		{
+			name:   "force reply",
+			markup: `{"force_reply":true,"input_field_placeholder":"Your name"}`,
+			want:   MarkupForceReply,
+			check: func(t *testing.T, r *ReplyMarkup) {
+				if !r.ForceReply.ForceReply || r.ForceReply.InputFieldPlaceholder != "Your name" {
+					t.Errorf("unexpected force reply: %+v", r.ForceReply)
+				}
+			},
+		},
+		{
+			name:   "inline keyboard",
+			markup: `{"inline_keyboard":[[{"text":"Open","url":"https://example.com"},{"text":"Buy","callback_data":"buy"}]]}`,
+			want:   MarkupInlineKeyboard,
+			check: func(t *testing.T, r *ReplyMarkup) {
+				row := r.InlineKeyboard.InlineKeyboard[0]
+				if row[0].URL != "https://example.com" || row[1].CallbackData != "buy" {
+					t.Errorf("unexpected inline keyboard: %+v", row)
+				}
+			},
+		},
+		{
+			name:   "unknown shape",
+			markup: `{"something_new":true}`,
+			want:   MarkupNone,
+			check: func(t *testing.T, r *ReplyMarkup) {
+				if string(r.Raw) != `{"something_new":true}` {
+					t.Errorf("expected raw markup preserved, got %s", r.Raw)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := `{"message_id":1,"date":0,"chat":{"id":1,"type":"private"},"reply_markup":` + tt.markup + `}`
+			var msg Message
+			if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+			if got := msg.ReplyMarkup.MarkupType(); got != tt.want {
+				t.Fatalf("MarkupType() = %q, want %q", got, tt.want)
+			}
+			tt.check(t, msg.ReplyMarkup)
+
+			// Round trip keeps the same variant
+			encoded, err := json.Marshal(msg)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			var again Message
+			if err := json.Unmarshal(encoded, &again); err != nil {
+				t.Fatalf("re-unmarshal failed: %v", err)
+			}
+			if got := again.ReplyMarkup.MarkupType(); got != tt.want {
+				t.Errorf("round trip MarkupType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage_NoReplyMarkup(t *testing.T) {
+	var msg Message
+	if err := json.Unmarshal([]byte(`{"message_id":1,"date":0}`), &msg); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if msg.ReplyMarkup != nil || msg.ReplyMarkup.MarkupType() != MarkupNone {
+		t.Errorf("expected no markup, got %+v", msg.ReplyMarkup)
+	}
+}
+
+func TestTelegramUpdate_Type(t *testing.T) {
+	msg := &Message{MessageID: 1}
+	tests := []struct {
+		update TelegramUpdate
+		want   UpdateType
+	}{
+		{TelegramUpdate{Message: msg}, UpdateTypeMessage},
+		{TelegramUpdate{EditedMessage: msg}, UpdateTypeEditedMessage},
+		{TelegramUpdate{ChannelPost: msg}, UpdateTypeChannelPost},
+		{TelegramUpdate{EditedChannelPost: msg}, UpdateTypeEditedChannelPost},
+		{TelegramUpdate{BusinessMessage: msg}, UpdateTypeBusinessMessage},
+		{TelegramUpdate{EditedBusinessMessage: msg}, UpdateTypeEditedBusinessMessage},
+		{TelegramUpdate{CallbackQuery: &CallbackQuery{ID: "1"}}, UpdateTypeCallbackQuery},
+		{TelegramUpdate{UpdateID: 1}, UpdateTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := tt.update.Type(); got != tt.want {
+			t.Errorf("Type() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestMessage_TopicThread(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 55,
+			"message_thread_id": 42,
+			"is_topic_message": true,
+			"date": 1700000000,
+			"chat": {"id": -1001234567890, "type": "supergroup", "is_forum": true},
+			"text": "in a topic"
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	msg := upd.Message
+	if msg.MessageThreadID != 42 || !msg.IsTopicMessage {
+		t.Fatalf("unexpected topic fields: thread=%d topic=%v", msg.MessageThreadID, msg.IsTopicMessage)
+	}
+	if id, ok := msg.ThreadID(); !ok || id != 42 {
+		t.Errorf("ThreadID() = (%d, %v), want (42, true)", id, ok)
+	}
+
+	// Reply threads outside forum topics are not reported as topics
+	plain := &Message{MessageID: 1, MessageThreadID: 7}
+	if id, ok := plain.ThreadID(); ok || id != 0 {
+		t.Errorf("ThreadID() = (%d, %v), want (0, false)", id, ok)
+	}
+}
+
+func TestMessage_SenderChat(t *testing.T) {
+	// Anonymous admin: Telegram sets From to the GroupAnonymousBot placeholder
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 9,
+			"from": {"id": 1087968824, "is_bot": true, "first_name": "Group", "username": "GroupAnonymousBot"},
+			"sender_chat": {"id": -1001234567890, "type": "supergroup", "title": "Mods"},
+			"chat": {"id": -1001234567890, "type": "supergroup", "title": "Mods"},
+			"date": 1700000000,
+			"text": "please behave"
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	msg := upd.Message
+	if msg.SenderChat == nil || msg.SenderChat.ID != -1001234567890 {
+		t.Fatalf("expected sender_chat to be decoded, got %+v", msg.SenderChat)
+	}
+	if msg.From == nil || msg.From.Username != "GroupAnonymousBot" {
+		t.Errorf("expected placeholder From to be kept, got %+v", msg.From)
+	}
+
+	user, chat := msg.EffectiveSender()
+	if user != nil || chat != msg.SenderChat {
+		t.Errorf("EffectiveSender() = (%v, %v), want sender chat", user, chat)
+	}
+
+	// Regular message: attributed to the user
+	regular := &Message{From: &User{ID: 42}}
+	if user, chat := regular.EffectiveSender(); chat != nil || user == nil || user.ID != 42 {
+		t.Errorf("EffectiveSender() = (%v, %v), want user 42", user, chat)
+	}
+}
+
+func TestMessage_CaptionEntities(t *testing.T) {
+	// The emoji is two UTF-16 code units, so byte or rune offsets would be wrong
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 3,
+			"date": 1700000000,
+			"chat": {"id": 42, "type": "private"},
+			"photo": [{"file_id": "p1", "file_unique_id": "u1", "width": 90, "height": 90}],
+			"caption": "📷 by @alice at https://example.com/pic",
+			"caption_entities": [
+				{"type": "mention", "offset": 6, "length": 6},
+				{"type": "url", "offset": 16, "length": 23}
+			]
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	msg := upd.Message
+
+	if got := msg.CaptionURLs(); len(got) != 1 || got[0] != "https://example.com/pic" {
+		t.Errorf("CaptionURLs() = %q", got)
+	}
+	if got := msg.CaptionMentions(); len(got) != 1 || got[0] != "@alice" {
+		t.Errorf("CaptionMentions() = %q", got)
+	}
+	if got := msg.URLs(); got != nil {
+		t.Errorf("URLs() on a caption-only message = %q, want nil", got)
+	}
+}
+
+func TestMessage_TextEntities(t *testing.T) {
+	msg := &Message{
+		Text: "see docs and @bob",
+		Entities: []MessageEntity{
+			{Type: "text_link", Offset: 4, Length: 4, URL: "https://example.com/docs"},
+			{Type: "mention", Offset: 13, Length: 4},
+			{Type: "url", Offset: 100, Length: 5}, // out of range, ignored
+		},
+	}
+
+	if got := msg.URLs(); len(got) != 1 || got[0] != "https://example.com/docs" {
+		t.Errorf("URLs() = %q", got)
+	}
+	if got := msg.Mentions(); len(got) != 1 || got[0] != "@bob" {
+		t.Errorf("Mentions() = %q", got)
+	}
+}
+
+func TestMessage_EffectiveText(t *testing.T) {
+	tests := []struct {
+		name         string
+		msg          *Message
+		wantText     string
+		wantEntities int
+	}{
+		{
+			name: "text message",
+			msg: &Message{
+				Text:     "/start now",
+				Entities: []MessageEntity{{Type: "bot_command", Offset: 0, Length: 6}},
+			},
+			wantText:     "/start now",
+			wantEntities: 1,
+		},
+		{
+			name: "captioned photo",
+			msg: &Message{
+				Photo:           []PhotoSize{{FileID: "p1"}},
+				Caption:         "/scan @bob",
+				CaptionEntities: []MessageEntity{{Type: "bot_command", Offset: 0, Length: 5}, {Type: "mention", Offset: 6, Length: 4}},
+			},
+			wantText:     "/scan @bob",
+			wantEntities: 2,
+		},
+		{
+			name:     "photo without caption",
+			msg:      &Message{Photo: []PhotoSize{{FileID: "p1"}}},
+			wantText: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.msg.EffectiveText(); got != tt.wantText {
+				t.Errorf("EffectiveText() = %q, want %q", got, tt.wantText)
+			}
+			entities := tt.msg.EffectiveEntities()
+			if len(entities) != tt.wantEntities {
+				t.Fatalf("EffectiveEntities() returned %d entities, want %d", len(entities), tt.wantEntities)
+			}
+			if len(entities) > 0 && entities[0].Text(tt.msg.EffectiveText()) != strings.Fields(tt.wantText)[0] {
+				t.Errorf("first entity text = %q", entities[0].Text(tt.msg.EffectiveText()))
+			}
+		})
+	}
+}
+
+func TestMessage_EditDate(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"edited_message": {
+			"message_id": 5,
+			"from": {"id": 42, "is_bot": false, "first_name": "Alice"},
+			"chat": {"id": 42, "type": "private"},
+			"date": 1700000000,
+			"edit_date": 1700000060,
+			"text": "fixed typo"
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if !upd.Type().IsEdit() {
+		t.Errorf("expected %q to be an edit", upd.Type())
+	}
+	msg := upd.EditedMessage
+	if !msg.WasEdited() || msg.EditDate != 1700000060 {
+		t.Errorf("expected edit_date 1700000060, got %d", msg.EditDate)
+	}
+
+	if (&Message{Date: 1700000000}).WasEdited() {
+		t.Error("expected WasEdited false without edit_date")
+	}
+	if UpdateTypeMessage.IsEdit() {
+		t.Error("expected message not to be an edit")
+	}
+}
+
+func TestMessage_ServiceMessages(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 6,
+			"from": {"id": 42, "is_bot": false, "first_name": "Alice"},
+			"chat": {"id": -100, "type": "supergroup", "title": "Team"},
+			"date": 1700000000,
+			"new_chat_members": [
+				{"id": 43, "is_bot": false, "first_name": "Bob", "username": "bob"},
+				{"id": 99, "is_bot": true, "first_name": "Helper", "username": "helper_bot"}
+			]
+		}
+	}`
+
+	// Webhook and polling decode the same way; check both entry points
+	var viaPolling []TelegramUpdate
+	if err := json.Unmarshal([]byte("["+payload+"]"), &viaPolling); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	viaWebhook, err := UnmarshalUpdate([]byte(payload))
+	if err != nil {
+		t.Fatalf("UnmarshalUpdate failed: %v", err)
+	}
+
+	for _, upd := range []TelegramUpdate{viaPolling[0], viaWebhook} {
+		msg := upd.Message
+		if !msg.IsServiceMessage() {
+			t.Error("expected a service message")
+		}
+		if len(msg.NewChatMembers) != 2 || msg.NewChatMembers[0].Username != "bob" || !msg.NewChatMembers[1].IsBot {
+			t.Errorf("unexpected new_chat_members: %+v", msg.NewChatMembers)
+		}
+	}
+
+	tests := []struct {
+		name string
+		msg  *Message
+		want bool
+	}{
+		{"left member", &Message{LeftChatMember: &User{ID: 43}}, true},
+		{"new title", &Message{NewChatTitle: "Team 2"}, true},
+		{"new photo", &Message{NewChatPhoto: []PhotoSize{{FileID: "p1"}}}, true},
+		{"text", &Message{Text: "hello"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.msg.IsServiceMessage(); got != tt.want {
+			t.Errorf("%s: IsServiceMessage() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMessage_Animation(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 7,
+			"chat": {"id": 42, "type": "private"},
+			"date": 1700000000,
+			"animation": {
+				"file_id": "anim1", "file_unique_id": "ua1",
+				"width": 320, "height": 240, "duration": 3,
+				"thumbnail": {"file_id": "thumb1", "file_unique_id": "ut1", "width": 90, "height": 68, "file_size": 2048},
+				"file_name": "cat.gif.mp4", "mime_type": "video/mp4", "file_size": 123456
+			},
+			"document": {"file_id": "anim1", "file_unique_id": "ua1", "file_name": "cat.gif.mp4", "mime_type": "video/mp4"}
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	anim := upd.Message.Animation
+	if anim == nil {
+		t.Fatal("expected animation")
+	}
+	if anim.FileID != "anim1" || anim.Width != 320 || anim.Height != 240 || anim.Duration != 3 || anim.MimeType != "video/mp4" || anim.FileSize != 123456 {
+		t.Errorf("unexpected animation: %+v", anim)
+	}
+	if anim.Thumbnail == nil || anim.Thumbnail.FileID != "thumb1" || anim.Thumbnail.Width != 90 {
+		t.Errorf("unexpected thumbnail: %+v", anim.Thumbnail)
+	}
+}
+
+func TestMessage_CustomEmojiAndTextMention(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 8,
+			"chat": {"id": 42, "type": "private"},
+			"date": 1700000000,
+			"text": "hi 👍 Bob 🎉",
+			"entities": [
+				{"type": "custom_emoji", "offset": 3, "length": 2, "custom_emoji_id": "5368324170671202286"},
+				{"type": "text_mention", "offset": 6, "length": 3, "user": {"id": 43, "is_bot": false, "first_name": "Bob"}},
+				{"type": "custom_emoji", "offset": 10, "length": 2, "custom_emoji_id": "5377305978079288312"}
+			]
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	msg := upd.Message
+
+	ids := msg.CustomEmojiIDs()
+	if len(ids) != 2 || ids[0] != "5368324170671202286" || ids[1] != "5377305978079288312" {
+		t.Errorf("CustomEmojiIDs() = %q", ids)
+	}
+	if got := msg.Entities[0].Text(msg.Text); got != "👍" {
+		t.Errorf("custom emoji entity text = %q", got)
+	}
+	mention := msg.Entities[1]
+	if mention.User == nil || mention.User.ID != 43 || mention.Text(msg.Text) != "Bob" {
+		t.Errorf("unexpected text_mention: %+v", mention)
+	}
+	if got := (&Message{Text: "plain"}).CustomEmojiIDs(); got != nil {
+		t.Errorf("expected no custom emoji, got %q", got)
+	}
+}
+
+func TestMessage_ViaBot(t *testing.T) {
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 4,
+			"from": {"id": 42, "is_bot": false, "first_name": "Alice"},
+			"via_bot": {"id": 99, "is_bot": true, "first_name": "Gif", "username": "gif"},
+			"chat": {"id": 42, "type": "private"},
+			"date": 1700000000,
+			"text": "funny.gif"
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	msg := upd.Message
+	if !msg.SentViaBot() || msg.ViaBot.Username != "gif" || !msg.ViaBot.IsBot {
+		t.Errorf("expected via_bot @gif, got %+v", msg.ViaBot)
+	}
+	if msg.From == nil || msg.From.ID != 42 {
+		t.Errorf("expected sender 42, got %+v", msg.From)
+	}
+
+	if (&Message{MessageID: 1}).SentViaBot() {
+		t.Error("expected SentViaBot false without via_bot")
+	}
+}
+
+func TestMessage_MediaGroupAndFlags(t *testing.T) {
+	// An album arrives as separate messages sharing media_group_id
+	payloads := []string{
+		`{"message_id": 20, "date": 1700000000, "chat": {"id": 1, "type": "private"},
+		  "media_group_id": "13579", "photo": [{"file_id": "a", "file_unique_id": "ua", "width": 90, "height": 90}],
+		  "caption": "holiday"}`,
+		`{"message_id": 21, "date": 1700000000, "chat": {"id": 1, "type": "private"},
+		  "media_group_id": "13579", "photo": [{"file_id": "b", "file_unique_id": "ub", "width": 90, "height": 90}],
+		  "has_protected_content": true}`,
+	}
+	var msgs []Message
+	for _, p := range payloads {
+		var msg Message
+		if err := json.Unmarshal([]byte(p), &msg); err != nil {
+			t.Fatalf("unmarshal failed: %v", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	if msgs[0].MediaGroupID != "13579" || msgs[0].MediaGroupID != msgs[1].MediaGroupID {
+		t.Errorf("media group IDs = %q, %q", msgs[0].MediaGroupID, msgs[1].MediaGroupID)
+	}
+	if msgs[0].HasProtectedContent || !msgs[1].HasProtectedContent {
+		t.Errorf("has_protected_content = %v, %v", msgs[0].HasProtectedContent, msgs[1].HasProtectedContent)
+	}
+
+	var fwd Message
+	payload := `{"message_id": 5, "date": 1700000000, "chat": {"id": -100, "type": "supergroup"},
+		"is_automatic_forward": true, "author_signature": "Editor"}`
+	if err := json.Unmarshal([]byte(payload), &fwd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if !fwd.IsAutomaticForward || fwd.AuthorSignature != "Editor" || fwd.MediaGroupID != "" {
+		t.Errorf("unexpected forward fields: %+v", fwd)
+	}
+}
+
+func TestMessage_Migration(t *testing.T) {
+	payloads := map[string]string{
+		"old group":  `{"message_id": 30, "date": 1700000000, "chat": {"id": -4001, "type": "group"}, "migrate_to_chat_id": -1009001}`,
+		"supergroup": `{"message_id": 1, "date": 1700000000, "chat": {"id": -1009001, "type": "supergroup"}, "migrate_from_chat_id": -4001}`,
+	}
+	want := MigrationEvent{FromChatID: -4001, ToChatID: -1009001}
+
+	for name, payload := range payloads {
+		var msg Message
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			t.Fatalf("%s: unmarshal failed: %v", name, err)
+		}
+		if msg.MigrateToChatID == 0 && msg.MigrateFromChatID == 0 {
+			t.Errorf("%s: migration IDs not decoded", name)
+		}
+		ev, ok := msg.Migration()
+		if !ok || ev != want {
+			t.Errorf("%s: Migration() = (%+v, %v), want (%+v, true)", name, ev, ok, want)
+		}
+		if !msg.IsServiceMessage() {
+			t.Errorf("%s: expected a service message", name)
+		}
+	}
+
+	if _, ok := (&Message{Chat: &Chat{ID: 1}, Text: "hi"}).Migration(); ok {
+		t.Error("a regular message must not report a migration")
+	}
+}
+
+func TestMessage_CodeBlocks(t *testing.T) {
+	// Sent as "🚀 Try `go run .`:\n```go\nfmt.Println(\"héllo\")\n```"
+	payload := `{
+		"update_id": 1,
+		"message": {
+			"message_id": 9,
+			"chat": {"id": 42, "type": "private"},
+			"date": 1700000000,
+			"text": "🚀 Try go run .:\nfmt.Println(\"héllo\")",
+			"entities": [
+				{"type": "code", "offset": 7, "length": 8},
+				{"type": "pre", "offset": 17, "length": 20, "language": "go"}
+			]
+		}
+	}`
+
+	var upd TelegramUpdate
+	if err := json.Unmarshal([]byte(payload), &upd); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	blocks := upd.Message.CodeBlocks()
+	want := []CodeBlock{
+		{Code: "go run ."},
+		{Language: "go", Code: `fmt.Println("héllo")`},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("CodeBlocks() = %+v, want %+v", blocks, want)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+	if got := (&Message{Text: "plain"}).CodeBlocks(); got != nil {
+		t.Errorf("expected no code blocks, got %+v", got)
+	}
+}
@@ -0,0 +1,118 @@
+package telegramreceiver
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultWebhookMaxConnections is Telegram's default, used when
+// Config.WebhookMaxConnections is 0.
+const defaultWebhookMaxConnections = 40
+
+// WebhookChange is a setWebhook parameter whose registered value differs
+// from the configured one.
+type WebhookChange struct {
+	Field   string // Parameter name, e.g. "url" or "allowed_updates"
+	Current any    // As reported by getWebhookInfo
+	Desired any    // As auto-registration would set it
+}
+
+// WebhookDiffResult compares the registered webhook with a configuration.
+type WebhookDiffResult struct {
+	Current *WebhookInfo    // As reported by getWebhookInfo
+	Changes []WebhookChange // Empty when registering would change nothing
+
+	// SecretToken reports whether registering sets a secret token.
+	// getWebhookInfo does not reveal the current one, so a changed secret
+	// never appears in Changes.
+	SecretToken bool
+}
+
+// Changed reports whether registering the configuration would change the
+// webhook.
+func (r *WebhookDiffResult) Changed() bool {
+	return len(r.Changes) > 0
+}
+
+// String lists the changes as `field: current -> desired`, separated by
+// semicolons, or "no changes".
+func (r *WebhookDiffResult) String() string {
+	if !r.Changed() {
+		return "no changes"
+	}
+	parts := make([]string, len(r.Changes))
+	for i, c := range r.Changes {
+		parts[i] = c.Field + ": " + formatDiffValue(c.Current) + " -> " + formatDiffValue(c.Desired)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatDiffValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// WebhookDiff compares the webhook registered with Telegram against what
+// auto-registration would set for cfg (URL, allowed updates, max
+// connections and IP address) without changing anything, for a plan and
+// apply workflow around deployments.
+func WebhookDiff(ctx context.Context, cfg *Config) (*WebhookDiffResult, error) {
+	return WebhookDiffWithClient(ctx, defaultHTTPClient(), cfg)
+}
+
+// WebhookDiffWithClient is WebhookDiff using a custom HTTP client.
+func WebhookDiffWithClient(ctx context.Context, client httpClient, cfg *Config) (*WebhookDiffResult, error) {
+	info, err := GetWebhookInfoWithClient(ctx, client, cfg.BotToken)
+	if err != nil {
+		return nil, fmt.Errorf("getWebhookInfo: %w", err)
+	}
+	return &WebhookDiffResult{
+		Current:     info,
+		Changes:     diffWebhook(info, cfg),
+		SecretToken: cfg.WebhookSecret != "",
+	}, nil
+}
+
+// diffWebhook returns the parameters setWebhook would change. Settings
+// Telegram keeps when they are left out, such as an empty allowed updates
+// list, and values getWebhookInfo does not report are not compared.
+func diffWebhook(info *WebhookInfo, cfg *Config) []WebhookChange {
+	want := webhookRequest(cfg)
+
+	var changes []WebhookChange
+	if info.URL != want.URL {
+		changes = append(changes, WebhookChange{Field: "url", Current: info.URL, Desired: want.URL})
+	}
+	if len(want.AllowedUpdates) > 0 {
+		current := slices.Sorted(slices.Values(info.AllowedUpdates))
+		desired := slices.Sorted(slices.Values(want.AllowedUpdates))
+		if !slices.Equal(current, desired) {
+			changes = append(changes, WebhookChange{Field: "allowed_updates", Current: current, Desired: desired})
+		}
+	}
+	if info.MaxConnections != 0 && info.MaxConnections != want.MaxConnections {
+		changes = append(changes, WebhookChange{Field: "max_connections", Current: info.MaxConnections, Desired: want.MaxConnections})
+	}
+	if want.IPAddress != "" && info.IPAddress != want.IPAddress {
+		changes = append(changes, WebhookChange{Field: "ip_address", Current: info.IPAddress, Desired: want.IPAddress})
+	}
+	return changes
+}
+
+// webhookRequest returns the setWebhook parameters auto-registration sends
+// for cfg.
+func webhookRequest(cfg *Config) setWebhookRequest {
+	return setWebhookRequest{
+		URL:            cfg.WebhookURL,
+		SecretToken:    cfg.WebhookSecret,
+		MaxConnections: cmp.Or(cfg.WebhookMaxConnections, defaultWebhookMaxConnections),
+		IPAddress:      cfg.WebhookIPAddress,
+		AllowedUpdates: cfg.AllowedUpdates,
+	}
+}
@@ -0,0 +1,91 @@
+package telegramreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWebhookDiff(t *testing.T) {
+	const registered = `{"ok":true,"result":{"url":"https://example.com/hook","pending_update_count":0,` +
+		`"max_connections":40,"ip_address":"203.0.113.5","allowed_updates":["message","callback_query"]}}`
+
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantChanges []string // Changed fields
+		wantString  string
+	}{
+		{
+			name: "no change",
+			cfg: Config{
+				WebhookURL:     "https://example.com/hook",
+				AllowedUpdates: []string{"callback_query", "message"},
+			},
+			wantString: "no changes",
+		},
+		{
+			name:        "URL change",
+			cfg:         Config{WebhookURL: "https://example.com/new"},
+			wantChanges: []string{"url"},
+			wantString:  `url: "https://example.com/hook" -> "https://example.com/new"`,
+		},
+		{
+			name: "allowed updates change",
+			cfg: Config{
+				WebhookURL:     "https://example.com/hook",
+				AllowedUpdates: []string{"message"},
+			},
+			wantChanges: []string{"allowed_updates"},
+			wantString:  "allowed_updates: [callback_query message] -> [message]",
+		},
+		{
+			name: "max connections and IP address change",
+			cfg: Config{
+				WebhookURL:            "https://example.com/hook",
+				WebhookMaxConnections: 100,
+				WebhookIPAddress:      "203.0.113.9",
+			},
+			wantChanges: []string{"max_connections", "ip_address"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasSuffix(r.URL.Path, "/getWebhookInfo") {
+					t.Errorf("unexpected call %s: WebhookDiff must not change the webhook", r.URL.Path)
+				}
+				w.Write([]byte(registered))
+			}))
+			defer server.Close()
+
+			tt.cfg.BotToken = SecretToken(testBotToken)
+			tt.cfg.WebhookSecret = "secret"
+			diff, err := WebhookDiffWithClient(context.Background(), newTestAPIClient(server), &tt.cfg)
+			if err != nil {
+				t.Fatalf("WebhookDiff: %v", err)
+			}
+
+			var fields []string
+			for _, c := range diff.Changes {
+				fields = append(fields, c.Field)
+			}
+			if !slices.Equal(fields, tt.wantChanges) {
+				t.Errorf("changed fields = %v, want %v", fields, tt.wantChanges)
+			}
+			if diff.Changed() != (len(tt.wantChanges) > 0) {
+				t.Errorf("Changed() = %v", diff.Changed())
+			}
+			if tt.wantString != "" && diff.String() != tt.wantString {
+				t.Errorf("String() = %q, want %q", diff.String(), tt.wantString)
+			}
+			if !diff.SecretToken || diff.Current.IPAddress != "203.0.113.5" {
+				t.Errorf("unexpected result %+v", diff)
+			}
+		})
+	}
+}